@@ -16,34 +16,245 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/apikey"
+	"github.com/nglmq/avito-shop/internal/app/audit"
 	"github.com/nglmq/avito-shop/internal/app/auth"
+	"github.com/nglmq/avito-shop/internal/app/events"
+	"github.com/nglmq/avito-shop/internal/app/fraud"
 	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/app/leaderboard"
+	"github.com/nglmq/avito-shop/internal/app/lockout"
 	"github.com/nglmq/avito-shop/internal/app/merch"
+	"github.com/nglmq/avito-shop/internal/app/outbox"
+	"github.com/nglmq/avito-shop/internal/app/payment"
+	"github.com/nglmq/avito-shop/internal/app/schedule"
+	"github.com/nglmq/avito-shop/internal/app/webhook"
 	md "github.com/nglmq/avito-shop/internal/middleware"
+	"github.com/nglmq/avito-shop/internal/models"
+	ujwt "github.com/nglmq/avito-shop/internal/utils/jwt"
+	"github.com/nglmq/avito-shop/internal/utils/worker"
 )
 
 func main() {
 	config.ParseFlags()
 
+	jwtKeys, err := ujwt.LoadKeySet(config.JWTSigningKeyFile, config.JWTVerificationKeyFiles)
+	if err != nil {
+		log.Fatalf("jwt keys: %s", err)
+	}
+	ujwt.SetKeySet(jwtKeys)
+
 	storage, _ := postgresql.NewRepo(context.Background(), config.DatabaseDSN)
 	logger := slog.New(
 		slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
 	)
 
-	authService := auth.New(logger, storage)
-	infoService := history.New(logger, storage)
-	txService := transaction.New(logger, storage)
-	merchService := merch.New(logger, storage)
+	eventBus := events.New()
+	webhookService := webhook.New(logger, storage,
+		webhook.WithHTTPClient(&http.Client{Timeout: config.WebhookTimeout}),
+		webhook.WithRetryPolicy(config.WebhookMaxAttempts, config.WebhookRetryBase),
+	)
+
+	outboxOptions := []outbox.Option{
+		outbox.WithMaxAttempts(config.OutboxMaxAttempts),
+		outbox.WithSink(eventBus),
+		outbox.WithSink(webhookService),
+	}
+	if config.OutboxLog {
+		outboxOptions = append(outboxOptions, outbox.WithSink(outbox.NewLogSink(logger)))
+	}
+	if config.OutboxFile != "" {
+		fileSink, err := outbox.NewFileSink(config.OutboxFile)
+		if err != nil {
+			log.Fatalf("outbox: %s", err)
+		}
+		defer fileSink.Close()
+		outboxOptions = append(outboxOptions, outbox.WithSink(fileSink))
+	}
+	publisher := outbox.New(logger, storage, outboxOptions...)
+	auditService := audit.New(logger, storage)
+
+	userPolicy, ipPolicy := lockout.DefaultUserPolicy, lockout.DefaultIPPolicy
+	userPolicy.LockoutAfter, userPolicy.LockoutDuration = config.LoginLockoutAfter, config.LoginLockoutDuration
+	ipPolicy.LockoutAfter, ipPolicy.LockoutDuration = config.LoginLockoutAfterPerIP, config.LoginLockoutDuration
+	lockoutService := lockout.New(logger, storage, lockout.WithPolicies(userPolicy, ipPolicy))
+
+	authService := auth.New(logger, storage,
+		auth.WithAuditor(auditService),
+		auth.WithLoginGuard(lockoutService),
+		auth.WithAutoRegister(config.AutoRegister),
+		auth.WithTokenTTL(config.AccessTokenTTL, config.RefreshTokenTTL),
+		auth.WithPasswordResetTTL(config.PasswordResetTTL),
+	)
+	if config.BootstrapAdmin != "" {
+		if err := authService.BootstrapAdmin(context.Background(), config.BootstrapAdmin, config.BootstrapAdminPassword); err != nil {
+			log.Fatalf("bootstrap admin: %s", err)
+		}
+	}
+	apiKeyService := apikey.New(logger, storage, apikey.WithAuditor(auditService))
+	infoService := history.New(logger, storage, history.WithFeeAccount(config.FeeAccount))
+
+	fraudRules, err := fraud.LoadRules(config.FraudRulesFile)
+	if err != nil {
+		log.Fatalf("fraud rules: %s", err)
+	}
+	fraudService := fraud.New(logger, storage, fraudRules,
+		fraud.WithPublisher(publisher),
+		fraud.WithFeeAccount(config.FeeAccount),
+	)
+
+	if config.FeeAccount != "" {
+		if exists, err := storage.GetUserByUsername(context.Background(), config.FeeAccount); err != nil || !exists {
+			log.Fatalf("fee account %q does not exist", config.FeeAccount)
+		}
+	}
+
+	txService := transaction.New(logger, storage,
+		transaction.WithPendingTTL(config.PendingTransferTTL),
+		transaction.WithLimits(transaction.Limits{
+			MaxPerTransfer:    config.MaxTransferAmount,
+			MaxSentPerDay:     config.MaxSentPerDay,
+			MaxSentPerWeek:    config.MaxSentPerWeek,
+			MaxReceivedPerDay: config.MaxReceivedPerDay,
+			MinAccountAge:     config.MinAccountAge,
+		}),
+		transaction.WithFraudChecker(fraudService),
+		transaction.WithPublisher(publisher),
+		transaction.WithAuditor(auditService),
+		transaction.WithFeePolicy(transaction.FeePolicy{
+			Percent: config.TransferFeePercent,
+			Flat:    config.TransferFeeFlat,
+			Max:     config.TransferFeeMax,
+			Exempt:  config.FeeExemptUsers,
+			Account: config.FeeAccount,
+		}),
+	)
+	merchService := merch.New(logger, storage,
+		merch.WithPublisher(publisher),
+		merch.WithAuditor(auditService),
+	)
+	scheduleService := schedule.New(logger, storage, txService, schedule.WithAuditor(auditService))
+	paymentService := payment.New(logger, storage, txService,
+		payment.WithRequestTTL(config.PaymentRequestTTL),
+		payment.WithPublisher(publisher),
+		payment.WithAuditor(auditService),
+	)
+	leaderboardService := leaderboard.New(logger, storage,
+		leaderboard.WithCacheTTL(config.LeaderboardCacheTTL),
+		leaderboard.WithFeeAccount(config.FeeAccount),
+	)
 
 	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
 	router.Use(middleware.DefaultLogger)
+	router.Use(md.AuditRequestMiddleware)
+
+	router.Get("/.well-known/jwks.json", handlers.HandleJWKS(jwtKeys))
 
-	authMiddleware := md.CheckAuthMiddleware(logger)
+	authMiddleware := md.CheckAuthMiddleware(logger, authService)
+	apiKeyMiddleware := func(scope string) func(http.Handler) http.Handler {
+		return md.CheckAuthOrAPIKeyMiddleware(logger, authService, apiKeyService, scope)
+	}
 	router.Route("/api/", func(r chi.Router) {
-		r.With(authMiddleware).Get("/info", handlers.HandleGetInfo(infoService))
-		r.With(authMiddleware).Get("/buy/{item}", handlers.HandleBuyItem(merchService))
+		r.With(apiKeyMiddleware(models.APIKeyScopeReadInfo)).Get("/info", handlers.HandleGetInfo(infoService))
+		r.With(apiKeyMiddleware(models.APIKeyScopeReadInfo)).Get("/history", handlers.HandleGetHistory(infoService))
+		r.With(apiKeyMiddleware(models.APIKeyScopeReadInfo)).Get("/history/export", handlers.HandleExportHistory(infoService))
+		r.With(apiKeyMiddleware(models.APIKeyScopeReadInfo)).Get("/stats", handlers.HandleGetStats(infoService))
+		r.With(apiKeyMiddleware(models.APIKeyScopeReadInfo)).Get("/balance", handlers.HandleGetBalanceAsOf(infoService))
+		r.With(authMiddleware).Get("/events", handlers.HandleEvents(eventBus))
+		r.With(apiKeyMiddleware(models.APIKeyScopeBuy)).Get("/buy/{item}", handlers.HandleBuyItem(merchService))
+		r.With(apiKeyMiddleware(models.APIKeyScopeReadInfo)).Get("/purchases", handlers.HandleListPurchases(merchService))
+		r.With(authMiddleware).Get("/leaderboard/{board}", handlers.HandleGetLeaderboard(leaderboardService))
+		r.With(authMiddleware).Put("/leaderboard/optOut", handlers.HandleSetLeaderboardOptOut(leaderboardService))
 		r.Post("/auth", handlers.HandleAuth(authService))
-		r.With(authMiddleware).Post("/sendCoin", handlers.HandleSendCoin(txService))
+		r.Post("/register", handlers.HandleRegister(authService))
+		r.Post("/login", handlers.HandleLogin(authService))
+		r.Post("/token/refresh", handlers.HandleRefreshToken(authService))
+		r.With(authMiddleware).Post("/logout", handlers.HandleLogout(authService))
+		r.With(authMiddleware).Post("/password", handlers.HandleChangePassword(authService))
+		r.Post("/password/reset", handlers.HandleResetPassword(authService))
+		r.With(authMiddleware).Post("/apiKeys", handlers.HandleCreateAPIKey(apiKeyService))
+		r.With(authMiddleware).Get("/apiKeys", handlers.HandleListAPIKeys(apiKeyService))
+		r.With(authMiddleware).Delete("/apiKeys/{id}", handlers.HandleDeleteAPIKey(apiKeyService))
+		r.With(apiKeyMiddleware(models.APIKeyScopeSendCoins)).Post("/sendCoin", handlers.HandleSendCoin(txService))
+		r.With(apiKeyMiddleware(models.APIKeyScopeSendCoins)).Get("/sendCoin/quote", handlers.HandleQuoteTransfer(txService))
+		r.With(apiKeyMiddleware(models.APIKeyScopeSendCoins)).Post("/sendCoin/batch", handlers.HandleSendCoinBatch(txService))
+		r.With(authMiddleware).Get("/transfers/pending", handlers.HandleListPendingTransfers(txService))
+		r.With(authMiddleware).Post("/transfers/{id}/accept", handlers.HandleAcceptPendingTransfer(txService))
+		r.With(authMiddleware).Post("/transfers/{id}/decline", handlers.HandleDeclinePendingTransfer(txService))
+		r.With(authMiddleware).Post("/transfers/scheduled", handlers.HandleCreateScheduledTransfer(scheduleService))
+		r.With(authMiddleware).Get("/transfers/scheduled", handlers.HandleListScheduledTransfers(scheduleService))
+		r.With(authMiddleware).Delete("/transfers/scheduled/{id}", handlers.HandleCancelScheduledTransfer(scheduleService))
+		r.With(authMiddleware).Post("/paymentRequests", handlers.HandleCreatePaymentRequest(paymentService))
+		r.With(authMiddleware).Get("/paymentRequests", handlers.HandleListPaymentRequests(paymentService))
+		r.With(authMiddleware).Post("/paymentRequests/{id}/pay", handlers.HandlePayPaymentRequest(paymentService))
+		r.With(authMiddleware).Post("/paymentRequests/{id}/decline", handlers.HandleDeclinePaymentRequest(paymentService))
+		r.With(authMiddleware).Post("/paymentRequests/{id}/cancel", handlers.HandleCancelPaymentRequest(paymentService))
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(authMiddleware, md.AuditAdminMiddleware(auditService))
+			r.Group(func(r chi.Router) {
+				r.Use(md.RequirePermission(logger, models.PermissionManageUsers))
+				r.Put("/users/{username}/role", handlers.HandleSetUserRole(authService))
+				r.Post("/users/{username}/passwordReset", handlers.HandleIssuePasswordReset(authService))
+				r.Post("/users/{username}/apiKeys", handlers.HandleCreateAPIKey(apiKeyService))
+				r.Get("/users/{username}/apiKeys", handlers.HandleListAPIKeys(apiKeyService))
+				r.Delete("/users/{username}/apiKeys/{id}", handlers.HandleDeleteAPIKey(apiKeyService))
+			})
+			r.With(md.RequirePermission(logger, models.PermissionReadUsers)).
+				Get("/users/{username}/balance", handlers.HandleGetUserBalanceAsOf(infoService))
+			r.With(md.RequirePermission(logger, models.PermissionReverseTransactions)).
+				Post("/transactions/{id}/reverse", handlers.HandleReverseTransaction(txService))
+			r.Group(func(r chi.Router) {
+				r.Use(md.RequirePermission(logger, models.PermissionReadFraud))
+				r.Get("/fraud/rules", handlers.HandleGetFraudRules(fraudService))
+				r.Get("/fraud/flags", handlers.HandleListFraudFlags(fraudService))
+			})
+			r.With(md.RequirePermission(logger, models.PermissionReviewFraud)).
+				Post("/fraud/flags/{id}/review", handlers.HandleReviewFraudFlag(fraudService))
+			r.Group(func(r chi.Router) {
+				r.Use(md.RequirePermission(logger, models.PermissionManageWebhooks))
+				r.Post("/webhooks", handlers.HandleCreateWebhook(webhookService))
+				r.Get("/webhooks", handlers.HandleListWebhooks(webhookService))
+				r.Delete("/webhooks/{id}", handlers.HandleDeleteWebhook(webhookService))
+				r.Get("/webhooks/{id}/deliveries", handlers.HandleListWebhookDeliveries(webhookService))
+			})
+			r.With(md.RequirePermission(logger, models.PermissionReadAudit)).
+				Get("/audit", handlers.HandleListAuditLog(auditService))
+		})
+	})
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go worker.Run(workersCtx, logger, "pending-transfer-expiry", config.WorkerInterval, func(ctx context.Context) error {
+		_, err := txService.ExpirePendingTransfers(ctx)
+		return err
+	})
+	go worker.Run(workersCtx, logger, "scheduled-transfers", config.WorkerInterval, func(ctx context.Context) error {
+		_, err := scheduleService.RunDue(ctx)
+		return err
+	})
+	go worker.Run(workersCtx, logger, "payment-request-expiry", config.WorkerInterval, func(ctx context.Context) error {
+		_, err := paymentService.ExpireRequests(ctx)
+		return err
+	})
+	go publisher.Run(workersCtx, config.WorkerInterval)
+	go worker.Run(workersCtx, logger, "expired-token-cleanup", config.WorkerInterval, func(ctx context.Context) error {
+		_, err := authService.DeleteExpiredTokens(ctx)
+		return err
+	})
+	go worker.Run(workersCtx, logger, "login-attempts-cleanup", config.WorkerInterval, func(ctx context.Context) error {
+		_, err := lockoutService.DeleteStale(ctx)
+		return err
+	})
+	go worker.Run(workersCtx, logger, "event-history-cleanup", config.WorkerInterval, func(ctx context.Context) error {
+		eventBus.Prune(time.Now().UTC())
+		return nil
+	})
+	go worker.Run(workersCtx, logger, "webhook-delivery", config.WorkerInterval, func(ctx context.Context) error {
+		_, err := webhookService.DeliverDue(ctx)
+		return err
 	})
 
 	srv := &http.Server{
@@ -51,6 +262,7 @@ func main() {
 		Handler:           router,
 		ReadHeaderTimeout: 2 * time.Second,
 	}
+	srv.RegisterOnShutdown(eventBus.Close)
 
 	logger.Info("Starting server on port :8080")
 
@@ -69,6 +281,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutdown Server ...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -8,7 +8,6 @@ require (
 	github.com/golang-jwt/jwt/v4 v4.5.1
 	github.com/jackc/pgerrcode v0.0.0-20240316143900-6e2875d9b438
 	github.com/jackc/pgx/v5 v5.7.2
-	github.com/lib/pq v1.10.9
 	github.com/stretchr/testify v1.8.4
 	github.com/tsenart/vegeta/v12 v12.12.0
 	golang.org/x/crypto v0.33.0
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/nglmq/avito-shop/internal/app/apikey"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+// apiKeyOwner returns the authenticated user and whose keys the request
+// manages: the user named in the path on admin routes, the authenticated
+// user otherwise.
+func apiKeyOwner(r *http.Request) (actor, owner string, ok bool) {
+	actor, ok = r.Context().Value("user").(string)
+	if !ok {
+		return "", "", false
+	}
+
+	if owner = chi.URLParam(r, "username"); owner == "" {
+		owner = actor
+	}
+
+	return actor, owner, true
+}
+
+func HandleCreateAPIKey(s apikey.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, username, ok := apiKeyOwner(r)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleCreateAPIKey", ErrUnauthorized)
+			return
+		}
+
+		var req models.CreateAPIKeyRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleCreateAPIKey", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleCreateAPIKey", ErrInvalidBody)
+			return
+		}
+
+		key, err := s.CreateKey(r.Context(), actor, username, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, apikey.ErrInvalidScope), errors.Is(err, apikey.ErrInvalidExpiry):
+				respondWithError(w, http.StatusBadRequest, "HandleCreateAPIKey", err)
+			case errors.Is(err, apikey.ErrTooManyKeys):
+				respondWithError(w, http.StatusConflict, "HandleCreateAPIKey", err)
+			case errors.Is(err, storage.ErrUserNotFound):
+				respondWithError(w, http.StatusNotFound, "HandleCreateAPIKey", err)
+			default:
+				respondWithError(w, http.StatusInternalServerError, "HandleCreateAPIKey", ErrInternal)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(key); err != nil {
+			return
+		}
+	}
+}
+
+func HandleListAPIKeys(s apikey.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, username, ok := apiKeyOwner(r)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleListAPIKeys", ErrUnauthorized)
+			return
+		}
+
+		keys, err := s.ListKeys(r.Context(), username)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "HandleListAPIKeys", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(keys); err != nil {
+			return
+		}
+	}
+}
+
+func HandleDeleteAPIKey(s apikey.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, username, ok := apiKeyOwner(r)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleDeleteAPIKey", ErrUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleDeleteAPIKey", ErrInvalidID)
+			return
+		}
+
+		if err := s.DeleteKey(r.Context(), actor, username, id); err != nil {
+			if errors.Is(err, apikey.ErrKeyNotFound) {
+				respondWithError(w, http.StatusNotFound, "HandleDeleteAPIKey", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleDeleteAPIKey", ErrInternal)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/apikey"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func createAPIKeyRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/apiKeys", bytes.NewBufferString(body))
+	return req.WithContext(context.WithValue(req.Context(), "user", "bot"))
+}
+
+func deleteAPIKeyRequest(id string) *http.Request {
+	req := httptest.NewRequest(http.MethodDelete, "/api/apiKeys/"+id, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user", "bot")
+	return req.WithContext(ctx)
+}
+
+func TestHandleCreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			request:        createAPIKeyRequest(`{"name":"bot","scopes":["coins:send"]}`),
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "MissingScopes",
+			request:        createAPIKeyRequest(`{"name":"bot"}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidScope",
+			request:        createAPIKeyRequest(`{"name":"bot","scopes":["admin"]}`),
+			err:            apikey.ErrInvalidScope,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "TooManyKeys",
+			request:        createAPIKeyRequest(`{"name":"bot","scopes":["coins:send"]}`),
+			err:            apikey.ErrTooManyKeys,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "UserNotFound",
+			request:        createAPIKeyRequest(`{"name":"bot","scopes":["coins:send"]}`),
+			err:            storage.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "InternalError",
+			request:        createAPIKeyRequest(`{"name":"bot","scopes":["coins:send"]}`),
+			err:            errors.New("db down"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleCreateAPIKey(&apikey.ServiceMock{
+				CreateKeyFunc: func(ctx context.Context, actor, username string, req models.CreateAPIKeyRequest) (models.APIKey, error) {
+					return models.APIKey{ID: 1, Username: username, Key: apikey.KeyPrefix + "secret"}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteAPIKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			request:        deleteAPIKeyRequest("3"),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "InvalidID",
+			request:        deleteAPIKeyRequest("three"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "NotFound",
+			request:        deleteAPIKeyRequest("3"),
+			err:            apikey.ErrKeyNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleDeleteAPIKey(&apikey.ServiceMock{
+				DeleteKeyFunc: func(ctx context.Context, actor, username string, id int64) error {
+					return tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteAPIKeyAsAdmin(t *testing.T) {
+	var gotActor, gotOwner string
+	handler := handlers.HandleDeleteAPIKey(&apikey.ServiceMock{
+		DeleteKeyFunc: func(ctx context.Context, actor, username string, id int64) error {
+			gotActor, gotOwner = actor, username
+			return nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/users/bot/apiKeys/3", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("username", "bot")
+	rctx.URLParams.Add("id", "3")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user", "admin")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req.WithContext(ctx))
+
+	if status := rr.Code; status != http.StatusNoContent {
+		t.Errorf("expected status code %v, got %v", http.StatusNoContent, status)
+	}
+	if gotActor != "admin" || gotOwner != "bot" {
+		t.Errorf("expected admin to delete bot's key, got actor %q and owner %q", gotActor, gotOwner)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+func HandleListAuditLog(s audit.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditFilter(r.URL.Query())
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleListAuditLog", ErrInvalidQuery)
+			return
+		}
+
+		page, err := s.List(r.Context(), filter)
+		if err != nil {
+			if errors.Is(err, audit.ErrInvalidFilter) {
+				respondWithError(w, http.StatusBadRequest, "HandleListAuditLog", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleListAuditLog", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			return
+		}
+	}
+}
+
+// parseAuditFilter reads actor, action, from, to (RFC 3339), before and limit
+// from the query string.
+func parseAuditFilter(query url.Values) (models.AuditFilter, error) {
+	filter := models.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+	}
+
+	for key, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		v := query.Get(key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return models.AuditFilter{}, err
+		}
+		t = t.UTC()
+		*dst = &t
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return models.AuditFilter{}, ErrInvalidQuery
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("before"); v != "" {
+		before, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || before <= 0 {
+			return models.AuditFilter{}, ErrInvalidQuery
+		}
+		filter.Before = before
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,76 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandleListAuditLog(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		err            error
+		expectedStatus int
+		check          func(t *testing.T, filter models.AuditFilter)
+	}{
+		{
+			name:           "Success",
+			query:          "?actor=alice&action=send&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z&before=40&limit=10",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, filter models.AuditFilter) {
+				if filter.Actor != "alice" || filter.Action != models.AuditActionSend || filter.Before != 40 || filter.Limit != 10 {
+					t.Errorf("unexpected filter %+v", filter)
+				}
+				if filter.To == nil || !filter.To.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("unexpected to %v", filter.To)
+				}
+			},
+		},
+		{
+			name:           "InvalidDate",
+			query:          "?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidBefore",
+			query:          "?before=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidFilter",
+			err:            audit.ErrInvalidFilter,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InternalError",
+			err:            errors.New("db down"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleListAuditLog(&audit.ServiceMock{
+				ListFunc: func(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error) {
+					if tt.check != nil {
+						tt.check(t, filter)
+					}
+					return models.AuditPage{Entries: []models.AuditEntry{}}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/admin/audit"+tt.query, nil))
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
@@ -1,46 +1,151 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/nglmq/avito-shop/internal/app/auth"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/nglmq/avito-shop/internal/models"
 )
 
+// Error codes returned by the auth handlers, so that clients can tell a
+// rejected password from input they need to fix.
+const (
+	AuthErrorCodeValidation         = "validation_failed"
+	AuthErrorCodeInvalidCredentials = "invalid_credentials"
+	AuthErrorCodeUsernameTaken      = "username_taken"
+	AuthErrorCodeInvalidRefresh     = "invalid_refresh_token"
+	AuthErrorCodeRefreshReused      = "refresh_token_reused"
+	AuthErrorCodeInvalidReset       = "invalid_reset_token"
+	AuthErrorCodeTooManyAttempts    = "too_many_attempts"
+)
+
+// HandleAuth logs the user in, registering unknown usernames when the service
+// allows it.
 func HandleAuth(service auth.ServiceInterface) http.HandlerFunc {
+	return handleCredentials("HandleAuth", http.StatusOK, service.AuthenticateUser)
+}
+
+func HandleLogin(service auth.ServiceInterface) http.HandlerFunc {
+	return handleCredentials("HandleLogin", http.StatusOK, service.Login)
+}
+
+func HandleRegister(service auth.ServiceInterface) http.HandlerFunc {
+	return handleCredentials("HandleRegister", http.StatusCreated, service.RegisterUser)
+}
+
+func handleCredentials(
+	handlerName string,
+	successStatus int,
+	issue func(ctx context.Context, username, password string) (models.AuthResponse, error),
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req models.AuthRequest
 		validate := validator.New()
 
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "HandleAuth", ErrInvalidBody)
+			respondWithError(w, http.StatusBadRequest, handlerName, ErrInvalidBody)
 			return
 		}
 
 		err = validate.Struct(req)
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "HandleAuth", ErrInvalidBody)
+			respondWithErrorCode(w, http.StatusBadRequest, handlerName, auth.ErrValidation, AuthErrorCodeValidation)
 			return
 		}
 
-		if req.Username == "" || req.Password == "" {
-			respondWithError(w, http.StatusBadRequest, "HandleAuth", ErrInvalidBody)
+		resp, err := issue(r.Context(), req.Username, req.Password)
+		if err != nil {
+			respondWithAuthError(w, handlerName, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(successStatus)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func HandleRefreshToken(service auth.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.RefreshRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleRefreshToken", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithErrorCode(w, http.StatusBadRequest, "HandleRefreshToken", auth.ErrValidation, AuthErrorCodeValidation)
 			return
 		}
 
-		token, err := service.AuthenticateUser(r.Context(), req.Username, req.Password)
+		resp, err := service.Refresh(r.Context(), req.RefreshToken)
 		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, "HandleAuth", ErrUnauthorized)
+			respondWithAuthError(w, "HandleRefreshToken", err)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(models.AuthResponse{Token: token}); err != nil {
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// HandleLogout revokes the access token the request was made with and the
+// refresh token issued alongside it.
+func HandleLogout(service auth.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleLogout", ErrUnauthorized)
 			return
 		}
+		tokenID, ok := r.Context().Value("tokenId").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleLogout", ErrUnauthorized)
+			return
+		}
+
+		if err := service.Logout(r.Context(), username, tokenID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "HandleLogout", ErrInternal)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func respondWithAuthError(w http.ResponseWriter, handlerName string, err error) {
+	var throttled *auth.ThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		seconds := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		respondWithErrorCode(w, http.StatusTooManyRequests, handlerName, err, AuthErrorCodeTooManyAttempts)
+	case errors.Is(err, auth.ErrValidation):
+		respondWithErrorCode(w, http.StatusBadRequest, handlerName, err, AuthErrorCodeValidation)
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		respondWithErrorCode(w, http.StatusUnauthorized, handlerName, ErrUnauthorized, AuthErrorCodeInvalidCredentials)
+	case errors.Is(err, auth.ErrUsernameTaken):
+		respondWithErrorCode(w, http.StatusConflict, handlerName, err, AuthErrorCodeUsernameTaken)
+	case errors.Is(err, auth.ErrInvalidRefreshToken):
+		respondWithErrorCode(w, http.StatusUnauthorized, handlerName, err, AuthErrorCodeInvalidRefresh)
+	case errors.Is(err, auth.ErrRefreshTokenReused):
+		respondWithErrorCode(w, http.StatusUnauthorized, handlerName, err, AuthErrorCodeRefreshReused)
+	case errors.Is(err, auth.ErrInvalidResetToken):
+		respondWithErrorCode(w, http.StatusUnauthorized, handlerName, err, AuthErrorCodeInvalidReset)
+	default:
+		respondWithError(w, http.StatusInternalServerError, handlerName, ErrInternal)
 	}
 }
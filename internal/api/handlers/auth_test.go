@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func validAuthRequest() models.AuthRequest {
@@ -32,8 +33,8 @@ func TestHandleAuth(t *testing.T) {
 			name:        "Success",
 			requestBody: validAuthRequest(),
 			mockService: &auth.ServiceMock{
-				AuthenticateUserFunc: func(ctx context.Context, username, password string) (string, error) {
-					return "validToken", nil
+				AuthenticateUserFunc: func(ctx context.Context, username, password string) (models.AuthResponse, error) {
+					return models.AuthResponse{Token: "validToken"}, nil
 				},
 			},
 			expectedStatus: http.StatusOK,
@@ -49,12 +50,32 @@ func TestHandleAuth(t *testing.T) {
 			name:        "Unauthorized",
 			requestBody: validAuthRequest(),
 			mockService: &auth.ServiceMock{
-				AuthenticateUserFunc: func(ctx context.Context, username, password string) (string, error) {
-					return "", errors.New("unauthorized")
+				AuthenticateUserFunc: func(ctx context.Context, username, password string) (models.AuthResponse, error) {
+					return models.AuthResponse{}, auth.ErrInvalidCredentials
 				},
 			},
 			expectedStatus: http.StatusUnauthorized,
 		},
+		{
+			name:        "InvalidUsername",
+			requestBody: validAuthRequest(),
+			mockService: &auth.ServiceMock{
+				AuthenticateUserFunc: func(ctx context.Context, username, password string) (models.AuthResponse, error) {
+					return models.AuthResponse{}, auth.ErrInvalidUsername
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "InternalError",
+			requestBody: validAuthRequest(),
+			mockService: &auth.ServiceMock{
+				AuthenticateUserFunc: func(ctx context.Context, username, password string) (models.AuthResponse, error) {
+					return models.AuthResponse{}, errors.New("db down")
+				},
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
 		{
 			name:           "EmptyUsername",
 			requestBody:    models.AuthRequest{Username: "", Password: "validPass"},
@@ -99,3 +120,254 @@ func TestHandleAuth(t *testing.T) {
 		})
 	}
 }
+
+func postCredentials(handler http.HandlerFunc, body any) *http.Response {
+	reqBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/", bytes.NewBuffer(reqBody))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w.Result()
+}
+
+func TestHandleRegister(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    any
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Success",
+			requestBody:    validAuthRequest(),
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "MissingPassword",
+			requestBody:    models.AuthRequest{Username: "validUser"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   handlers.AuthErrorCodeValidation,
+		},
+		{
+			name:           "WeakPassword",
+			requestBody:    validAuthRequest(),
+			err:            auth.ErrInvalidPassword,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   handlers.AuthErrorCodeValidation,
+		},
+		{
+			name:           "UsernameTaken",
+			requestBody:    validAuthRequest(),
+			err:            auth.ErrUsernameTaken,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   handlers.AuthErrorCodeUsernameTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loginCalled bool
+			handler := handlers.HandleRegister(&auth.ServiceMock{
+				RegisterUserFunc: func(ctx context.Context, username, password string) (models.AuthResponse, error) {
+					return models.AuthResponse{Token: "newToken"}, tt.err
+				},
+				LoginFunc: func(ctx context.Context, username, password string) (models.AuthResponse, error) {
+					loginCalled = true
+					return models.AuthResponse{}, nil
+				},
+			})
+
+			resp := postCredentials(handler, tt.requestBody)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v; got %v", tt.expectedStatus, resp.StatusCode)
+			}
+			if loginCalled {
+				t.Fatal("register must not fall back to login")
+			}
+
+			if tt.expectedCode != "" {
+				var errResp models.ErrorResponse
+				if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+					t.Fatalf("could not decode response: %v", err)
+				}
+				if errResp.Code != tt.expectedCode {
+					t.Fatalf("expected code %q; got %q", tt.expectedCode, errResp.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleLogin(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Success",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "WrongPassword",
+			err:            auth.ErrInvalidCredentials,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   handlers.AuthErrorCodeInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleLogin(&auth.ServiceMock{
+				LoginFunc: func(ctx context.Context, username, password string) (models.AuthResponse, error) {
+					if tt.err != nil {
+						return models.AuthResponse{}, tt.err
+					}
+					return models.AuthResponse{Token: "validToken"}, nil
+				},
+				RegisterUserFunc: func(ctx context.Context, username, password string) (models.AuthResponse, error) {
+					t.Fatal("login must not register users")
+					return models.AuthResponse{}, nil
+				},
+			})
+
+			resp := postCredentials(handler, validAuthRequest())
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v; got %v", tt.expectedStatus, resp.StatusCode)
+			}
+
+			if tt.expectedCode != "" {
+				var errResp models.ErrorResponse
+				if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+					t.Fatalf("could not decode response: %v", err)
+				}
+				if errResp.Code != tt.expectedCode {
+					t.Fatalf("expected code %q; got %q", tt.expectedCode, errResp.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleRefreshToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    any
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Success",
+			requestBody:    models.RefreshRequest{RefreshToken: "refresh"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "MissingToken",
+			requestBody:    models.RefreshRequest{},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   handlers.AuthErrorCodeValidation,
+		},
+		{
+			name:           "Invalid",
+			requestBody:    models.RefreshRequest{RefreshToken: "refresh"},
+			err:            auth.ErrInvalidRefreshToken,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   handlers.AuthErrorCodeInvalidRefresh,
+		},
+		{
+			name:           "Reused",
+			requestBody:    models.RefreshRequest{RefreshToken: "refresh"},
+			err:            auth.ErrRefreshTokenReused,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   handlers.AuthErrorCodeRefreshReused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleRefreshToken(&auth.ServiceMock{
+				RefreshFunc: func(ctx context.Context, refreshToken string) (models.AuthResponse, error) {
+					return models.AuthResponse{Token: "newToken", RefreshToken: "newRefresh"}, tt.err
+				},
+			})
+
+			resp := postCredentials(handler, tt.requestBody)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v; got %v", tt.expectedStatus, resp.StatusCode)
+			}
+
+			if tt.expectedCode != "" {
+				var errResp models.ErrorResponse
+				if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+					t.Fatalf("could not decode response: %v", err)
+				}
+				if errResp.Code != tt.expectedCode {
+					t.Fatalf("expected code %q; got %q", tt.expectedCode, errResp.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleLogout(t *testing.T) {
+	var gotUser, gotTokenID string
+	handler := handlers.HandleLogout(&auth.ServiceMock{
+		LogoutFunc: func(ctx context.Context, username, tokenID string) error {
+			gotUser, gotTokenID = username, tokenID
+			return nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/logout", nil)
+	ctx := context.WithValue(req.Context(), "user", "validUser")
+	ctx = context.WithValue(ctx, "tokenId", "jti-1")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req.WithContext(ctx))
+
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status %v; got %v", http.StatusNoContent, rr.Code)
+	}
+	if gotUser != "validUser" || gotTokenID != "jti-1" {
+		t.Fatalf("unexpected logout of %q with token %q", gotUser, gotTokenID)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/logout", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %v; got %v", http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func TestHandleLoginThrottled(t *testing.T) {
+	handler := handlers.HandleLogin(&auth.ServiceMock{
+		LoginFunc: func(ctx context.Context, username, password string) (models.AuthResponse, error) {
+			return models.AuthResponse{}, &auth.ThrottledError{RetryAfter: 1500 * time.Millisecond}
+		},
+	})
+
+	resp := postCredentials(handler, validAuthRequest())
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected status %v; got %v", http.StatusTooManyRequests, resp.StatusCode)
+	}
+	if got := resp.Header.Get("Retry-After"); got != "2" {
+		t.Fatalf("expected Retry-After 2; got %q", got)
+	}
+
+	var errResp models.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if errResp.Code != handlers.AuthErrorCodeTooManyAttempts {
+		t.Fatalf("expected code %q; got %q", handlers.AuthErrorCodeTooManyAttempts, errResp.Code)
+	}
+}
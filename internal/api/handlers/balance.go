@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+// HandleGetBalanceAsOf returns the caller's balance at the time given by the
+// at query parameter (RFC 3339), or now.
+func HandleGetBalanceAsOf(s history.InfoServiceInterface) http.HandlerFunc {
+	return handleBalanceAsOf(s, "HandleGetBalanceAsOf", func(r *http.Request) (string, bool) {
+		username, ok := r.Context().Value("user").(string)
+		return username, ok
+	})
+}
+
+// HandleGetUserBalanceAsOf lets admins query any user's historical balance.
+func HandleGetUserBalanceAsOf(s history.InfoServiceInterface) http.HandlerFunc {
+	return handleBalanceAsOf(s, "HandleGetUserBalanceAsOf", func(r *http.Request) (string, bool) {
+		username := chi.URLParam(r, "username")
+		return username, username != ""
+	})
+}
+
+func handleBalanceAsOf(
+	s history.InfoServiceInterface,
+	handlerName string,
+	subject func(r *http.Request) (string, bool),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value("user").(string); !ok {
+			respondWithError(w, http.StatusUnauthorized, handlerName, ErrUnauthorized)
+			return
+		}
+
+		username, ok := subject(r)
+		if !ok {
+			respondWithError(w, http.StatusBadRequest, handlerName, ErrInvalidQuery)
+			return
+		}
+
+		var at time.Time
+		if v := r.URL.Query().Get("at"); v != "" {
+			var err error
+			at, err = time.Parse(time.RFC3339, v)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, handlerName, ErrInvalidQuery)
+				return
+			}
+		}
+
+		balance, err := s.GetBalanceAsOf(r.Context(), username, at)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrUserNotFound),
+				errors.Is(err, history.ErrBeforeAccountCreated):
+				respondWithError(w, http.StatusNotFound, handlerName, err)
+			default:
+				respondWithError(w, http.StatusInternalServerError, handlerName, ErrInternal)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(balance); err != nil {
+			return
+		}
+	}
+}
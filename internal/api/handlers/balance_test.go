@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandleGetBalanceAsOf(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		user           bool
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			query:          "?at=2026-06-30T23:59:59Z",
+			user:           true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "InvalidTime",
+			query:          "?at=last-quarter",
+			user:           true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "BeforeAccountCreated",
+			query:          "?at=2000-01-01T00:00:00Z",
+			user:           true,
+			err:            history.ErrBeforeAccountCreated,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Unauthorized",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleGetBalanceAsOf(&history.InfoServiceMock{
+				GetBalanceAsOfFunc: func(ctx context.Context, username string, at time.Time) (models.BalanceAsOf, error) {
+					if username != "validUser" {
+						t.Errorf("expected validUser, got %q", username)
+					}
+					if tt.name == "Success" && !at.Equal(time.Date(2026, 6, 30, 23, 59, 59, 0, time.UTC)) {
+						t.Errorf("unexpected time %v", at)
+					}
+					return models.BalanceAsOf{Username: username, At: at, Balance: 700}, tt.err
+				},
+			})
+			req := httptest.NewRequest(http.MethodGet, "/api/balance"+tt.query, nil)
+			if tt.user {
+				req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestHandleGetUserBalanceAsOf(t *testing.T) {
+	var got string
+	handler := handlers.HandleGetUserBalanceAsOf(&history.InfoServiceMock{
+		GetBalanceAsOfFunc: func(ctx context.Context, username string, at time.Time) (models.BalanceAsOf, error) {
+			got = username
+			if username == "ghost" {
+				return models.BalanceAsOf{}, storage.ErrUserNotFound
+			}
+			return models.BalanceAsOf{Username: username, At: at, Balance: 700}, nil
+		},
+	})
+
+	for user, expectedStatus := range map[string]int{"bob": http.StatusOK, "ghost": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/users/"+user+"/balance", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("username", user)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		ctx = context.WithValue(ctx, "user", "admin")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req.WithContext(ctx))
+
+		if rr.Code != expectedStatus {
+			t.Errorf("%s: expected status code %v, got %v", user, expectedStatus, rr.Code)
+		}
+		if got != user {
+			t.Errorf("expected balance of %q, got %q", user, got)
+		}
+	}
+}
@@ -15,6 +15,12 @@ var (
 )
 
 func respondWithError(w http.ResponseWriter, statusCode int, handlerName string, err error) {
+	respondWithErrorCode(w, statusCode, handlerName, err, "")
+}
+
+// respondWithErrorCode adds a machine-readable code to the error response so
+// that clients can tell apart errors sharing a status code.
+func respondWithErrorCode(w http.ResponseWriter, statusCode int, handlerName string, err error, code string) {
 	slog.Error("Error occurred in handler",
 		slog.String("handler", handlerName),
 		slog.String("error", err.Error()))
@@ -24,6 +30,7 @@ func respondWithError(w http.ResponseWriter, statusCode int, handlerName string,
 
 	errorResponse := models.ErrorResponse{
 		Errors: err.Error(),
+		Code:   code,
 	}
 	if err := json.NewEncoder(w).Encode(errorResponse); err != nil {
 		slog.Error("Failed to send error response", "error", err)
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/events"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// eventsKeepAlive is how often an idle stream sends a comment so that
+// proxies do not close it.
+const eventsKeepAlive = 15 * time.Second
+
+var ErrStreamingUnsupported = errors.New("streaming unsupported")
+
+// HandleEvents streams the user's notifications as Server-Sent Events. A
+// client resumes with the Last-Event-ID header, or the lastEventId query
+// parameter where it cannot set headers.
+func HandleEvents(s events.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleEvents", ErrUnauthorized)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			respondWithError(w, http.StatusInternalServerError, "HandleEvents", ErrStreamingUnsupported)
+			return
+		}
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("lastEventId")
+		}
+		var lastID int64
+		if lastEventID != "" {
+			var err error
+			lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+			if err != nil || lastID < 0 {
+				respondWithError(w, http.StatusBadRequest, "HandleEvents", ErrInvalidQuery)
+				return
+			}
+		}
+
+		sub := s.Subscribe(username, lastID)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		for _, ev := range sub.Replay {
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev, ok := <-sub.Events:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, ev); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev models.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+	return err
+}
@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/events"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleEvents(t *testing.T) {
+	bus := events.New()
+	publish := func(id int64, username, eventType string, data any) {
+		bus.Publish(models.Event{ID: id, Type: eventType, Username: username, Data: data, CreatedAt: time.Now().UTC()})
+	}
+	publish(10, "validUser", models.EventCoinsReceived, models.CoinsReceivedEvent{FromUser: "bob", Amount: 5})
+	publish(12, "validUser", models.EventPurchaseCompleted, models.PurchaseCompletedEvent{Item: "cup", Quantity: 1, TotalPrice: 20})
+
+	handler := handlers.HandleEvents(bus)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", "validUser")))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "10")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() []string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("reading stream: %v", err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				return lines
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	replayed := readEvent()
+	if len(replayed) != 3 || replayed[0] != "id: 12" || replayed[1] != "event: "+models.EventPurchaseCompleted {
+		t.Errorf("unexpected replayed event %q", replayed)
+	}
+
+	publish(13, "someoneElse", models.EventCoinsReceived, nil)
+	publish(14, "validUser", models.EventTransferPending, models.PendingTransfer{ID: 7, FromUser: "bob", Amount: 10})
+
+	live := readEvent()
+	if len(live) != 3 || live[0] != "id: 14" || live[1] != "event: "+models.EventTransferPending {
+		t.Errorf("unexpected live event %q", live)
+	}
+	if !strings.Contains(live[2], `"fromUser":"bob"`) {
+		t.Errorf("expected event data in %q", live[2])
+	}
+}
+
+func TestHandleEventsInvalidRequest(t *testing.T) {
+	handler := handlers.HandleEvents(events.New())
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/events", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected status code %v, got %v", http.StatusUnauthorized, rr.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events?lastEventId=abc", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %v, got %v", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+
+	// exportFlushEvery bounds how many entries are buffered before they are
+	// pushed to the client.
+	exportFlushEvery = 100
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format, use csv or ndjson")
+
+var exportCSVHeader = []string{
+	"id", "type", "timestamp", "amount", "fee", "counterparty", "item", "quantity", "status", "kind",
+}
+
+// HandleExportHistory streams the user's whole history as CSV or NDJSON. The
+// format comes from the format query parameter or, failing that, the Accept
+// header; CSV is the default. It accepts the same filters as HandleGetHistory.
+func HandleExportHistory(s history.InfoServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleExportHistory", ErrUnauthorized)
+			return
+		}
+
+		format, ok := exportFormat(r)
+		if !ok {
+			respondWithError(w, http.StatusNotAcceptable, "HandleExportHistory", ErrUnsupportedFormat)
+			return
+		}
+
+		filter, err := parseHistoryFilter(r.URL.Query())
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleExportHistory", ErrInvalidQuery)
+			return
+		}
+
+		var (
+			begin func() error
+			write func(models.HistoryEntry) error
+			flush func() error
+		)
+		switch format {
+		case exportFormatCSV:
+			cw := csv.NewWriter(w)
+			begin = func() error { return cw.Write(exportCSVHeader) }
+			write = func(e models.HistoryEntry) error { return cw.Write(historyCSVRecord(e)) }
+			flush = func() error {
+				cw.Flush()
+				return cw.Error()
+			}
+		default:
+			enc := json.NewEncoder(w)
+			begin = func() error { return nil }
+			write = func(e models.HistoryEntry) error { return enc.Encode(e) }
+			flush = func() error { return nil }
+		}
+
+		// The response starts with the first entry, so that errors raised
+		// before anything is read can still get a proper status code.
+		started := false
+		start := func() error {
+			started = true
+			startExport(w, format)
+			return begin()
+		}
+
+		flusher, _ := w.(http.Flusher)
+		written := 0
+
+		err = s.ExportHistory(r.Context(), username, filter, func(e models.HistoryEntry) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			if err := write(e); err != nil {
+				return err
+			}
+			written++
+			if written%exportFlushEvery == 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+			return nil
+		})
+		if err == nil && !started {
+			err = start()
+		}
+		if err == nil {
+			err = flush()
+		}
+		if err != nil {
+			if !started {
+				if errors.Is(err, history.ErrInvalidFilter) {
+					respondWithError(w, http.StatusBadRequest, "HandleExportHistory", err)
+					return
+				}
+				respondWithError(w, http.StatusInternalServerError, "HandleExportHistory", ErrInternal)
+				return
+			}
+			// The status line is already out; cutting the stream short is
+			// the only way left to signal the failure.
+			slog.Error("Error streaming history export",
+				slog.String("username", username),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+func startExport(w http.ResponseWriter, format string) {
+	contentType := "text/csv; charset=utf-8"
+	if format == exportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="history.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func exportFormat(r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case exportFormatCSV, exportFormatNDJSON:
+		return format, true
+	case "":
+	default:
+		return "", false
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return exportFormatCSV, true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv", "*/*", "text/*":
+			return exportFormatCSV, true
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			return exportFormatNDJSON, true
+		}
+	}
+
+	return "", false
+}
+
+func historyCSVRecord(e models.HistoryEntry) []string {
+	return []string{
+		strconv.FormatInt(e.ID, 10),
+		e.Type,
+		e.Timestamp.UTC().Format(time.RFC3339),
+		strconv.Itoa(e.Amount),
+		strconv.Itoa(e.Fee),
+		e.Counterparty,
+		e.Item,
+		strconv.Itoa(e.Quantity),
+		e.Status,
+		e.Kind,
+	}
+}
@@ -0,0 +1,116 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleExportHistory(t *testing.T) {
+	entries := []models.HistoryEntry{
+		{ID: 2, Type: models.HistoryEntrySent, Timestamp: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC), Amount: 10, Counterparty: "bob"},
+		{ID: 1, Type: models.HistoryEntryPurchase, Timestamp: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Amount: 80, Item: "cup", Quantity: 1},
+	}
+	service := &history.InfoServiceMock{
+		ExportHistoryFunc: func(ctx context.Context, username string, filter models.HistoryFilter, fn func(models.HistoryEntry) error) error {
+			for _, e := range entries {
+				if err := fn(e); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		accept         string
+		expectedStatus int
+		expectedType   string
+		check          func(t *testing.T, body string)
+	}{
+		{
+			name:           "DefaultCSV",
+			expectedStatus: http.StatusOK,
+			expectedType:   "text/csv; charset=utf-8",
+			check: func(t *testing.T, body string) {
+				lines := strings.Split(strings.TrimSpace(body), "\n")
+				if len(lines) != 3 {
+					t.Fatalf("expected header and 2 rows, got %q", body)
+				}
+				if !strings.HasPrefix(lines[0], "id,type,timestamp") {
+					t.Errorf("unexpected header %q", lines[0])
+				}
+				if lines[1] != "2,sent,2026-10-02T00:00:00Z,10,0,bob,,0,," {
+					t.Errorf("unexpected row %q", lines[1])
+				}
+			},
+		},
+		{
+			name:           "NDJSONViaAccept",
+			accept:         "application/x-ndjson",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/x-ndjson",
+			check: func(t *testing.T, body string) {
+				lines := strings.Split(strings.TrimSpace(body), "\n")
+				if len(lines) != 2 {
+					t.Fatalf("expected 2 lines, got %q", body)
+				}
+				var e models.HistoryEntry
+				if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+					t.Fatalf("invalid json line: %v", err)
+				}
+				if e.Item != "cup" {
+					t.Errorf("expected cup, got %+v", e)
+				}
+			},
+		},
+		{
+			name:           "FormatParamWins",
+			query:          "?format=ndjson",
+			accept:         "text/csv",
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/x-ndjson",
+		},
+		{
+			name:           "UnsupportedFormat",
+			query:          "?format=xml",
+			expectedStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:           "UnsupportedAccept",
+			accept:         "application/xml",
+			expectedStatus: http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/history/export"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+			rr := httptest.NewRecorder()
+			handlers.HandleExportHistory(service).ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Fatalf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+			if tt.expectedType != "" && rr.Header().Get("Content-Type") != tt.expectedType {
+				t.Errorf("expected content type %q, got %q", tt.expectedType, rr.Header().Get("Content-Type"))
+			}
+			if tt.check != nil {
+				tt.check(t, rr.Body.String())
+			}
+		})
+	}
+}
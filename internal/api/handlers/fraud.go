@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/nglmq/avito-shop/internal/app/fraud"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+func HandleGetFraudRules(s fraud.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(s.Rules()); err != nil {
+			return
+		}
+	}
+}
+
+func HandleListFraudFlags(s fraud.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flags, err := s.ListFlags(r.Context(), r.URL.Query().Get("status"))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "HandleListFraudFlags", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(flags); err != nil {
+			return
+		}
+	}
+}
+
+func HandleReviewFraudFlag(s fraud.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleReviewFraudFlag", ErrUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleReviewFraudFlag", ErrInvalidID)
+			return
+		}
+
+		var req models.FraudReviewRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleReviewFraudFlag", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleReviewFraudFlag", ErrInvalidBody)
+			return
+		}
+
+		flag, err := s.ReviewFlag(r.Context(), admin, id, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, fraud.ErrFlagNotFound):
+				respondWithError(w, http.StatusNotFound, "HandleReviewFraudFlag", err)
+			case errors.Is(err, fraud.ErrFlagAlreadyReviewed):
+				respondWithError(w, http.StatusConflict, "HandleReviewFraudFlag", err)
+			case errors.Is(err, fraud.ErrInvalidDecision):
+				respondWithError(w, http.StatusBadRequest, "HandleReviewFraudFlag", err)
+			default:
+				respondWithError(w, http.StatusInternalServerError, "HandleReviewFraudFlag", ErrInternal)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(flag); err != nil {
+			return
+		}
+	}
+}
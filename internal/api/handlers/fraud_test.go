@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/fraud"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func reviewFlagRequest(id, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/fraud/flags/"+id+"/review", bytes.NewBufferString(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user", "admin")
+	return req.WithContext(ctx)
+}
+
+func TestHandleListFraudFlags(t *testing.T) {
+	var gotStatus string
+	handler := handlers.HandleListFraudFlags(&fraud.ServiceMock{
+		ListFlagsFunc: func(ctx context.Context, status string) ([]models.FraudFlag, error) {
+			gotStatus = status
+			return []models.FraudFlag{{ID: 1}}, nil
+		},
+	})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/admin/fraud/flags?status=open", nil))
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("expected status code %v, got %v", http.StatusOK, status)
+	}
+	if gotStatus != models.FraudFlagStatusOpen {
+		t.Errorf("expected status filter %q, got %q", models.FraudFlagStatusOpen, gotStatus)
+	}
+}
+
+func TestHandleReviewFraudFlag(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			request:        reviewFlagRequest("3", `{"decision":"approve"}`),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "InvalidDecision",
+			request:        reviewFlagRequest("3", `{"decision":"maybe"}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidID",
+			request:        reviewFlagRequest("three", `{"decision":"reject"}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "NotFound",
+			request:        reviewFlagRequest("3", `{"decision":"reject"}`),
+			err:            fraud.ErrFlagNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "AlreadyReviewed",
+			request:        reviewFlagRequest("3", `{"decision":"reject"}`),
+			err:            fraud.ErrFlagAlreadyReviewed,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "InternalError",
+			request:        reviewFlagRequest("3", `{"decision":"reject"}`),
+			err:            errors.New("db down"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleReviewFraudFlag(&fraud.ServiceMock{
+				ReviewFlagFunc: func(ctx context.Context, admin string, id int64, req models.FraudReviewRequest) (models.FraudFlag, error) {
+					return models.FraudFlag{ID: id, ReviewedBy: admin}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
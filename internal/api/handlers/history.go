@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+var ErrInvalidQuery = errors.New("invalid query parameters")
+
+func HandleGetHistory(s history.InfoServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleGetHistory", ErrUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		filter, err := parseHistoryFilter(query)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleGetHistory", ErrInvalidQuery)
+			return
+		}
+
+		page, err := s.GetHistory(r.Context(), username, filter, query.Get("cursor"))
+		if err != nil {
+			if errors.Is(err, history.ErrInvalidCursor) || errors.Is(err, history.ErrInvalidFilter) {
+				respondWithError(w, http.StatusBadRequest, "HandleGetHistory", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleGetHistory", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			return
+		}
+	}
+}
+
+// parseHistoryFilter reads direction, counterparty, from, to (RFC 3339) and
+// limit from the query string.
+func parseHistoryFilter(query url.Values) (models.HistoryFilter, error) {
+	filter := models.HistoryFilter{
+		Direction:    query.Get("direction"),
+		Counterparty: query.Get("counterparty"),
+	}
+
+	for key, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		v := query.Get(key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return models.HistoryFilter{}, err
+		}
+		t = t.UTC()
+		*dst = &t
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return models.HistoryFilter{}, ErrInvalidQuery
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,85 @@
+package handlers_test
+
+import (
+	"context"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func historyRequest(query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/history"+query, nil)
+	ctx := context.WithValue(req.Context(), "user", "validUser")
+	return req.WithContext(ctx)
+}
+
+func TestHandleGetHistory(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+		check          func(t *testing.T, filter models.HistoryFilter, cursor string)
+	}{
+		{
+			name:           "Success",
+			request:        historyRequest("?direction=sent&counterparty=bob&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z&limit=10&cursor=abc"),
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, filter models.HistoryFilter, cursor string) {
+				if filter.Direction != models.HistoryDirectionSent || filter.Counterparty != "bob" || filter.Limit != 10 {
+					t.Errorf("unexpected filter %+v", filter)
+				}
+				if filter.From == nil || !filter.From.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("unexpected from %v", filter.From)
+				}
+				if cursor != "abc" {
+					t.Errorf("expected cursor abc, got %q", cursor)
+				}
+			},
+		},
+		{
+			name:           "InvalidDate",
+			request:        historyRequest("?from=yesterday"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidLimit",
+			request:        historyRequest("?limit=-5"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidCursor",
+			request:        historyRequest("?cursor=zzz"),
+			err:            history.ErrInvalidCursor,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unauthorized",
+			request:        httptest.NewRequest(http.MethodGet, "/api/history", nil),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleGetHistory(&history.InfoServiceMock{
+				GetHistoryFunc: func(ctx context.Context, username string, filter models.HistoryFilter, cursor string) (models.HistoryPage, error) {
+					if tt.check != nil {
+						tt.check(t, filter, cursor)
+					}
+					return models.HistoryPage{Entries: []models.HistoryEntry{}}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	ujwt "github.com/nglmq/avito-shop/internal/utils/jwt"
+)
+
+// HandleJWKS publishes the public keys access tokens can be verified with, so
+// that other services can check our tokens without sharing a secret.
+func HandleJWKS(keys *ujwt.KeySet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(keys.JWKS()); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package handlers_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	ujwt "github.com/nglmq/avito-shop/internal/utils/jwt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleJWKS(t *testing.T) {
+	_, current, _ := ed25519.GenerateKey(rand.Reader)
+	previous, _, _ := ed25519.GenerateKey(rand.Reader)
+	keys, err := ujwt.NewKeySet(current, previous)
+	if err != nil {
+		t.Fatalf("building key set: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handlers.HandleJWKS(keys).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code %v, got %v", http.StatusOK, rr.Code)
+	}
+
+	var jwks ujwt.JWKS
+	if err := json.NewDecoder(rr.Body).Decode(&jwks); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(jwks.Keys))
+	}
+	for _, key := range jwks.Keys {
+		if key.Kid == "" || key.Alg != "EdDSA" || key.X == "" {
+			t.Errorf("unexpected key %+v", key)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/nglmq/avito-shop/internal/app/leaderboard"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+func HandleGetLeaderboard(s leaderboard.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		limit := 0
+		if v := query.Get("limit"); v != "" {
+			var err error
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit <= 0 {
+				respondWithError(w, http.StatusBadRequest, "HandleGetLeaderboard", ErrInvalidQuery)
+				return
+			}
+		}
+
+		lb, err := s.GetLeaderboard(r.Context(), chi.URLParam(r, "board"), query.Get("window"), query.Get("item"), limit)
+		if err != nil {
+			switch {
+			case errors.Is(err, leaderboard.ErrUnknownBoard):
+				respondWithError(w, http.StatusNotFound, "HandleGetLeaderboard", err)
+			case errors.Is(err, leaderboard.ErrInvalidWindow),
+				errors.Is(err, leaderboard.ErrUnknownItem):
+				respondWithError(w, http.StatusBadRequest, "HandleGetLeaderboard", err)
+			default:
+				respondWithError(w, http.StatusInternalServerError, "HandleGetLeaderboard", ErrInternal)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(lb); err != nil {
+			return
+		}
+	}
+}
+
+func HandleSetLeaderboardOptOut(s leaderboard.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleSetLeaderboardOptOut", ErrUnauthorized)
+			return
+		}
+
+		var req models.LeaderboardOptOutRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleSetLeaderboardOptOut", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleSetLeaderboardOptOut", ErrInvalidBody)
+			return
+		}
+
+		if err := s.SetOptOut(r.Context(), username, *req.OptOut); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "HandleSetLeaderboardOptOut", ErrInternal)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
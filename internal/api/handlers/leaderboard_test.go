@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/leaderboard"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func leaderboardRequest(board, query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/leaderboard/"+board+query, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("board", board)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user", "validUser")
+	return req.WithContext(ctx)
+}
+
+func TestHandleGetLeaderboard(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			request:        leaderboardRequest(models.LeaderboardCollectors, "?window=month&item=cup&limit=5"),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "InvalidLimit",
+			request:        leaderboardRequest(models.LeaderboardSenders, "?limit=many"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "UnknownBoard",
+			request:        leaderboardRequest("losers", ""),
+			err:            leaderboard.ErrUnknownBoard,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "InvalidWindow",
+			request:        leaderboardRequest(models.LeaderboardSenders, "?window=decade"),
+			err:            leaderboard.ErrInvalidWindow,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleGetLeaderboard(&leaderboard.ServiceMock{
+				GetLeaderboardFunc: func(ctx context.Context, board, window, item string, limit int) (models.Leaderboard, error) {
+					if tt.name == "Success" && (board != models.LeaderboardCollectors || window != "month" || item != "cup" || limit != 5) {
+						t.Errorf("unexpected arguments %s %s %s %d", board, window, item, limit)
+					}
+					return models.Leaderboard{}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestHandleSetLeaderboardOptOut(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		user           bool
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			body:           `{"optOut": true}`,
+			user:           true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "MissingField",
+			body:           `{}`,
+			user:           true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unauthorized",
+			body:           `{"optOut": true}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *bool
+			handler := handlers.HandleSetLeaderboardOptOut(&leaderboard.ServiceMock{
+				SetOptOutFunc: func(ctx context.Context, username string, optOut bool) error {
+					got = &optOut
+					return nil
+				},
+			})
+			req := httptest.NewRequest(http.MethodPut, "/api/leaderboard/optOut", bytes.NewBufferString(tt.body))
+			if tt.user {
+				req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+			if tt.expectedStatus == http.StatusOK && (got == nil || !*got) {
+				t.Error("expected opt-out to be set")
+			}
+		})
+	}
+}
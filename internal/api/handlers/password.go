@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/nglmq/avito-shop/internal/app/auth"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+// HandleChangePassword sets a new password for the authenticated user. All of
+// their sessions are revoked, so the response carries a fresh one.
+func HandleChangePassword(service auth.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleChangePassword", ErrUnauthorized)
+			return
+		}
+
+		var req models.ChangePasswordRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleChangePassword", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithErrorCode(w, http.StatusBadRequest, "HandleChangePassword", auth.ErrValidation, AuthErrorCodeValidation)
+			return
+		}
+
+		resp, err := service.ChangePassword(r.Context(), username, req.OldPassword, req.NewPassword)
+		if err != nil {
+			respondWithAuthError(w, "HandleChangePassword", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func HandleIssuePasswordReset(service auth.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleIssuePasswordReset", ErrUnauthorized)
+			return
+		}
+
+		reset, err := service.IssuePasswordReset(r.Context(), admin, chi.URLParam(r, "username"))
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				respondWithError(w, http.StatusNotFound, "HandleIssuePasswordReset", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleIssuePasswordReset", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(reset); err != nil {
+			return
+		}
+	}
+}
+
+// HandleResetPassword redeems a password reset token issued by an admin and
+// signs the user in with the new password.
+func HandleResetPassword(service auth.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.ResetPasswordRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleResetPassword", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithErrorCode(w, http.StatusBadRequest, "HandleResetPassword", auth.ErrValidation, AuthErrorCodeValidation)
+			return
+		}
+
+		resp, err := service.ResetPassword(r.Context(), req.Token, req.NewPassword)
+		if err != nil {
+			respondWithAuthError(w, "HandleResetPassword", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			return
+		}
+	}
+}
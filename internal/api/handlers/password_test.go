@@ -0,0 +1,190 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/auth"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleChangePassword(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    any
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Success",
+			requestBody:    models.ChangePasswordRequest{OldPassword: "correct-horse", NewPassword: "battery-staple"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "MissingOldPassword",
+			requestBody:    models.ChangePasswordRequest{NewPassword: "battery-staple"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   handlers.AuthErrorCodeValidation,
+		},
+		{
+			name:           "WrongOldPassword",
+			requestBody:    models.ChangePasswordRequest{OldPassword: "wrong", NewPassword: "battery-staple"},
+			err:            auth.ErrInvalidCredentials,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   handlers.AuthErrorCodeInvalidCredentials,
+		},
+		{
+			name:           "WeakPassword",
+			requestBody:    models.ChangePasswordRequest{OldPassword: "correct-horse", NewPassword: "short"},
+			err:            auth.ErrInvalidPassword,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   handlers.AuthErrorCodeValidation,
+		},
+		{
+			name:           "InternalError",
+			requestBody:    models.ChangePasswordRequest{OldPassword: "correct-horse", NewPassword: "battery-staple"},
+			err:            errors.New("db down"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleChangePassword(&auth.ServiceMock{
+				ChangePasswordFunc: func(ctx context.Context, username, oldPassword, newPassword string) (models.AuthResponse, error) {
+					return models.AuthResponse{Token: "newToken", RefreshToken: "newRefresh"}, tt.err
+				},
+			})
+
+			reqBody, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/api/password", bytes.NewBuffer(reqBody))
+			req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %v; got %v", tt.expectedStatus, rr.Code)
+			}
+
+			if tt.expectedCode != "" {
+				var errResp models.ErrorResponse
+				if err := json.NewDecoder(rr.Body).Decode(&errResp); err != nil {
+					t.Fatalf("could not decode response: %v", err)
+				}
+				if errResp.Code != tt.expectedCode {
+					t.Fatalf("expected code %q; got %q", tt.expectedCode, errResp.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleIssuePasswordReset(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "Success", expectedStatus: http.StatusCreated},
+		{name: "UserNotFound", err: storage.ErrUserNotFound, expectedStatus: http.StatusNotFound},
+		{name: "InternalError", err: errors.New("db down"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAdmin, gotUsername string
+			handler := handlers.HandleIssuePasswordReset(&auth.ServiceMock{
+				IssuePasswordResetFunc: func(ctx context.Context, admin, username string) (models.PasswordReset, error) {
+					gotAdmin, gotUsername = admin, username
+					return models.PasswordReset{Username: username, Token: "reset"}, tt.err
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/users/alice/passwordReset", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("username", "alice")
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = context.WithValue(ctx, "user", "admin")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req.WithContext(ctx))
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %v; got %v", tt.expectedStatus, rr.Code)
+			}
+			if gotAdmin != "admin" || gotUsername != "alice" {
+				t.Fatalf("unexpected reset of %q by %q", gotUsername, gotAdmin)
+			}
+		})
+	}
+}
+
+func TestHandleResetPassword(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    any
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Success",
+			requestBody:    models.ResetPasswordRequest{Token: "reset", NewPassword: "battery-staple"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "MissingToken",
+			requestBody:    models.ResetPasswordRequest{NewPassword: "battery-staple"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   handlers.AuthErrorCodeValidation,
+		},
+		{
+			name:           "InvalidToken",
+			requestBody:    models.ResetPasswordRequest{Token: "reset", NewPassword: "battery-staple"},
+			err:            auth.ErrInvalidResetToken,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   handlers.AuthErrorCodeInvalidReset,
+		},
+		{
+			name:           "WeakPassword",
+			requestBody:    models.ResetPasswordRequest{Token: "reset", NewPassword: "short"},
+			err:            auth.ErrInvalidPassword,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   handlers.AuthErrorCodeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleResetPassword(&auth.ServiceMock{
+				ResetPasswordFunc: func(ctx context.Context, token, newPassword string) (models.AuthResponse, error) {
+					return models.AuthResponse{Token: "newToken", RefreshToken: "newRefresh"}, tt.err
+				},
+			})
+
+			resp := postCredentials(handler, tt.requestBody)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %v; got %v", tt.expectedStatus, resp.StatusCode)
+			}
+
+			if tt.expectedCode != "" {
+				var errResp models.ErrorResponse
+				if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+					t.Fatalf("could not decode response: %v", err)
+				}
+				if errResp.Code != tt.expectedCode {
+					t.Fatalf("expected code %q; got %q", tt.expectedCode, errResp.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/nglmq/avito-shop/internal/app/payment"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+func HandleCreatePaymentRequest(s payment.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleCreatePaymentRequest", ErrUnauthorized)
+			return
+		}
+
+		var req models.CreatePaymentRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleCreatePaymentRequest", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleCreatePaymentRequest", ErrInvalidBody)
+			return
+		}
+
+		pr, err := s.CreateRequest(r.Context(), username, req)
+		if err != nil {
+			if errors.Is(err, payment.ErrInvalidAmount) ||
+				errors.Is(err, payment.ErrInvalidPayer) ||
+				errors.Is(err, storage.ErrUserNotFound) {
+				respondWithError(w, http.StatusBadRequest, "HandleCreatePaymentRequest", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleCreatePaymentRequest", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(pr); err != nil {
+			return
+		}
+	}
+}
+
+func HandleListPaymentRequests(s payment.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleListPaymentRequests", ErrUnauthorized)
+			return
+		}
+
+		requests, err := s.ListRequests(r.Context(), username, r.URL.Query().Get("status"))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "HandleListPaymentRequests", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(requests); err != nil {
+			return
+		}
+	}
+}
+
+func HandlePayPaymentRequest(s payment.ServiceInterface) http.HandlerFunc {
+	return handlePaymentRequestAction("HandlePayPaymentRequest", func(ctx context.Context, username string, id int64) (any, error) {
+		return s.PayRequest(ctx, username, id)
+	})
+}
+
+func HandleDeclinePaymentRequest(s payment.ServiceInterface) http.HandlerFunc {
+	return handlePaymentRequestAction("HandleDeclinePaymentRequest", func(ctx context.Context, username string, id int64) (any, error) {
+		return nil, s.DeclineRequest(ctx, username, id)
+	})
+}
+
+func HandleCancelPaymentRequest(s payment.ServiceInterface) http.HandlerFunc {
+	return handlePaymentRequestAction("HandleCancelPaymentRequest", func(ctx context.Context, username string, id int64) (any, error) {
+		return nil, s.CancelRequest(ctx, username, id)
+	})
+}
+
+func handlePaymentRequestAction(
+	handlerName string,
+	action func(ctx context.Context, username string, id int64) (any, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, handlerName, ErrUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, handlerName, ErrInvalidID)
+			return
+		}
+
+		resp, err := action(r.Context(), username, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, payment.ErrRequestNotFound):
+				respondWithError(w, http.StatusNotFound, handlerName, err)
+			case errors.Is(err, payment.ErrRequestNotPending),
+				errors.Is(err, payment.ErrRequestExpired):
+				respondWithError(w, http.StatusConflict, handlerName, err)
+			case errors.Is(err, transaction.ErrInsufficientBalance):
+				respondWithError(w, http.StatusBadRequest, handlerName, err)
+			case errors.Is(err, transaction.ErrLimitExceeded):
+				respondWithTransferError(w, handlerName, err)
+			default:
+				respondWithError(w, http.StatusInternalServerError, handlerName, ErrInternal)
+			}
+			return
+		}
+
+		if resp == nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/payment"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func paymentRequestActionRequest(id string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/paymentRequests/"+id+"/pay", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user", "validUser")
+	return req.WithContext(ctx)
+}
+
+func TestHandleCreatePaymentRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			body:           `{"payer":"bob","amount":50,"note":"lunch"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "MissingPayer",
+			body:           `{"amount":50}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "NegativeAmount",
+			body:           `{"payer":"bob","amount":-5}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "SelfRequest",
+			body:           `{"payer":"validUser","amount":50}`,
+			err:            payment.ErrInvalidPayer,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/paymentRequests", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+
+			handler := handlers.HandleCreatePaymentRequest(&payment.ServiceMock{
+				CreateRequestFunc: func(ctx context.Context, requester string, req models.CreatePaymentRequest) (models.PaymentRequest, error) {
+					return models.PaymentRequest{ID: 1}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestHandlePayPaymentRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "InvalidID",
+			id:             "x",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "NotFound",
+			id:             "1",
+			err:            payment.ErrRequestNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Expired",
+			id:             "1",
+			err:            payment.ErrRequestExpired,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "InsufficientBalance",
+			id:             "1",
+			err:            transaction.ErrInsufficientBalance,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandlePayPaymentRequest(&payment.ServiceMock{
+				PayRequestFunc: func(ctx context.Context, payer string, id int64) (models.PaymentRequest, error) {
+					return models.PaymentRequest{ID: id}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, paymentRequestActionRequest(tt.id))
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
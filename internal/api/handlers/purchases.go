@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/nglmq/avito-shop/internal/app/merch"
+)
+
+func HandleListPurchases(s merch.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleListPurchases", ErrUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		limit := 0
+		if v := query.Get("limit"); v != "" {
+			var err error
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit <= 0 {
+				respondWithError(w, http.StatusBadRequest, "HandleListPurchases", ErrInvalidQuery)
+				return
+			}
+		}
+
+		page, err := s.ListPurchases(r.Context(), username, query.Get("item"), query.Get("cursor"), limit)
+		if err != nil {
+			if errors.Is(err, merch.ErrInvalidCursor) {
+				respondWithError(w, http.StatusBadRequest, "HandleListPurchases", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleListPurchases", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package handlers_test
+
+import (
+	"context"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/merch"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleListPurchases(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			query:          "?item=cup&limit=10",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "InvalidLimit",
+			query:          "?limit=zero",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidCursor",
+			query:          "?cursor=abc",
+			err:            merch.ErrInvalidCursor,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleListPurchases(&merch.ServiceMock{
+				ListPurchasesFunc: func(ctx context.Context, username, item, cursor string, limit int) (models.PurchasesPage, error) {
+					return models.PurchasesPage{Purchases: []models.Purchase{}}, tt.err
+				},
+			})
+			req := httptest.NewRequest(http.MethodGet, "/api/purchases"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+func HandleReverseTransaction(s transaction.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleReverseTransaction", ErrUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleReverseTransaction", ErrInvalidID)
+			return
+		}
+
+		var req models.ReverseTransactionRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleReverseTransaction", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleReverseTransaction", ErrInvalidBody)
+			return
+		}
+
+		reversal, err := s.ReverseTransaction(r.Context(), admin, id, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, transaction.ErrTransferNotFound):
+				respondWithError(w, http.StatusNotFound, "HandleReverseTransaction", err)
+			case errors.Is(err, transaction.ErrNotReversible),
+				errors.Is(err, transaction.ErrAlreadyReversed),
+				errors.Is(err, transaction.ErrRecipientFundsSpent):
+				respondWithError(w, http.StatusConflict, "HandleReverseTransaction", err)
+			default:
+				respondWithError(w, http.StatusInternalServerError, "HandleReverseTransaction", ErrInternal)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(reversal); err != nil {
+			return
+		}
+	}
+}
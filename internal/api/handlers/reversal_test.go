@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func reverseRequest(id, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/transactions/"+id+"/reverse", bytes.NewBufferString(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user", "admin")
+	return req.WithContext(ctx)
+}
+
+func TestHandleReverseTransaction(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			request:        reverseRequest("5", `{"reason":"wrong recipient"}`),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "MissingReason",
+			request:        reverseRequest("5", `{}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidID",
+			request:        reverseRequest("five", `{"reason":"wrong recipient"}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "NotFound",
+			request:        reverseRequest("5", `{"reason":"wrong recipient"}`),
+			err:            transaction.ErrTransferNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "FundsSpent",
+			request:        reverseRequest("5", `{"reason":"wrong recipient"}`),
+			err:            transaction.ErrRecipientFundsSpent,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleReverseTransaction(&transaction.ServiceMock{
+				ReverseTransactionFunc: func(ctx context.Context, admin string, id int64, req models.ReverseTransactionRequest) (models.Reversal, error) {
+					return models.Reversal{TransactionID: id, Admin: admin}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/nglmq/avito-shop/internal/app/auth"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+func HandleSetUserRole(s auth.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.SetRoleRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleSetUserRole", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleSetUserRole", ErrInvalidBody)
+			return
+		}
+
+		if err := s.SetUserRole(r.Context(), chi.URLParam(r, "username"), req.Role); err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidRole):
+				respondWithError(w, http.StatusBadRequest, "HandleSetUserRole", err)
+			case errors.Is(err, storage.ErrUserNotFound):
+				respondWithError(w, http.StatusNotFound, "HandleSetUserRole", err)
+			case errors.Is(err, auth.ErrLastAdmin):
+				respondWithError(w, http.StatusConflict, "HandleSetUserRole", err)
+			default:
+				respondWithError(w, http.StatusInternalServerError, "HandleSetUserRole", ErrInternal)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/auth"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setRoleRequest(username, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/users/"+username+"/role", bytes.NewBufferString(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("username", username)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user", "admin")
+	return req.WithContext(ctx)
+}
+
+func TestHandleSetUserRole(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			request:        setRoleRequest("alice", `{"role":"auditor"}`),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "MissingRole",
+			request:        setRoleRequest("alice", `{}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidRole",
+			request:        setRoleRequest("alice", `{"role":"superuser"}`),
+			err:            auth.ErrInvalidRole,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "UserNotFound",
+			request:        setRoleRequest("nobody", `{"role":"admin"}`),
+			err:            storage.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "LastAdmin",
+			request:        setRoleRequest("admin", `{"role":"user"}`),
+			err:            auth.ErrLastAdmin,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "InternalError",
+			request:        setRoleRequest("alice", `{"role":"admin"}`),
+			err:            errors.New("db down"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUsername string
+			handler := handlers.HandleSetUserRole(&auth.ServiceMock{
+				SetUserRoleFunc: func(ctx context.Context, username, role string) error {
+					gotUsername = username
+					return tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+			if tt.expectedStatus == http.StatusNoContent && gotUsername != "alice" {
+				t.Errorf("expected role change for alice, got %q", gotUsername)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/nglmq/avito-shop/internal/app/schedule"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+func HandleCreateScheduledTransfer(s schedule.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleCreateScheduledTransfer", ErrUnauthorized)
+			return
+		}
+
+		var req models.ScheduledTransferRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleCreateScheduledTransfer", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleCreateScheduledTransfer", ErrInvalidBody)
+			return
+		}
+
+		transfer, err := s.CreateScheduledTransfer(r.Context(), username, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, schedule.ErrInvalidAmount),
+				errors.Is(err, schedule.ErrInvalidRecipient),
+				errors.Is(err, schedule.ErrInvalidSchedule),
+				errors.Is(err, schedule.ErrRunAtInPast),
+				errors.Is(err, storage.ErrUserNotFound):
+				respondWithError(w, http.StatusBadRequest, "HandleCreateScheduledTransfer", err)
+			default:
+				respondWithError(w, http.StatusInternalServerError, "HandleCreateScheduledTransfer", ErrInternal)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(transfer); err != nil {
+			return
+		}
+	}
+}
+
+func HandleListScheduledTransfers(s schedule.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleListScheduledTransfers", ErrUnauthorized)
+			return
+		}
+
+		transfers, err := s.ListScheduledTransfers(r.Context(), username)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "HandleListScheduledTransfers", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(transfers); err != nil {
+			return
+		}
+	}
+}
+
+func HandleCancelScheduledTransfer(s schedule.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleCancelScheduledTransfer", ErrUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleCancelScheduledTransfer", ErrInvalidID)
+			return
+		}
+
+		err = s.CancelScheduledTransfer(r.Context(), username, id)
+		if err != nil {
+			if errors.Is(err, schedule.ErrScheduleNotFound) {
+				respondWithError(w, http.StatusNotFound, "HandleCancelScheduledTransfer", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleCancelScheduledTransfer", ErrInternal)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
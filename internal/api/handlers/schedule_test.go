@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/schedule"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func scheduleRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/transfers/scheduled", bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), "user", "validUser")
+	return req.WithContext(ctx)
+}
+
+func TestHandleCreateScheduledTransfer(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Recurring",
+			request:        scheduleRequest(`{"toUser":"intern","amount":10,"schedule":"0 9 * * 1"}`),
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "OneShot",
+			request:        scheduleRequest(`{"toUser":"intern","amount":10,"runAt":"2030-01-01T09:00:00Z"}`),
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "NeitherRunAtNorSchedule",
+			request:        scheduleRequest(`{"toUser":"intern","amount":10}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "BothRunAtAndSchedule",
+			request:        scheduleRequest(`{"toUser":"intern","amount":10,"schedule":"@daily","runAt":"2030-01-01T09:00:00Z"}`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidSchedule",
+			request:        scheduleRequest(`{"toUser":"intern","amount":10,"schedule":"sometimes"}`),
+			err:            schedule.ErrInvalidSchedule,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unauthorized",
+			request:        httptest.NewRequest(http.MethodPost, "/api/transfers/scheduled", nil),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleCreateScheduledTransfer(&schedule.ServiceMock{
+				CreateScheduledTransferFunc: func(ctx context.Context, from string, req models.ScheduledTransferRequest) (models.ScheduledTransfer, error) {
+					return models.ScheduledTransfer{ID: 1}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
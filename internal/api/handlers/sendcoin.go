@@ -6,7 +6,16 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/nglmq/avito-shop/internal/app/transaction"
 	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
 	"net/http"
+	"strconv"
+)
+
+// Error codes returned when a transfer is rejected, next to the limit codes
+// of transaction.LimitError.
+const (
+	TransferErrorCodeInsufficientBalance = "insufficient_balance"
+	TransferErrorCodeUserNotFound        = "user_not_found"
 )
 
 func HandleSendCoin(s transaction.ServiceInterface) http.HandlerFunc {
@@ -30,27 +39,147 @@ func HandleSendCoin(s transaction.ServiceInterface) http.HandlerFunc {
 			return
 		}
 
+		if req.RequireAcceptance {
+			resp, err := s.CreatePendingTransfer(r.Context(), username, req.ToUser, req.Amount)
+			if err != nil {
+				respondWithTransferError(w, "HandleSendCoin", err)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			if err := json.NewEncoder(w).Encode(resp); err != nil {
+				return
+			}
+			return
+		}
+
+		var receipt models.TransferReceipt
 		errCh := make(chan error)
 
 		go func() {
-			err := s.SendCoins(r.Context(), username, req.ToUser, req.Amount)
+			var err error
+			receipt, err = s.SendCoins(r.Context(), username, req.ToUser, req.Amount)
 			errCh <- err
 		}()
 
 		err = <-errCh
-		if err != nil {
-			if errors.Is(err, transaction.ErrInvalidAmount) {
-				respondWithError(w, http.StatusBadRequest, "HandleSendCoin", err)
+		if errors.Is(err, transaction.ErrTransferHeld) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			if err := json.NewEncoder(w).Encode(models.TransferHeldResponse{
+				Status:  models.TransactionStatusHeld,
+				Message: err.Error(),
+			}); err != nil {
 				return
 			}
-			if errors.Is(err, transaction.ErrInvalidRecipient) {
-				respondWithError(w, http.StatusBadRequest, "HandleSendCoin", err)
-				return
-			}
-			respondWithError(w, http.StatusInternalServerError, "HandleSendCoin", err)
+			return
+		}
+		if err != nil {
+			respondWithTransferError(w, "HandleSendCoin", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(receipt); err != nil {
+			return
+		}
+	}
+}
+
+// HandleQuoteTransfer shows what sending coins would cost, fee included,
+// without sending them.
+func HandleQuoteTransfer(s transaction.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleQuoteTransfer", ErrUnauthorized)
+			return
+		}
+
+		toUser := r.URL.Query().Get("toUser")
+		amount, err := strconv.Atoi(r.URL.Query().Get("amount"))
+		if toUser == "" || err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleQuoteTransfer", ErrInvalidBody)
+			return
+		}
+
+		quote, err := s.QuoteTransfer(r.Context(), username, toUser, amount)
+		if err != nil {
+			respondWithTransferError(w, "HandleQuoteTransfer", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(quote); err != nil {
+			return
+		}
+	}
+}
+
+func HandleSendCoinBatch(s transaction.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleSendCoinBatch", ErrUnauthorized)
+			return
+		}
+
+		var req models.BatchTransferRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleSendCoinBatch", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleSendCoinBatch", ErrInvalidBody)
+			return
+		}
+
+		resp, err := s.SendBatch(r.Context(), username, req)
+		if err != nil {
+			respondWithTransferError(w, "HandleSendCoinBatch", err)
 			return
 		}
 
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func respondWithTransferError(w http.ResponseWriter, handlerName string, err error) {
+	var limitErr *transaction.LimitError
+	if errors.As(err, &limitErr) {
+		respondWithErrorCode(w, http.StatusUnprocessableEntity, handlerName, err, limitErr.Code)
+		return
+	}
+	if errors.Is(err, transaction.ErrInsufficientBalance) {
+		respondWithErrorCode(w, http.StatusUnprocessableEntity, handlerName, err, TransferErrorCodeInsufficientBalance)
+		return
+	}
+	if errors.Is(err, storage.ErrUserNotFound) {
+		respondWithErrorCode(w, http.StatusNotFound, handlerName, err, TransferErrorCodeUserNotFound)
+		return
+	}
+
+	respondWithError(w, sendCoinErrorStatus(err), handlerName, err)
+}
+
+func sendCoinErrorStatus(err error) int {
+	if errors.Is(err, transaction.ErrInvalidAmount) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, transaction.ErrInvalidRecipient) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, transaction.ErrInvalidBatch) || errors.Is(err, transaction.ErrDuplicateRecipient) {
+		return http.StatusBadRequest
 	}
+	return http.StatusInternalServerError
 }
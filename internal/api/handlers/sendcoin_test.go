@@ -8,6 +8,7 @@ import (
 	"github.com/nglmq/avito-shop/internal/api/handlers"
 	"github.com/nglmq/avito-shop/internal/app/transaction"
 	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -38,8 +39,8 @@ func TestHandleSendCoin(t *testing.T) {
 			name:    "Success",
 			request: validSendCoinRequest(),
 			mockService: &transaction.ServiceMock{
-				SendCoinsFunc: func(ctx context.Context, fromUser, toUser string, amount int) error {
-					return nil
+				SendCoinsFunc: func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferReceipt, error) {
+					return models.TransferReceipt{TransactionID: 7, ToUser: toUser, Amount: amount, Fee: 3, Total: amount + 3}, nil
 				},
 			},
 			expectedStatus: http.StatusOK,
@@ -54,12 +55,37 @@ func TestHandleSendCoin(t *testing.T) {
 			name:    "InternalServerError",
 			request: validSendCoinRequest(),
 			mockService: &transaction.ServiceMock{
-				SendCoinsFunc: func(ctx context.Context, fromUser, toUser string, amount int) error {
-					return errors.New("internal error")
+				SendCoinsFunc: func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferReceipt, error) {
+					return models.TransferReceipt{}, errors.New("internal error")
 				},
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name: "PendingTransfer",
+			request: func() *http.Request {
+				reqBody, _ := json.Marshal(models.SendCoinsRequest{ToUser: "recipient", Amount: 100, RequireAcceptance: true})
+				req := httptest.NewRequest(http.MethodPost, "/sendCoin", bytes.NewBuffer(reqBody))
+				ctx := context.WithValue(req.Context(), "user", "validUser")
+				return req.WithContext(ctx)
+			}(),
+			mockService: &transaction.ServiceMock{
+				CreatePendingTransferFunc: func(ctx context.Context, fromUser, toUser string, amount int) (models.PendingTransferResponse, error) {
+					return models.PendingTransferResponse{ID: 1}, nil
+				},
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:    "HeldForReview",
+			request: validSendCoinRequest(),
+			mockService: &transaction.ServiceMock{
+				SendCoinsFunc: func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferReceipt, error) {
+					return models.TransferReceipt{TransactionID: 7}, transaction.ErrTransferHeld
+				},
+			},
+			expectedStatus: http.StatusAccepted,
+		},
 		{
 			name:           "Unauthorized",
 			request:        httptest.NewRequest(http.MethodPost, "/sendCoin", bytes.NewBuffer([]byte(`{"toUser": "recipient", "amount": 100}`))),
@@ -80,3 +106,200 @@ func TestHandleSendCoin(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleSendCoinReceipt(t *testing.T) {
+	handler := handlers.HandleSendCoin(&transaction.ServiceMock{
+		SendCoinsFunc: func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferReceipt, error) {
+			return models.TransferReceipt{TransactionID: 7, ToUser: toUser, Amount: amount, Fee: 3, Total: amount + 3}, nil
+		},
+		QuoteTransferFunc: func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferQuote, error) {
+			t.Fatal("the receipt must not be a fresh quote")
+			return models.TransferQuote{}, nil
+		},
+	})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, validSendCoinRequest())
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("expected status code %v, got %v", http.StatusOK, status)
+	}
+
+	var receipt models.TransferReceipt
+	if err := json.NewDecoder(rr.Body).Decode(&receipt); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+	if receipt.TransactionID != 7 || receipt.Fee != 3 || receipt.Total != 103 {
+		t.Errorf("unexpected receipt %+v", receipt)
+	}
+}
+
+func TestHandleSendCoinBatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Recipients",
+			body:           `{"recipients":[{"toUser":"bob","amount":10},{"toUser":"carol","amount":5}]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "EvenSplit",
+			body:           `{"toUsers":["bob","carol"],"total":10}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "BothForms",
+			body:           `{"recipients":[{"toUser":"bob","amount":10}],"toUsers":["carol"],"total":10}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "SplitWithoutTotal",
+			body:           `{"toUsers":["bob","carol"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "DuplicateRecipient",
+			body:           `{"recipients":[{"toUser":"bob","amount":10},{"toUser":"bob","amount":5}]}`,
+			err:            transaction.ErrDuplicateRecipient,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/sendCoin/batch", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+
+			handler := handlers.HandleSendCoinBatch(&transaction.ServiceMock{
+				SendBatchFunc: func(ctx context.Context, fromUser string, req models.BatchTransferRequest) (models.BatchTransferResponse, error) {
+					return models.BatchTransferResponse{BatchID: 1}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestHandleSendCoinLimitExceeded(t *testing.T) {
+	mockService := &transaction.ServiceMock{
+		SendCoinsFunc: func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferReceipt, error) {
+			return models.TransferReceipt{}, &transaction.LimitError{Code: transaction.LimitCodeDailySent, Message: "daily limit"}
+		},
+	}
+
+	handler := handlers.HandleSendCoin(mockService)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, validSendCoinRequest())
+
+	if status := rr.Code; status != http.StatusUnprocessableEntity {
+		t.Errorf("expected status code %v, got %v", http.StatusUnprocessableEntity, status)
+	}
+
+	var resp models.ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+	if resp.Code != transaction.LimitCodeDailySent {
+		t.Errorf("expected code %q, got %q", transaction.LimitCodeDailySent, resp.Code)
+	}
+}
+
+func TestHandleSendCoinErrorCodes(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "InsufficientBalance",
+			err:            transaction.ErrInsufficientBalance,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   handlers.TransferErrorCodeInsufficientBalance,
+		},
+		{
+			name:           "UserNotFound",
+			err:            storage.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   handlers.TransferErrorCodeUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &transaction.ServiceMock{
+				SendCoinsFunc: func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferReceipt, error) {
+					return models.TransferReceipt{}, tt.err
+				},
+			}
+
+			handler := handlers.HandleSendCoin(mockService)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, validSendCoinRequest())
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+
+			var resp models.ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("error decoding response: %v", err)
+			}
+			if resp.Code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, resp.Code)
+			}
+		})
+	}
+}
+
+func TestHandleQuoteTransfer(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			query:          "?toUser=recipient&amount=100",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "MissingAmount",
+			query:          "?toUser=recipient",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidAmount",
+			query:          "?toUser=recipient&amount=-1",
+			err:            transaction.ErrInvalidAmount,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleQuoteTransfer(&transaction.ServiceMock{
+				QuoteTransferFunc: func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferQuote, error) {
+					return models.TransferQuote{ToUser: toUser, Amount: amount, Fee: 2, Total: amount + 2}, tt.err
+				},
+			})
+			req := httptest.NewRequest(http.MethodGet, "/api/sendCoin/quote"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
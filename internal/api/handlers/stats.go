@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+func HandleGetStats(s history.InfoServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleGetStats", ErrUnauthorized)
+			return
+		}
+
+		filter, err := parseStatsFilter(r.URL.Query())
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleGetStats", ErrInvalidQuery)
+			return
+		}
+
+		stats, err := s.GetStats(r.Context(), username, filter)
+		if err != nil {
+			if errors.Is(err, history.ErrInvalidFilter) {
+				respondWithError(w, http.StatusBadRequest, "HandleGetStats", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleGetStats", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			return
+		}
+	}
+}
+
+// parseStatsFilter reads from, to (RFC 3339), bucket and top from the query
+// string. Missing values are left for the service to default.
+func parseStatsFilter(query url.Values) (models.StatsFilter, error) {
+	filter := models.StatsFilter{Bucket: query.Get("bucket")}
+
+	for key, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		v := query.Get(key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return models.StatsFilter{}, err
+		}
+		*dst = t.UTC()
+	}
+
+	if v := query.Get("top"); v != "" {
+		top, err := strconv.Atoi(v)
+		if err != nil || top <= 0 {
+			return models.StatsFilter{}, ErrInvalidQuery
+		}
+		filter.Top = top
+	}
+
+	return filter, nil
+}
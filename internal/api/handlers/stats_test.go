@@ -0,0 +1,82 @@
+package handlers_test
+
+import (
+	"context"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func statsRequest(query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/stats"+query, nil)
+	ctx := context.WithValue(req.Context(), "user", "validUser")
+	return req.WithContext(ctx)
+}
+
+func TestHandleGetStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+		check          func(t *testing.T, filter models.StatsFilter)
+	}{
+		{
+			name:           "Success",
+			request:        statsRequest("?from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z&bucket=week&top=3"),
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, filter models.StatsFilter) {
+				if filter.Bucket != models.StatsBucketWeek || filter.Top != 3 {
+					t.Errorf("unexpected filter %+v", filter)
+				}
+				if !filter.From.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("unexpected from %v", filter.From)
+				}
+			},
+		},
+		{
+			name:           "InvalidDate",
+			request:        statsRequest("?to=tomorrow"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidTop",
+			request:        statsRequest("?top=zero"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "InvalidBucket",
+			request:        statsRequest("?bucket=hour"),
+			err:            history.ErrInvalidFilter,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unauthorized",
+			request:        httptest.NewRequest(http.MethodGet, "/api/stats", nil),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleGetStats(&history.InfoServiceMock{
+				GetStatsFunc: func(ctx context.Context, username string, filter models.StatsFilter) (models.StatsResponse, error) {
+					if tt.check != nil {
+						tt.check(t, filter)
+					}
+					return models.StatsResponse{}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+var ErrInvalidID = errors.New("invalid id")
+
+func HandleListPendingTransfers(s transaction.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleListPendingTransfers", ErrUnauthorized)
+			return
+		}
+
+		transfers, err := s.ListPendingTransfers(r.Context(), username)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "HandleListPendingTransfers", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(transfers); err != nil {
+			return
+		}
+	}
+}
+
+func HandleAcceptPendingTransfer(s transaction.ServiceInterface) http.HandlerFunc {
+	return handlePendingTransferAction("HandleAcceptPendingTransfer", s.AcceptPendingTransfer)
+}
+
+func HandleDeclinePendingTransfer(s transaction.ServiceInterface) http.HandlerFunc {
+	return handlePendingTransferAction("HandleDeclinePendingTransfer", s.DeclinePendingTransfer)
+}
+
+func handlePendingTransferAction(
+	handlerName string,
+	action func(ctx context.Context, username string, id int64) error,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, handlerName, ErrUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, handlerName, ErrInvalidID)
+			return
+		}
+
+		err = action(r.Context(), username, id)
+		if errors.Is(err, transaction.ErrTransferHeld) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			if err := json.NewEncoder(w).Encode(models.TransferHeldResponse{
+				Status:  models.TransactionStatusHeld,
+				Message: err.Error(),
+			}); err != nil {
+				return
+			}
+			return
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, transaction.ErrTransferNotFound):
+				respondWithError(w, http.StatusNotFound, handlerName, err)
+			case errors.Is(err, transaction.ErrTransferNotPending),
+				errors.Is(err, transaction.ErrTransferExpired):
+				respondWithError(w, http.StatusConflict, handlerName, err)
+			default:
+				respondWithError(w, http.StatusInternalServerError, handlerName, ErrInternal)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
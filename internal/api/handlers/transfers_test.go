@@ -0,0 +1,104 @@
+package handlers_test
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func transferActionRequest(id string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/transfers/"+id+"/accept", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user", "validUser")
+	return req.WithContext(ctx)
+}
+
+func TestHandleListPendingTransfers(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/transfers/pending", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "user", "validUser"))
+
+	handler := handlers.HandleListPendingTransfers(&transaction.ServiceMock{
+		ListPendingTransfersFunc: func(ctx context.Context, username string) (models.PendingTransfersResponse, error) {
+			return models.PendingTransfersResponse{
+				Incoming: []models.PendingTransfer{},
+				Outgoing: []models.PendingTransfer{},
+			}, nil
+		},
+	})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code %v, got %v", http.StatusOK, rr.Code)
+	}
+	if body := strings.TrimSpace(rr.Body.String()); body != `{"incoming":[],"outgoing":[]}` {
+		t.Fatalf("unexpected body %v", body)
+	}
+}
+
+func TestHandleAcceptPendingTransfer(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			request:        transferActionRequest("1"),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "InvalidID",
+			request:        transferActionRequest("abc"),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "NotFound",
+			request:        transferActionRequest("1"),
+			err:            transaction.ErrTransferNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "AlreadyResolved",
+			request:        transferActionRequest("1"),
+			err:            transaction.ErrTransferNotPending,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "HeldForReview",
+			request:        transferActionRequest("1"),
+			err:            transaction.ErrTransferHeld,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Unauthorized",
+			request:        httptest.NewRequest(http.MethodPost, "/api/transfers/1/accept", nil),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleAcceptPendingTransfer(&transaction.ServiceMock{
+				AcceptPendingTransferFunc: func(ctx context.Context, username string, id int64) error {
+					return tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.request)
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/nglmq/avito-shop/internal/app/webhook"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+func HandleCreateWebhook(s webhook.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := r.Context().Value("user").(string)
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "HandleCreateWebhook", ErrUnauthorized)
+			return
+		}
+
+		var req models.CreateWebhookRequest
+		validate := validator.New()
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleCreateWebhook", ErrInvalidBody)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleCreateWebhook", ErrInvalidBody)
+			return
+		}
+
+		hook, err := s.CreateWebhook(r.Context(), admin, req)
+		if err != nil {
+			if errors.Is(err, webhook.ErrInvalidEventType) {
+				respondWithError(w, http.StatusBadRequest, "HandleCreateWebhook", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleCreateWebhook", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(hook); err != nil {
+			return
+		}
+	}
+}
+
+func HandleListWebhooks(s webhook.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhooks, err := s.ListWebhooks(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "HandleListWebhooks", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(webhooks); err != nil {
+			return
+		}
+	}
+}
+
+func HandleDeleteWebhook(s webhook.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleDeleteWebhook", ErrInvalidID)
+			return
+		}
+
+		if err := s.DeleteWebhook(r.Context(), id); err != nil {
+			if errors.Is(err, webhook.ErrWebhookNotFound) {
+				respondWithError(w, http.StatusNotFound, "HandleDeleteWebhook", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleDeleteWebhook", ErrInternal)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func HandleListWebhookDeliveries(s webhook.ServiceInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "HandleListWebhookDeliveries", ErrInvalidID)
+			return
+		}
+
+		query := r.URL.Query()
+		limit := 0
+		if v := query.Get("limit"); v != "" {
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit <= 0 {
+				respondWithError(w, http.StatusBadRequest, "HandleListWebhookDeliveries", ErrInvalidQuery)
+				return
+			}
+		}
+
+		deliveries, err := s.ListDeliveries(r.Context(), id, query.Get("status"), limit)
+		if err != nil {
+			if errors.Is(err, webhook.ErrWebhookNotFound) {
+				respondWithError(w, http.StatusNotFound, "HandleListWebhookDeliveries", err)
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, "HandleListWebhookDeliveries", ErrInternal)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+			return
+		}
+	}
+}
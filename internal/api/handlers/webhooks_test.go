@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-chi/chi/v5"
+	"github.com/nglmq/avito-shop/internal/api/handlers"
+	"github.com/nglmq/avito-shop/internal/app/webhook"
+	"github.com/nglmq/avito-shop/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func webhookRequest(method, target, id, body string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	rctx := chi.NewRouteContext()
+	if id != "" {
+		rctx.URLParams.Add("id", id)
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user", "admin")
+	return req.WithContext(ctx)
+}
+
+func TestHandleCreateWebhook(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			body:           `{"url": "https://chat.internal/hooks", "events": ["coins.received"]}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "InvalidURL",
+			body:           `{"url": "not a url"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "ShortSecret",
+			body:           `{"url": "https://chat.internal/hooks", "secret": "short"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "UnknownEvent",
+			body:           `{"url": "https://chat.internal/hooks", "events": ["user.deleted"]}`,
+			err:            webhook.ErrInvalidEventType,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.HandleCreateWebhook(&webhook.ServiceMock{
+				CreateWebhookFunc: func(ctx context.Context, admin string, req models.CreateWebhookRequest) (models.Webhook, error) {
+					return models.Webhook{ID: 1, URL: req.URL}, tt.err
+				},
+			})
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, webhookRequest(http.MethodPost, "/api/admin/webhooks", "", tt.body))
+
+			if status := rr.Code; status != tt.expectedStatus {
+				t.Errorf("expected status code %v, got %v", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteWebhook(t *testing.T) {
+	handler := handlers.HandleDeleteWebhook(&webhook.ServiceMock{
+		DeleteWebhookFunc: func(ctx context.Context, id int64) error {
+			if id != 1 {
+				return webhook.ErrWebhookNotFound
+			}
+			return nil
+		},
+	})
+
+	for id, expectedStatus := range map[string]int{"1": http.StatusOK, "2": http.StatusNotFound, "x": http.StatusBadRequest} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, webhookRequest(http.MethodDelete, "/api/admin/webhooks/"+id, id, ""))
+
+		if rr.Code != expectedStatus {
+			t.Errorf("id %s: expected status code %v, got %v", id, expectedStatus, rr.Code)
+		}
+	}
+}
+
+func TestHandleListWebhookDeliveries(t *testing.T) {
+	var gotStatus string
+	var gotLimit int
+	handler := handlers.HandleListWebhookDeliveries(&webhook.ServiceMock{
+		ListDeliveriesFunc: func(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+			gotStatus, gotLimit = status, limit
+			return []models.WebhookDelivery{{ID: 1, WebhookID: webhookID}}, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, webhookRequest(http.MethodGet, "/api/admin/webhooks/1/deliveries?status=failed&limit=20", "1", ""))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status code %v, got %v", http.StatusOK, rr.Code)
+	}
+	if gotStatus != models.WebhookDeliveryStatusFailed || gotLimit != 20 {
+		t.Errorf("unexpected filter %q %d", gotStatus, gotLimit)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, webhookRequest(http.MethodGet, "/api/admin/webhooks/1/deliveries?limit=-1", "1", ""))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %v, got %v", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -0,0 +1,10 @@
+package apikey
+
+import "github.com/nglmq/avito-shop/internal/app/audit"
+
+// WithAuditor records the creation and deletion of keys in the audit log.
+func WithAuditor(a audit.Auditor) Option {
+	return func(s *Service) {
+		s.auditor = a
+	}
+}
@@ -0,0 +1,18 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type Repository interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	CreateAPIKey(ctx context.Context, keyHash string, k models.APIKey) (models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context, username string) ([]models.APIKey, error)
+	CountAPIKeysForUpdate(ctx context.Context, username string) (int, error)
+	DeleteAPIKey(ctx context.Context, username string, id int64) error
+	TouchAPIKey(ctx context.Context, id int64, now, notBefore time.Time) error
+}
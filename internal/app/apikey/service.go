@@ -0,0 +1,210 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+const (
+	// MaxKeysPerUser caps how many keys a user can hold at once.
+	MaxKeysPerUser = 20
+
+	// KeyPrefix starts every key, so that leaked keys are easy to spot.
+	KeyPrefix = "shop_"
+
+	// displayPrefixLength is how much of a key is kept in clear to identify
+	// it in listings.
+	displayPrefixLength = len(KeyPrefix) + 6
+
+	// lastUsedResolution is how stale the last-used time of a key may get
+	// before a request updates it.
+	lastUsedResolution = time.Minute
+)
+
+var (
+	ErrInvalidKey    = errors.New("invalid api key")
+	ErrKeyNotFound   = errors.New("api key not found")
+	ErrInvalidScope  = errors.New("invalid api key scope")
+	ErrInvalidExpiry = errors.New("api key expiry must be in the future")
+	ErrTooManyKeys   = fmt.Errorf("a user can have at most %d api keys", MaxKeysPerUser)
+)
+
+type Service struct {
+	logger  *slog.Logger
+	repo    Repository
+	auditor audit.Auditor
+}
+
+type Option func(*Service)
+
+func New(logger *slog.Logger, repo Repository, opts ...Option) *Service {
+	s := &Service{
+		logger: logger,
+		repo:   repo,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CreateKey issues a key acting as username with the requested scopes on
+// behalf of actor, who is username itself or an admin. The returned key is
+// the only time it is available in clear.
+func (s *Service) CreateKey(
+	ctx context.Context,
+	actor, username string,
+	req models.CreateAPIKeyRequest,
+) (models.APIKey, error) {
+	key, err := s.createKey(ctx, username, req)
+	audit.Record(ctx, s.auditor, actor, models.AuditActionAPIKeyCreate, map[string]any{
+		"username": username,
+		"id":       key.ID,
+		"name":     req.Name,
+		"scopes":   req.Scopes,
+	}, err)
+
+	return key, err
+}
+
+func (s *Service) createKey(ctx context.Context, username string, req models.CreateAPIKeyRequest) (models.APIKey, error) {
+	scopes := []string{}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(models.APIKeyScopes, scope) {
+			return models.APIKey{}, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return models.APIKey{}, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+
+	now := time.Now().UTC()
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return models.APIKey{}, ErrInvalidExpiry
+		}
+		utc := req.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return models.APIKey{}, fmt.Errorf("error generating api key: %w", err)
+	}
+	plain := KeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	var key models.APIKey
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		count, err := s.repo.CountAPIKeysForUpdate(ctx, username)
+		if err != nil {
+			return err
+		}
+		if count >= MaxKeysPerUser {
+			return ErrTooManyKeys
+		}
+
+		key, err = s.repo.CreateAPIKey(ctx, hashKey(plain), models.APIKey{
+			Username:  username,
+			Name:      req.Name,
+			Prefix:    plain[:displayPrefixLength],
+			Scopes:    scopes,
+			ExpiresAt: expiresAt,
+			CreatedAt: now,
+		})
+		return err
+	})
+	if errors.Is(err, ErrTooManyKeys) || errors.Is(err, storage.ErrUserNotFound) {
+		return models.APIKey{}, err
+	}
+	if err != nil {
+		s.logger.Error("Error creating api key",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return models.APIKey{}, err
+	}
+
+	s.logger.Info("API key created",
+		slog.Int64("id", key.ID),
+		slog.String("username", username),
+		slog.Any("scopes", scopes))
+
+	key.Key = plain
+	return key, nil
+}
+
+// ListKeys returns the keys of username, without the keys themselves.
+func (s *Service) ListKeys(ctx context.Context, username string) ([]models.APIKey, error) {
+	keys, err := s.repo.ListAPIKeys(ctx, username)
+	if err != nil {
+		s.logger.Error("Error listing api keys",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// DeleteKey revokes one of username's keys on behalf of actor. Requests made
+// with it are rejected from then on.
+func (s *Service) DeleteKey(ctx context.Context, actor, username string, id int64) error {
+	err := s.repo.DeleteAPIKey(ctx, username, id)
+	if errors.Is(err, storage.ErrAPIKeyNotFound) {
+		err = ErrKeyNotFound
+	}
+	audit.Record(ctx, s.auditor, actor, models.AuditActionAPIKeyDelete, map[string]any{"username": username, "id": id}, err)
+
+	return err
+}
+
+// Authenticate looks up the key a request was made with. Unknown and expired
+// keys yield ErrInvalidKey.
+func (s *Service) Authenticate(ctx context.Context, plain string) (models.APIKey, error) {
+	if !strings.HasPrefix(plain, KeyPrefix) {
+		return models.APIKey{}, ErrInvalidKey
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashKey(plain))
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return models.APIKey{}, ErrInvalidKey
+		}
+		return models.APIKey{}, err
+	}
+
+	now := time.Now().UTC()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return models.APIKey{}, ErrInvalidKey
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID, now, now.Add(-lastUsedResolution)); err != nil {
+		s.logger.Error("Error recording api key use",
+			slog.Int64("id", key.ID),
+			slog.String("error", err.Error()))
+	}
+
+	return key, nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,13 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceInterface interface {
+	CreateKey(ctx context.Context, actor, username string, req models.CreateAPIKeyRequest) (models.APIKey, error)
+	ListKeys(ctx context.Context, username string) ([]models.APIKey, error)
+	DeleteKey(ctx context.Context, actor, username string, id int64) error
+}
@@ -0,0 +1,34 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceMock struct {
+	CreateKeyFunc func(ctx context.Context, actor, username string, req models.CreateAPIKeyRequest) (models.APIKey, error)
+	ListKeysFunc  func(ctx context.Context, username string) ([]models.APIKey, error)
+	DeleteKeyFunc func(ctx context.Context, actor, username string, id int64) error
+}
+
+func (m *ServiceMock) CreateKey(ctx context.Context, actor, username string, req models.CreateAPIKeyRequest) (models.APIKey, error) {
+	if m.CreateKeyFunc != nil {
+		return m.CreateKeyFunc(ctx, actor, username, req)
+	}
+	return models.APIKey{}, nil
+}
+
+func (m *ServiceMock) ListKeys(ctx context.Context, username string) ([]models.APIKey, error) {
+	if m.ListKeysFunc != nil {
+		return m.ListKeysFunc(ctx, username)
+	}
+	return nil, nil
+}
+
+func (m *ServiceMock) DeleteKey(ctx context.Context, actor, username string, id int64) error {
+	if m.DeleteKeyFunc != nil {
+		return m.DeleteKeyFunc(ctx, actor, username, id)
+	}
+	return nil
+}
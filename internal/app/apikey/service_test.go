@@ -0,0 +1,232 @@
+package apikey_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/apikey"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockRepository struct {
+	keys   map[string]*models.APIKey
+	nextID int64
+}
+
+func newMockRepository() *MockRepository {
+	return &MockRepository{keys: make(map[string]*models.APIKey)}
+}
+
+func (m *MockRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockRepository) CreateAPIKey(ctx context.Context, keyHash string, k models.APIKey) (models.APIKey, error) {
+	m.nextID++
+	k.ID = m.nextID
+	m.keys[keyHash] = &k
+	return k, nil
+}
+
+func (m *MockRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	k, ok := m.keys[keyHash]
+	if !ok {
+		return models.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	return *k, nil
+}
+
+func (m *MockRepository) ListAPIKeys(ctx context.Context, username string) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	for _, k := range m.keys {
+		if k.Username == username {
+			keys = append(keys, *k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *MockRepository) CountAPIKeysForUpdate(ctx context.Context, username string) (int, error) {
+	keys, _ := m.ListAPIKeys(ctx, username)
+	return len(keys), nil
+}
+
+func (m *MockRepository) DeleteAPIKey(ctx context.Context, username string, id int64) error {
+	for hash, k := range m.keys {
+		if k.ID == id && k.Username == username {
+			delete(m.keys, hash)
+			return nil
+		}
+	}
+	return storage.ErrAPIKeyNotFound
+}
+
+func (m *MockRepository) TouchAPIKey(ctx context.Context, id int64, now, notBefore time.Time) error {
+	for _, k := range m.keys {
+		if k.ID == id && (k.LastUsedAt == nil || k.LastUsedAt.Before(notBefore)) {
+			k.LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+type recordingAuditor struct {
+	actions []string
+	params  []any
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, actor, action string, params any, err error) {
+	a.actions = append(a.actions, actor+":"+action)
+	a.params = append(a.params, params)
+}
+
+func newService(repo *MockRepository, opts ...apikey.Option) *apikey.Service {
+	return apikey.New(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, opts...)
+}
+
+func TestCreateAndAuthenticate(t *testing.T) {
+	repo := newMockRepository()
+	auditor := &recordingAuditor{}
+	service := newService(repo, apikey.WithAuditor(auditor))
+
+	key, err := service.CreateKey(context.Background(), "bot", "bot", models.CreateAPIKeyRequest{
+		Name:   "chat bot",
+		Scopes: []string{models.APIKeyScopeSendCoins, models.APIKeyScopeSendCoins, models.APIKeyScopeReadInfo},
+	})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key.Key, apikey.KeyPrefix))
+	assert.True(t, strings.HasPrefix(key.Key, key.Prefix))
+	assert.Equal(t, []string{models.APIKeyScopeSendCoins, models.APIKeyScopeReadInfo}, key.Scopes)
+	assert.Equal(t, []string{"bot:" + models.AuditActionAPIKeyCreate}, auditor.actions)
+
+	for hash := range repo.keys {
+		assert.NotContains(t, hash, key.Key, "keys should be stored hashed")
+	}
+
+	got, err := service.Authenticate(context.Background(), key.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "bot", got.Username)
+	assert.Equal(t, key.ID, got.ID)
+
+	listed, err := service.ListKeys(context.Background(), "bot")
+	assert.NoError(t, err)
+	if assert.Len(t, listed, 1) {
+		assert.Empty(t, listed[0].Key, "listed keys should not carry the key")
+		assert.NotNil(t, listed[0].LastUsedAt, "use should be recorded")
+	}
+
+	_, err = service.Authenticate(context.Background(), key.Key+"x")
+	assert.ErrorIs(t, err, apikey.ErrInvalidKey)
+	_, err = service.Authenticate(context.Background(), "not-a-key")
+	assert.ErrorIs(t, err, apikey.ErrInvalidKey)
+}
+
+func TestCreateKeyValidation(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		req     models.CreateAPIKeyRequest
+		wantErr error
+	}{
+		{
+			name:    "UnknownScope",
+			req:     models.CreateAPIKeyRequest{Name: "bot", Scopes: []string{"admin"}},
+			wantErr: apikey.ErrInvalidScope,
+		},
+		{
+			name:    "NoScopes",
+			req:     models.CreateAPIKeyRequest{Name: "bot"},
+			wantErr: apikey.ErrInvalidScope,
+		},
+		{
+			name:    "ExpiryInPast",
+			req:     models.CreateAPIKeyRequest{Name: "bot", Scopes: []string{models.APIKeyScopeBuy}, ExpiresAt: &past},
+			wantErr: apikey.ErrInvalidExpiry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newService(newMockRepository()).CreateKey(context.Background(), "bot", "bot", tt.req)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("TooManyKeys", func(t *testing.T) {
+		service := newService(newMockRepository())
+		req := models.CreateAPIKeyRequest{Name: "bot", Scopes: []string{models.APIKeyScopeBuy}}
+		for i := 0; i < apikey.MaxKeysPerUser; i++ {
+			_, err := service.CreateKey(context.Background(), "bot", "bot", req)
+			assert.NoError(t, err)
+		}
+
+		_, err := service.CreateKey(context.Background(), "bot", "bot", req)
+		assert.ErrorIs(t, err, apikey.ErrTooManyKeys)
+	})
+}
+
+func TestExpiredKey(t *testing.T) {
+	repo := newMockRepository()
+	service := newService(repo)
+
+	expiresAt := time.Now().Add(time.Hour)
+	key, err := service.CreateKey(context.Background(), "bot", "bot", models.CreateAPIKeyRequest{
+		Name:      "short-lived",
+		Scopes:    []string{models.APIKeyScopeBuy},
+		ExpiresAt: &expiresAt,
+	})
+	assert.NoError(t, err)
+
+	for _, k := range repo.keys {
+		expired := time.Now().Add(-time.Second)
+		k.ExpiresAt = &expired
+	}
+
+	_, err = service.Authenticate(context.Background(), key.Key)
+	assert.ErrorIs(t, err, apikey.ErrInvalidKey)
+}
+
+func TestDeleteKey(t *testing.T) {
+	service := newService(newMockRepository())
+
+	key, err := service.CreateKey(context.Background(), "bot", "bot", models.CreateAPIKeyRequest{
+		Name:   "bot",
+		Scopes: []string{models.APIKeyScopeBuy},
+	})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, service.DeleteKey(context.Background(), "alice", "alice", key.ID), apikey.ErrKeyNotFound,
+		"users should not delete each other's keys")
+	assert.NoError(t, service.DeleteKey(context.Background(), "bot", "bot", key.ID))
+
+	_, err = service.Authenticate(context.Background(), key.Key)
+	assert.ErrorIs(t, err, apikey.ErrInvalidKey)
+}
+
+func TestAdminManagesKeys(t *testing.T) {
+	auditor := &recordingAuditor{}
+	service := newService(newMockRepository(), apikey.WithAuditor(auditor))
+
+	key, err := service.CreateKey(context.Background(), "admin", "bot", models.CreateAPIKeyRequest{
+		Name:   "bot",
+		Scopes: []string{models.APIKeyScopeBuy},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "bot", key.Username)
+	assert.NoError(t, service.DeleteKey(context.Background(), "admin", "bot", key.ID))
+
+	assert.Equal(t, []string{
+		"admin:" + models.AuditActionAPIKeyCreate,
+		"admin:" + models.AuditActionAPIKeyDelete,
+	}, auditor.actions, "the admin should be recorded as the actor")
+	for _, params := range auditor.params {
+		assert.Equal(t, "bot", params.(map[string]any)["username"], "the key owner should be recorded too")
+	}
+}
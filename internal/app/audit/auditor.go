@@ -0,0 +1,20 @@
+package audit
+
+import "context"
+
+// Auditor appends entries to the audit log, normally Service. Domain services
+// take one as an option, so that they work without an audit log too.
+type Auditor interface {
+	Record(ctx context.Context, actor, action string, params any, err error)
+}
+
+// Record passes the outcome of an action to a, doing nothing if a is nil. It
+// must be called once the action is over and outside of its database
+// transaction, or failed attempts would be rolled back with it.
+func Record(ctx context.Context, a Auditor, actor, action string, params any, err error) {
+	if a == nil {
+		return
+	}
+
+	a.Record(ctx, actor, action, params, err)
+}
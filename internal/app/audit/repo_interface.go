@@ -0,0 +1,12 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type Repository interface {
+	InsertAuditEntry(ctx context.Context, e models.AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
@@ -0,0 +1,22 @@
+package audit
+
+import "context"
+
+type requestKey struct{}
+
+// RequestInfo describes the HTTP request an action was made in.
+type RequestInfo struct {
+	ID       string
+	ClientIP string
+}
+
+// WithRequestInfo attaches the request details that Record stores alongside
+// every entry made while handling the request.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestKey{}, info)
+}
+
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestKey{}).(RequestInfo)
+	return info
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+var ErrInvalidFilter = errors.New("invalid audit filter")
+
+// Service writes and queries the append-only audit log.
+type Service struct {
+	logger *slog.Logger
+	repo   Repository
+}
+
+func New(logger *slog.Logger, repo Repository) *Service {
+	return &Service{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+// Record appends an entry for an action taken by actor. A nil err records a
+// success. The request id and client IP are taken from ctx. Record is called
+// once the action is over, outside of its database transaction, so that
+// failed attempts are kept too; it is not cancelled with the request.
+// Failures to write are logged rather than returned, since the action itself
+// has already happened.
+func (s *Service) Record(ctx context.Context, actor, action string, params any, err error) {
+	info := RequestInfoFrom(ctx)
+	entry := models.AuditEntry{
+		Actor:     actor,
+		Action:    action,
+		RequestID: info.ID,
+		ClientIP:  info.ClientIP,
+		Outcome:   models.AuditOutcomeSuccess,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err != nil {
+		entry.Outcome = models.AuditOutcomeFailure
+		entry.Error = err.Error()
+	}
+
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			s.logger.Error("Error encoding audit params",
+				slog.String("action", action),
+				slog.String("error", err.Error()))
+		} else {
+			entry.Params = raw
+		}
+	}
+
+	if err := s.repo.InsertAuditEntry(context.WithoutCancel(ctx), entry); err != nil {
+		s.logger.Error("Error writing audit entry",
+			slog.String("actor", actor),
+			slog.String("action", action),
+			slog.String("outcome", entry.Outcome),
+			slog.String("requestId", entry.RequestID),
+			slog.String("error", err.Error()))
+	}
+}
+
+// List returns a page of entries matching the filter, newest first.
+func (s *Service) List(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return models.AuditPage{}, ErrInvalidFilter
+	}
+	if filter.Before < 0 {
+		return models.AuditPage{}, ErrInvalidFilter
+	}
+
+	limit := filter.Limit
+	filter.Limit++
+	entries, err := s.repo.ListAuditEntries(ctx, filter)
+	if err != nil {
+		s.logger.Error("Error listing audit entries",
+			slog.String("error", err.Error()))
+		return models.AuditPage{}, err
+	}
+
+	page := models.AuditPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextBefore = page.Entries[limit-1].ID
+	}
+	if page.Entries == nil {
+		page.Entries = []models.AuditEntry{}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,11 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceInterface interface {
+	List(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error)
+}
@@ -0,0 +1,18 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceMock struct {
+	ListFunc func(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error)
+}
+
+func (m *ServiceMock) List(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, filter)
+	}
+	return models.AuditPage{}, nil
+}
@@ -0,0 +1,94 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockAuditRepository struct {
+	entries []models.AuditEntry
+	filter  models.AuditFilter
+}
+
+func (m *MockAuditRepository) InsertAuditEntry(ctx context.Context, e models.AuditEntry) error {
+	e.ID = int64(len(m.entries) + 1)
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *MockAuditRepository) ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	m.filter = filter
+	var out []models.AuditEntry
+	for i := len(m.entries) - 1; i >= 0 && len(out) < filter.Limit; i-- {
+		if filter.Before == 0 || m.entries[i].ID < filter.Before {
+			out = append(out, m.entries[i])
+		}
+	}
+	return out, nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestRecord(t *testing.T) {
+	repo := &MockAuditRepository{}
+	service := audit.New(discardLogger(), repo)
+
+	ctx := audit.WithRequestInfo(context.Background(), audit.RequestInfo{ID: "req-1", ClientIP: "10.0.0.7"})
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	service.Record(ctx, "alice", models.AuditActionSend, map[string]any{"toUser": "bob", "amount": 10}, nil)
+	service.Record(cancelled, "alice", models.AuditActionBuy, nil, errors.New("insufficient balance"))
+
+	if assert.Len(t, repo.entries, 2) {
+		sent := repo.entries[0]
+		assert.Equal(t, "alice", sent.Actor)
+		assert.Equal(t, "req-1", sent.RequestID)
+		assert.Equal(t, "10.0.0.7", sent.ClientIP)
+		assert.Equal(t, models.AuditOutcomeSuccess, sent.Outcome)
+		assert.JSONEq(t, `{"toUser":"bob","amount":10}`, string(sent.Params))
+
+		bought := repo.entries[1]
+		assert.Equal(t, models.AuditOutcomeFailure, bought.Outcome)
+		assert.Equal(t, "insufficient balance", bought.Error)
+		assert.Nil(t, bought.Params)
+	}
+}
+
+func TestList(t *testing.T) {
+	repo := &MockAuditRepository{}
+	service := audit.New(discardLogger(), repo)
+	for i := 0; i < 5; i++ {
+		service.Record(context.Background(), "alice", models.AuditActionLogin, nil, nil)
+	}
+
+	page, err := service.List(context.Background(), models.AuditFilter{Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, repo.filter.Limit, "repository should be asked for one extra entry")
+	assert.Len(t, page.Entries, 3)
+	assert.Equal(t, int64(3), page.NextBefore)
+
+	page, err = service.List(context.Background(), models.AuditFilter{Limit: 3, Before: page.NextBefore})
+	assert.NoError(t, err)
+	assert.Len(t, page.Entries, 2)
+	assert.Zero(t, page.NextBefore)
+
+	_, err = service.List(context.Background(), models.AuditFilter{Limit: 5000})
+	assert.NoError(t, err)
+	assert.Equal(t, audit.MaxPageSize+1, repo.filter.Limit)
+
+	from := time.Now()
+	to := from.Add(-time.Hour)
+	_, err = service.List(context.Background(), models.AuditFilter{From: &from, To: &to})
+	assert.ErrorIs(t, err, audit.ErrInvalidFilter)
+}
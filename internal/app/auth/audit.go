@@ -0,0 +1,10 @@
+package auth
+
+import "github.com/nglmq/avito-shop/internal/app/audit"
+
+// WithAuditor records registrations and logins in the audit log.
+func WithAuditor(a audit.Auditor) Option {
+	return func(s *Service) {
+		s.auditor = a
+	}
+}
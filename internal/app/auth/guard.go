@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/storage"
+	"github.com/nglmq/avito-shop/internal/utils/validation"
+)
+
+var ErrTooManyAttempts = errors.New("too many failed attempts")
+
+// ThrottledError is returned instead of checking a password while the
+// username or client has failed too often recently, or instead of
+// registering a user while the client has signed up too often.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// LoginGuard throttles password guessing and sign-ups, normally
+// lockout.Service. Attempt counts an attempt as failed before the password is
+// checked, and RecordSuccess takes it back once the password turns out right.
+type LoginGuard interface {
+	Attempt(ctx context.Context, username string) (time.Duration, error)
+	AttemptRegistration(ctx context.Context) (time.Duration, error)
+	RecordSuccess(ctx context.Context, username string) error
+}
+
+// WithLoginGuard makes logins, password changes and registrations consult
+// guard before hashing or checking a password.
+func WithLoginGuard(guard LoginGuard) Option {
+	return func(s *Service) {
+		s.guard = guard
+	}
+}
+
+// verifyPassword checks password against the one stored for username. Unknown
+// usernames and wrong passwords both yield ErrInvalidCredentials. While the
+// guard throttles the attempt, the password is not checked at all, which
+// also spares the bcrypt work.
+func (s *Service) verifyPassword(ctx context.Context, username, password string) error {
+	if s.guard != nil {
+		wait, err := s.guard.Attempt(ctx, username)
+		if err != nil {
+			s.logger.Error("Error checking login attempts",
+				slog.String("username", username),
+				slog.String("error", err.Error()))
+			return fmt.Errorf("error checking login attempts: %w", err)
+		}
+		if wait > 0 {
+			return &ThrottledError{RetryAfter: wait}
+		}
+	}
+
+	storedPassHash, err := s.userRepo.GetUserPassword(ctx, username)
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		err = ErrInvalidCredentials
+	case err != nil:
+		s.logger.Error("Error fetching user data",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return fmt.Errorf("error getting user password: %w", err)
+	case !validation.CheckPassword(password, storedPassHash):
+		err = ErrInvalidCredentials
+	}
+
+	if s.guard != nil && err == nil {
+		if err := s.guard.RecordSuccess(ctx, username); err != nil {
+			s.logger.Error("Error recording login attempt",
+				slog.String("username", username),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	return err
+}
+
+// checkRegistration counts a sign-up from the client with the guard, so that
+// creating accounts is throttled per client IP before any bcrypt work.
+func (s *Service) checkRegistration(ctx context.Context) error {
+	if s.guard == nil {
+		return nil
+	}
+
+	wait, err := s.guard.AttemptRegistration(ctx)
+	if err != nil {
+		s.logger.Error("Error checking registration attempts",
+			slog.String("error", err.Error()))
+		return fmt.Errorf("error checking registration attempts: %w", err)
+	}
+	if wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"github.com/nglmq/avito-shop/internal/utils/validation"
+)
+
+const DefaultPasswordResetTTL = time.Hour
+
+var ErrInvalidResetToken = errors.New("invalid password reset token")
+
+// WithPasswordResetTTL sets how long an admin-issued password reset token
+// can be redeemed.
+func WithPasswordResetTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.resetTTL = ttl
+	}
+}
+
+// ChangePassword replaces the user's password after checking the old one.
+// Every existing session of the user is revoked, including the one making
+// the request, and a new session is started in its place. The user's API
+// keys are deleted as well, since whoever holds them may be the reason the
+// password is being changed.
+func (s *Service) ChangePassword(ctx context.Context, username, oldPassword, newPassword string) (models.AuthResponse, error) {
+	resp, err := s.changePassword(ctx, username, oldPassword, newPassword)
+	audit.Record(ctx, s.auditor, username, models.AuditActionPasswordChange, nil, err)
+
+	return resp, err
+}
+
+func (s *Service) changePassword(ctx context.Context, username, oldPassword, newPassword string) (models.AuthResponse, error) {
+	if err := s.verifyPassword(ctx, username, oldPassword); err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	return s.setPassword(ctx, username, newPassword, nil)
+}
+
+// IssuePasswordReset creates a one-time token the user can set a new password
+// with. Issuing a new token invalidates the ones issued before.
+func (s *Service) IssuePasswordReset(ctx context.Context, admin, username string) (models.PasswordReset, error) {
+	if _, err := s.userRepo.GetUserRole(ctx, username); err != nil {
+		return models.PasswordReset{}, err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return models.PasswordReset{}, err
+	}
+
+	expiresAt := time.Now().Add(s.resetTTL).UTC()
+	err = s.userRepo.CreatePasswordResetToken(ctx, hashToken(token), models.PasswordResetToken{
+		Username:  username,
+		CreatedBy: admin,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		s.logger.Error("Error storing password reset token",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return models.PasswordReset{}, err
+	}
+
+	s.logger.Info("Password reset issued",
+		slog.String("username", username),
+		slog.String("admin", admin))
+
+	return models.PasswordReset{
+		Username:  username,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ResetPassword redeems a reset token, setting a new password for the user it
+// was issued to. Like ChangePassword it revokes every existing session,
+// deletes the user's API keys and starts a new session.
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) (models.AuthResponse, error) {
+	var username string
+	resp, err := s.setPassword(ctx, "", newPassword, func(ctx context.Context, now time.Time) (string, error) {
+		stored, err := s.userRepo.GetPasswordResetTokenForUpdate(ctx, hashToken(token))
+		if err != nil {
+			if errors.Is(err, storage.ErrPasswordResetTokenNotFound) {
+				return "", ErrInvalidResetToken
+			}
+			return "", err
+		}
+		if stored.UsedAt != nil || !now.Before(stored.ExpiresAt) {
+			return "", ErrInvalidResetToken
+		}
+
+		username = stored.Username
+		return stored.Username, s.userRepo.MarkPasswordResetTokenUsed(ctx, stored.ID, now)
+	})
+	audit.Record(ctx, s.auditor, username, models.AuditActionPasswordReset, nil, err)
+
+	return resp, err
+}
+
+// setPassword stores a new password for the user, revokes their sessions,
+// deletes their API keys and starts a new session, all in one transaction. When redeem is set, it runs first
+// in the transaction and names the user instead.
+func (s *Service) setPassword(
+	ctx context.Context,
+	username, password string,
+	redeem func(ctx context.Context, now time.Time) (string, error),
+) (models.AuthResponse, error) {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return models.AuthResponse{}, ErrInvalidPassword
+	}
+
+	passHash, err := validation.HashPassword(password)
+	if err != nil {
+		return models.AuthResponse{}, fmt.Errorf("error hashing password: %w", err)
+	}
+
+	var resp models.AuthResponse
+	now := time.Now().UTC()
+
+	err = s.userRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if redeem != nil {
+			var err error
+			if username, err = redeem(ctx, now); err != nil {
+				return err
+			}
+		}
+
+		if err := s.userRepo.UpdateUserPassword(ctx, username, passHash); err != nil {
+			return err
+		}
+		if err := s.revokeUserSessions(ctx, username, now); err != nil {
+			return err
+		}
+		if _, err := s.userRepo.DeleteUserAPIKeys(ctx, username); err != nil {
+			return err
+		}
+
+		var err error
+		resp, err = s.issueSession(ctx, username, "")
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidResetToken) {
+			s.logger.Error("Error setting password",
+				slog.String("username", username),
+				slog.String("error", err.Error()))
+		}
+		return models.AuthResponse{}, err
+	}
+
+	return resp, nil
+}
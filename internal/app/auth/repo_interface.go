@@ -1,8 +1,35 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
 
 type Repository interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
 	GetUserPassword(ctx context.Context, username string) (string, error)
 	SaveUser(ctx context.Context, username, password string) (string, error)
+	UpdateUserPassword(ctx context.Context, username, password string) error
+	GetUserRole(ctx context.Context, username string) (string, error)
+	SetUserRole(ctx context.Context, username, role string) error
+	CountUsersWithRole(ctx context.Context, role string) (int, error)
+
+	CreateRefreshToken(ctx context.Context, tokenHash string, t models.RefreshToken) error
+	GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id int64, now time.Time) error
+	GetRefreshTokenFamily(ctx context.Context, accessTokenID string) (string, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string, now time.Time) ([]string, error)
+	RevokeUserRefreshTokens(ctx context.Context, username string, now time.Time) ([]string, error)
+	RevokeTokens(ctx context.Context, ids []string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, id string) (bool, error)
+	DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error)
+
+	CreatePasswordResetToken(ctx context.Context, tokenHash string, t models.PasswordResetToken) error
+	GetPasswordResetTokenForUpdate(ctx context.Context, tokenHash string) (models.PasswordResetToken, error)
+	MarkPasswordResetTokenUsed(ctx context.Context, id int64, now time.Time) error
+
+	DeleteUserAPIKeys(ctx context.Context, username string) (int64, error)
 }
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"github.com/nglmq/avito-shop/internal/utils/validation"
+)
+
+var (
+	ErrInvalidRole = fmt.Errorf("%w: unknown role", ErrValidation)
+	ErrLastAdmin   = errors.New("cannot demote the last admin")
+)
+
+// SetUserRole changes the role of a user and signs them out everywhere, so
+// that no token carrying the old role stays in use. The last admin cannot be
+// demoted.
+func (s *Service) SetUserRole(ctx context.Context, username, role string) error {
+	if !models.ValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	now := time.Now().UTC()
+	err := s.userRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.userRepo.GetUserRole(ctx, username)
+		if err != nil {
+			return err
+		}
+		if current == role {
+			return nil
+		}
+
+		if current == models.RoleAdmin {
+			admins, err := s.userRepo.CountUsersWithRole(ctx, models.RoleAdmin)
+			if err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return ErrLastAdmin
+			}
+		}
+
+		if err := s.userRepo.SetUserRole(ctx, username, role); err != nil {
+			return err
+		}
+
+		return s.revokeUserSessions(ctx, username, now)
+	})
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserNotFound) && !errors.Is(err, ErrLastAdmin) {
+			s.logger.Error("Error setting user role",
+				slog.String("username", username),
+				slog.String("role", role),
+				slog.String("error", err.Error()))
+		}
+		return err
+	}
+
+	s.logger.Info("User role changed",
+		slog.String("username", username),
+		slog.String("role", role))
+
+	return nil
+}
+
+// BootstrapAdmin makes sure there is at least one admin. If there is none
+// yet, username is promoted to admin, being registered with password first
+// if it does not exist. Once an admin exists it does nothing, so it is safe
+// to run on every start.
+func (s *Service) BootstrapAdmin(ctx context.Context, username, password string) error {
+	return s.userRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		admins, err := s.userRepo.CountUsersWithRole(ctx, models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if admins > 0 {
+			return nil
+		}
+
+		_, err = s.userRepo.GetUserRole(ctx, username)
+		if errors.Is(err, storage.ErrUserNotFound) {
+			if err := validateCredentials(username, password); err != nil {
+				return err
+			}
+
+			passHash, err := validation.HashPassword(password)
+			if err != nil {
+				return fmt.Errorf("error hashing password: %w", err)
+			}
+			if _, err := s.userRepo.SaveUser(ctx, username, passHash); err != nil {
+				return fmt.Errorf("error saving user: %w", err)
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.SetUserRole(ctx, username, models.RoleAdmin); err != nil {
+			return err
+		}
+
+		s.logger.Info("Bootstrapped admin", slog.String("username", username))
+		return nil
+	})
+}
+
+// revokeUserSessions revokes every session of the user, along with the
+// access tokens issued in them.
+func (s *Service) revokeUserSessions(ctx context.Context, username string, now time.Time) error {
+	ids, err := s.userRepo.RevokeUserRefreshTokens(ctx, username, now)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.userRepo.RevokeTokens(ctx, ids, now.Add(s.accessTTL))
+}
@@ -4,92 +4,159 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
 	"github.com/nglmq/avito-shop/internal/storage"
 	"github.com/nglmq/avito-shop/internal/utils/jwt"
 	"github.com/nglmq/avito-shop/internal/utils/validation"
 	"log/slog"
+	"regexp"
+	"time"
 )
 
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 64
+	MinPasswordLength = 8
+	// MaxPasswordLength is the most bcrypt can hash.
+	MaxPasswordLength = 72
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUsernameTaken      = errors.New("username already taken")
+
+	// ErrValidation is wrapped by every error about malformed registration
+	// input.
+	ErrValidation      = errors.New("validation failed")
+	ErrInvalidUsername = fmt.Errorf("%w: username must be %d-%d letters, digits, '.', '_' or '-'",
+		ErrValidation, MinUsernameLength, MaxUsernameLength)
+	ErrInvalidPassword = fmt.Errorf("%w: password must be %d-%d characters",
+		ErrValidation, MinPasswordLength, MaxPasswordLength)
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
 type Service struct {
-	userRepo Repository
-	logger   *slog.Logger
+	userRepo     Repository
+	logger       *slog.Logger
+	auditor      audit.Auditor
+	guard        LoginGuard
+	autoRegister bool
+	accessTTL    time.Duration
+	refreshTTL   time.Duration
+	resetTTL     time.Duration
 }
 
-func New(logger *slog.Logger, userRepo Repository) *Service {
-	return &Service{
-		logger:   logger,
-		userRepo: userRepo,
+type Option func(*Service)
+
+// WithAutoRegister sets whether AuthenticateUser registers unknown usernames.
+// It is enabled by default.
+func WithAutoRegister(enabled bool) Option {
+	return func(s *Service) {
+		s.autoRegister = enabled
 	}
 }
 
-func (s *Service) AuthenticateUser(ctx context.Context, username, password string) (string, error) {
-	storedPassHash, err := s.userRepo.GetUserPassword(ctx, username)
-	if err != nil {
+func New(logger *slog.Logger, userRepo Repository, opts ...Option) *Service {
+	s := &Service{
+		logger:       logger,
+		userRepo:     userRepo,
+		autoRegister: true,
+		accessTTL:    ujwt.TokenExp,
+		refreshTTL:   DefaultRefreshTokenTTL,
+		resetTTL:     DefaultPasswordResetTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// AuthenticateUser logs the user in, registering them first if the username
+// is unknown and auto-registration is enabled. Either way it starts a session.
+func (s *Service) AuthenticateUser(ctx context.Context, username, password string) (models.AuthResponse, error) {
+	if s.autoRegister {
+		_, err := s.userRepo.GetUserPassword(ctx, username)
 		if errors.Is(err, storage.ErrUserNotFound) {
 			s.logger.Info("User not found, proceeding with registration",
 				slog.String("username", username))
 
-			username, err = s.RegisterUser(ctx, username, password)
-
-			if err != nil {
-				s.logger.Error("Error registering user",
-					slog.String("username", username),
-					slog.String("error", err.Error()))
-				return "", fmt.Errorf("error registering user: %w", err)
+			resp, err := s.RegisterUser(ctx, username, password)
+			if errors.Is(err, ErrUsernameTaken) {
+				// Registered concurrently by another request.
+				return s.Login(ctx, username, password)
 			}
-
-			return username, nil
+			return resp, err
 		}
-
-		s.logger.Error("Error fetching user data",
-			slog.String("username", username),
-			slog.String("error", err.Error()))
-		return "", fmt.Errorf("error getting user password: %w", err)
 	}
 
-	if !validation.CheckPassword(password, storedPassHash) {
-		return "", errors.New("invalid credentials")
-	}
+	return s.Login(ctx, username, password)
+}
 
-	token, err := ujwt.BuildJWTString(username)
-	if err != nil {
-		s.logger.Error("Error generating JWT token",
-			slog.String("userUUID", username),
-			slog.String("error", err.Error()))
-		return "", fmt.Errorf("error generating JWT token: %w", err)
+// Login checks the password of an existing user and starts a session. Unknown
+// usernames and wrong passwords both yield ErrInvalidCredentials.
+func (s *Service) Login(ctx context.Context, username, password string) (models.AuthResponse, error) {
+	resp, err := s.login(ctx, username, password)
+	audit.Record(ctx, s.auditor, username, models.AuditActionLogin, nil, err)
+
+	return resp, err
+}
+
+func (s *Service) login(ctx context.Context, username, password string) (models.AuthResponse, error) {
+	if err := s.verifyPassword(ctx, username, password); err != nil {
+		return models.AuthResponse{}, err
 	}
 
-	return token, nil
+	return s.issueSession(ctx, username, "")
+}
+
+// RegisterUser creates a user and starts a session for them.
+func (s *Service) RegisterUser(ctx context.Context, username, password string) (models.AuthResponse, error) {
+	resp, err := s.register(ctx, username, password)
+	audit.Record(ctx, s.auditor, username, models.AuditActionRegister, nil, err)
+
+	return resp, err
 }
 
-func (s *Service) RegisterUser(ctx context.Context, username, password string) (string, error) {
+func (s *Service) register(ctx context.Context, username, password string) (models.AuthResponse, error) {
+	if err := validateCredentials(username, password); err != nil {
+		return models.AuthResponse{}, err
+	}
+	if err := s.checkRegistration(ctx); err != nil {
+		return models.AuthResponse{}, err
+	}
+
 	passHash, err := validation.HashPassword(password)
 	if err != nil {
 		s.logger.Error("Error hashing password",
 			slog.String("username", username),
 			slog.String("error", err.Error()))
-		return "", fmt.Errorf("error hashing password: %w", err)
+		return models.AuthResponse{}, fmt.Errorf("error hashing password: %w", err)
 	}
 
-	username, err = s.userRepo.SaveUser(ctx, username, passHash)
-	if err != nil {
+	if _, err := s.userRepo.SaveUser(ctx, username, passHash); err != nil {
 		if errors.Is(err, storage.ErrUsernameExists) {
-			return "", err
+			return models.AuthResponse{}, ErrUsernameTaken
 		}
 
 		s.logger.Error("Error saving user",
 			slog.String("username", username),
 			slog.String("error", err.Error()))
-		return "", fmt.Errorf("error saving user: %w", err)
+		return models.AuthResponse{}, fmt.Errorf("error saving user: %w", err)
 	}
 
-	token, err := ujwt.BuildJWTString(username)
-	if err != nil {
-		s.logger.Error("Error generating JWT token",
-			slog.String("userUUID", username),
-			slog.String("error", err.Error()))
-		return "", fmt.Errorf("error generating JWT token: %w", err)
+	return s.issueSession(ctx, username, "")
+}
+
+func validateCredentials(username, password string) error {
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength || !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return ErrInvalidPassword
 	}
 
-	return token, nil
+	return nil
 }
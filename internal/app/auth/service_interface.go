@@ -1,8 +1,19 @@
 package auth
 
-import "context"
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
 
 type ServiceInterface interface {
-	AuthenticateUser(ctx context.Context, username, password string) (string, error)
-	RegisterUser(ctx context.Context, username, password string) (string, error)
+	AuthenticateUser(ctx context.Context, username, password string) (models.AuthResponse, error)
+	Login(ctx context.Context, username, password string) (models.AuthResponse, error)
+	RegisterUser(ctx context.Context, username, password string) (models.AuthResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (models.AuthResponse, error)
+	Logout(ctx context.Context, username, tokenID string) error
+	ChangePassword(ctx context.Context, username, oldPassword, newPassword string) (models.AuthResponse, error)
+	IssuePasswordReset(ctx context.Context, admin, username string) (models.PasswordReset, error)
+	ResetPassword(ctx context.Context, token, newPassword string) (models.AuthResponse, error)
+	SetUserRole(ctx context.Context, username, role string) error
 }
@@ -1,22 +1,82 @@
 package auth
 
-import "context"
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
 
 type ServiceMock struct {
-	AuthenticateUserFunc func(ctx context.Context, username, password string) (string, error)
-	RegisterUserFunc     func(ctx context.Context, username, password string) (string, error)
+	AuthenticateUserFunc   func(ctx context.Context, username, password string) (models.AuthResponse, error)
+	LoginFunc              func(ctx context.Context, username, password string) (models.AuthResponse, error)
+	RegisterUserFunc       func(ctx context.Context, username, password string) (models.AuthResponse, error)
+	RefreshFunc            func(ctx context.Context, refreshToken string) (models.AuthResponse, error)
+	LogoutFunc             func(ctx context.Context, username, tokenID string) error
+	ChangePasswordFunc     func(ctx context.Context, username, oldPassword, newPassword string) (models.AuthResponse, error)
+	IssuePasswordResetFunc func(ctx context.Context, admin, username string) (models.PasswordReset, error)
+	ResetPasswordFunc      func(ctx context.Context, token, newPassword string) (models.AuthResponse, error)
+	SetUserRoleFunc        func(ctx context.Context, username, role string) error
 }
 
-func (m *ServiceMock) AuthenticateUser(ctx context.Context, username, password string) (string, error) {
+func (m *ServiceMock) AuthenticateUser(ctx context.Context, username, password string) (models.AuthResponse, error) {
 	if m.AuthenticateUserFunc != nil {
 		return m.AuthenticateUserFunc(ctx, username, password)
 	}
-	return "", nil
+	return models.AuthResponse{}, nil
+}
+
+func (m *ServiceMock) Login(ctx context.Context, username, password string) (models.AuthResponse, error) {
+	if m.LoginFunc != nil {
+		return m.LoginFunc(ctx, username, password)
+	}
+	return models.AuthResponse{}, nil
 }
 
-func (m *ServiceMock) RegisterUser(ctx context.Context, username, password string) (string, error) {
+func (m *ServiceMock) RegisterUser(ctx context.Context, username, password string) (models.AuthResponse, error) {
 	if m.RegisterUserFunc != nil {
 		return m.RegisterUserFunc(ctx, username, password)
 	}
-	return "", nil
+	return models.AuthResponse{}, nil
+}
+
+func (m *ServiceMock) Refresh(ctx context.Context, refreshToken string) (models.AuthResponse, error) {
+	if m.RefreshFunc != nil {
+		return m.RefreshFunc(ctx, refreshToken)
+	}
+	return models.AuthResponse{}, nil
+}
+
+func (m *ServiceMock) Logout(ctx context.Context, username, tokenID string) error {
+	if m.LogoutFunc != nil {
+		return m.LogoutFunc(ctx, username, tokenID)
+	}
+	return nil
+}
+
+func (m *ServiceMock) ChangePassword(ctx context.Context, username, oldPassword, newPassword string) (models.AuthResponse, error) {
+	if m.ChangePasswordFunc != nil {
+		return m.ChangePasswordFunc(ctx, username, oldPassword, newPassword)
+	}
+	return models.AuthResponse{}, nil
+}
+
+func (m *ServiceMock) IssuePasswordReset(ctx context.Context, admin, username string) (models.PasswordReset, error) {
+	if m.IssuePasswordResetFunc != nil {
+		return m.IssuePasswordResetFunc(ctx, admin, username)
+	}
+	return models.PasswordReset{}, nil
+}
+
+func (m *ServiceMock) ResetPassword(ctx context.Context, token, newPassword string) (models.AuthResponse, error) {
+	if m.ResetPasswordFunc != nil {
+		return m.ResetPasswordFunc(ctx, token, newPassword)
+	}
+	return models.AuthResponse{}, nil
+}
+
+func (m *ServiceMock) SetUserRole(ctx context.Context, username, role string) error {
+	if m.SetUserRoleFunc != nil {
+		return m.SetUserRoleFunc(ctx, username, role)
+	}
+	return nil
 }
@@ -0,0 +1,616 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/auth"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	ujwt "github.com/nglmq/avito-shop/internal/utils/jwt"
+	"github.com/nglmq/avito-shop/internal/utils/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockUserRepository struct {
+	passwords map[string]string
+	roles     map[string]string
+	refresh   map[string]*models.RefreshToken
+	revoked   map[string]time.Time
+	resets    map[string]*models.PasswordResetToken
+	apiKeys   map[string]int
+}
+
+func newMockUserRepository(t *testing.T, users map[string]string) *MockUserRepository {
+	repo := &MockUserRepository{
+		passwords: make(map[string]string),
+		roles:     make(map[string]string),
+		refresh:   make(map[string]*models.RefreshToken),
+		revoked:   make(map[string]time.Time),
+		resets:    make(map[string]*models.PasswordResetToken),
+		apiKeys:   make(map[string]int),
+	}
+	for username, password := range users {
+		hash, err := validation.HashPassword(password)
+		if err != nil {
+			t.Fatalf("hashing password: %v", err)
+		}
+		repo.passwords[username] = hash
+	}
+	return repo
+}
+
+func (m *MockUserRepository) GetUserPassword(ctx context.Context, username string) (string, error) {
+	hash, ok := m.passwords[username]
+	if !ok {
+		return "", storage.ErrUserNotFound
+	}
+	return hash, nil
+}
+
+func (m *MockUserRepository) SaveUser(ctx context.Context, username, password string) (string, error) {
+	if _, ok := m.passwords[username]; ok {
+		return "", storage.ErrUsernameExists
+	}
+	m.passwords[username] = password
+	return username, nil
+}
+
+func (m *MockUserRepository) UpdateUserPassword(ctx context.Context, username, password string) error {
+	if _, ok := m.passwords[username]; !ok {
+		return storage.ErrUserNotFound
+	}
+	m.passwords[username] = password
+	return nil
+}
+
+func (m *MockUserRepository) GetUserRole(ctx context.Context, username string) (string, error) {
+	if _, ok := m.passwords[username]; !ok {
+		return "", storage.ErrUserNotFound
+	}
+	if role, ok := m.roles[username]; ok {
+		return role, nil
+	}
+	return models.RoleUser, nil
+}
+
+func (m *MockUserRepository) SetUserRole(ctx context.Context, username, role string) error {
+	if _, ok := m.passwords[username]; !ok {
+		return storage.ErrUserNotFound
+	}
+	m.roles[username] = role
+	return nil
+}
+
+func (m *MockUserRepository) CountUsersWithRole(ctx context.Context, role string) (int, error) {
+	count := 0
+	for _, r := range m.roles {
+		if r == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockUserRepository) DeleteUserAPIKeys(ctx context.Context, username string) (int64, error) {
+	n := m.apiKeys[username]
+	delete(m.apiKeys, username)
+	return int64(n), nil
+}
+
+func (m *MockUserRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockUserRepository) CreateRefreshToken(ctx context.Context, tokenHash string, t models.RefreshToken) error {
+	t.ID = int64(len(m.refresh) + 1)
+	m.refresh[tokenHash] = &t
+	return nil
+}
+
+func (m *MockUserRepository) GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	t, ok := m.refresh[tokenHash]
+	if !ok {
+		return models.RefreshToken{}, storage.ErrRefreshTokenNotFound
+	}
+	return *t, nil
+}
+
+func (m *MockUserRepository) MarkRefreshTokenUsed(ctx context.Context, id int64, now time.Time) error {
+	for _, t := range m.refresh {
+		if t.ID == id {
+			t.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *MockUserRepository) GetRefreshTokenFamily(ctx context.Context, accessTokenID string) (string, error) {
+	for _, t := range m.refresh {
+		if t.AccessTokenID == accessTokenID {
+			return t.FamilyID, nil
+		}
+	}
+	return "", storage.ErrRefreshTokenNotFound
+}
+
+func (m *MockUserRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string, now time.Time) ([]string, error) {
+	var ids []string
+	for _, t := range m.refresh {
+		if t.FamilyID == familyID {
+			if t.RevokedAt == nil {
+				t.RevokedAt = &now
+			}
+			ids = append(ids, t.AccessTokenID)
+		}
+	}
+	return ids, nil
+}
+
+func (m *MockUserRepository) RevokeUserRefreshTokens(ctx context.Context, username string, now time.Time) ([]string, error) {
+	var ids []string
+	for _, t := range m.refresh {
+		if t.Username == username && t.RevokedAt == nil {
+			t.RevokedAt = &now
+			ids = append(ids, t.AccessTokenID)
+		}
+	}
+	return ids, nil
+}
+
+func (m *MockUserRepository) RevokeTokens(ctx context.Context, ids []string, expiresAt time.Time) error {
+	for _, id := range ids {
+		m.revoked[id] = expiresAt
+	}
+	return nil
+}
+
+func (m *MockUserRepository) IsTokenRevoked(ctx context.Context, id string) (bool, error) {
+	_, ok := m.revoked[id]
+	return ok, nil
+}
+
+func (m *MockUserRepository) DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockUserRepository) CreatePasswordResetToken(ctx context.Context, tokenHash string, t models.PasswordResetToken) error {
+	for hash, r := range m.resets {
+		if r.Username == t.Username && r.UsedAt == nil {
+			delete(m.resets, hash)
+		}
+	}
+	t.ID = int64(len(m.resets) + 1)
+	m.resets[tokenHash] = &t
+	return nil
+}
+
+func (m *MockUserRepository) GetPasswordResetTokenForUpdate(ctx context.Context, tokenHash string) (models.PasswordResetToken, error) {
+	t, ok := m.resets[tokenHash]
+	if !ok {
+		return models.PasswordResetToken{}, storage.ErrPasswordResetTokenNotFound
+	}
+	return *t, nil
+}
+
+func (m *MockUserRepository) MarkPasswordResetTokenUsed(ctx context.Context, id int64, now time.Time) error {
+	for _, t := range m.resets {
+		if t.ID == id {
+			t.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	keys, err := ujwt.NewKeySet(key)
+	if err != nil {
+		panic(err)
+	}
+	ujwt.SetKeySet(keys)
+
+	os.Exit(m.Run())
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func assertTokenFor(t *testing.T, resp models.AuthResponse, username string) {
+	t.Helper()
+	userID, err := ujwt.GetUserID(resp.Token)
+	if assert.NoError(t, err, "expected a valid JWT") {
+		assert.Equal(t, username, userID)
+	}
+	assert.NotEmpty(t, resp.RefreshToken)
+}
+
+func tokenClaims(t *testing.T, resp models.AuthResponse) ujwt.Claims {
+	t.Helper()
+	claims, err := ujwt.ParseToken(resp.Token)
+	if err != nil {
+		t.Fatalf("parsing token: %v", err)
+	}
+	return claims
+}
+
+func tokenID(t *testing.T, resp models.AuthResponse) string {
+	t.Helper()
+	return tokenClaims(t, resp).ID
+}
+
+func TestLogin(t *testing.T) {
+	service := auth.New(discardLogger(), newMockUserRepository(t, map[string]string{"alice": "correct-horse"}))
+
+	token, err := service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+	assertTokenFor(t, token, "alice")
+
+	_, err = service.Login(context.Background(), "alice", "wrong-password")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+	_, err = service.Login(context.Background(), "bob", "correct-horse")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials, "unknown users must not be registered by Login")
+}
+
+func TestRegisterUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantErr  error
+	}{
+		{name: "Success", username: "carol", password: "long-enough"},
+		{name: "Taken", username: "alice", password: "long-enough", wantErr: auth.ErrUsernameTaken},
+		{name: "ShortUsername", username: "al", password: "long-enough", wantErr: auth.ErrInvalidUsername},
+		{name: "UsernameWithSpaces", username: "al ice", password: "long-enough", wantErr: auth.ErrInvalidUsername},
+		{name: "ShortPassword", username: "carol", password: "short", wantErr: auth.ErrInvalidPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := auth.New(discardLogger(), newMockUserRepository(t, map[string]string{"alice": "correct-horse"}))
+
+			token, err := service.RegisterUser(context.Background(), tt.username, tt.password)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assertTokenFor(t, token, tt.username)
+		})
+	}
+
+	assert.True(t, errors.Is(auth.ErrInvalidPassword, auth.ErrValidation))
+}
+
+func TestAuthenticateUser(t *testing.T) {
+	t.Run("RegistersUnknownUser", func(t *testing.T) {
+		repo := newMockUserRepository(t, nil)
+		service := auth.New(discardLogger(), repo)
+
+		token, err := service.AuthenticateUser(context.Background(), "dave", "long-enough")
+		assert.NoError(t, err)
+		assertTokenFor(t, token, "dave")
+		assert.Contains(t, repo.passwords, "dave")
+
+		token, err = service.AuthenticateUser(context.Background(), "dave", "long-enough")
+		assert.NoError(t, err)
+		assertTokenFor(t, token, "dave")
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		service := auth.New(discardLogger(), newMockUserRepository(t, map[string]string{"alice": "correct-horse"}))
+
+		_, err := service.AuthenticateUser(context.Background(), "alice", "wrong-password")
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	})
+
+	t.Run("AutoRegisterDisabled", func(t *testing.T) {
+		repo := newMockUserRepository(t, nil)
+		service := auth.New(discardLogger(), repo, auth.WithAutoRegister(false))
+
+		_, err := service.AuthenticateUser(context.Background(), "dave", "long-enough")
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		assert.NotContains(t, repo.passwords, "dave")
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	repo := newMockUserRepository(t, map[string]string{"alice": "correct-horse"})
+	service := auth.New(discardLogger(), repo)
+
+	first, err := service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+
+	second, err := service.Refresh(context.Background(), first.RefreshToken)
+	assert.NoError(t, err)
+	assertTokenFor(t, second, "alice")
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken, "refresh tokens should rotate")
+
+	_, err = service.Refresh(context.Background(), "made-up")
+	assert.ErrorIs(t, err, auth.ErrInvalidRefreshToken)
+
+	_, err = service.Refresh(context.Background(), first.RefreshToken)
+	assert.ErrorIs(t, err, auth.ErrRefreshTokenReused)
+
+	for _, resp := range []models.AuthResponse{first, second} {
+		revoked, err := service.IsTokenRevoked(context.Background(), tokenID(t, resp))
+		assert.NoError(t, err)
+		assert.True(t, revoked, "reuse should revoke every access token of the session")
+	}
+
+	_, err = service.Refresh(context.Background(), second.RefreshToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidRefreshToken, "the rest of the session should be revoked")
+}
+
+func TestRefreshExpired(t *testing.T) {
+	service := auth.New(discardLogger(), newMockUserRepository(t, map[string]string{"alice": "correct-horse"}),
+		auth.WithTokenTTL(time.Minute, -time.Second))
+
+	resp, err := service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+
+	_, err = service.Refresh(context.Background(), resp.RefreshToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidRefreshToken)
+}
+
+func TestLogout(t *testing.T) {
+	repo := newMockUserRepository(t, map[string]string{"alice": "correct-horse"})
+	service := auth.New(discardLogger(), repo)
+
+	kept, err := service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+	resp, err := service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Logout(context.Background(), "alice", tokenID(t, resp)))
+
+	revoked, err := service.IsTokenRevoked(context.Background(), tokenID(t, resp))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = service.Refresh(context.Background(), resp.RefreshToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidRefreshToken)
+
+	revoked, err = service.IsTokenRevoked(context.Background(), tokenID(t, kept))
+	assert.NoError(t, err)
+	assert.False(t, revoked, "other sessions should stay signed in")
+	_, err = service.Refresh(context.Background(), kept.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestSetUserRole(t *testing.T) {
+	repo := newMockUserRepository(t, map[string]string{"alice": "correct-horse", "root": "correct-horse"})
+	repo.roles["root"] = models.RoleAdmin
+	service := auth.New(discardLogger(), repo)
+
+	resp, err := service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleUser, tokenClaims(t, resp).Role)
+
+	assert.NoError(t, service.SetUserRole(context.Background(), "alice", models.RoleAdmin))
+
+	revoked, err := service.IsTokenRevoked(context.Background(), tokenID(t, resp))
+	assert.NoError(t, err)
+	assert.True(t, revoked, "tokens carrying the old role should be revoked")
+	_, err = service.Refresh(context.Background(), resp.RefreshToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidRefreshToken)
+
+	resp, err = service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, tokenClaims(t, resp).Role)
+
+	assert.ErrorIs(t, service.SetUserRole(context.Background(), "alice", "superuser"), auth.ErrInvalidRole)
+	assert.ErrorIs(t, service.SetUserRole(context.Background(), "bob", models.RoleAuditor), storage.ErrUserNotFound)
+
+	assert.NoError(t, service.SetUserRole(context.Background(), "root", models.RoleUser))
+	assert.ErrorIs(t, service.SetUserRole(context.Background(), "alice", models.RoleUser), auth.ErrLastAdmin)
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	t.Run("CreatesAdmin", func(t *testing.T) {
+		repo := newMockUserRepository(t, nil)
+		service := auth.New(discardLogger(), repo)
+
+		assert.NoError(t, service.BootstrapAdmin(context.Background(), "root", "long-enough"))
+		assert.Equal(t, models.RoleAdmin, repo.roles["root"])
+
+		resp, err := service.Login(context.Background(), "root", "long-enough")
+		assert.NoError(t, err)
+		assert.Equal(t, models.RoleAdmin, tokenClaims(t, resp).Role)
+	})
+
+	t.Run("PromotesExistingUser", func(t *testing.T) {
+		repo := newMockUserRepository(t, map[string]string{"alice": "correct-horse"})
+		service := auth.New(discardLogger(), repo)
+
+		assert.NoError(t, service.BootstrapAdmin(context.Background(), "alice", ""))
+		assert.Equal(t, models.RoleAdmin, repo.roles["alice"])
+	})
+
+	t.Run("AdminExists", func(t *testing.T) {
+		repo := newMockUserRepository(t, map[string]string{"alice": "correct-horse"})
+		repo.roles["alice"] = models.RoleAdmin
+		service := auth.New(discardLogger(), repo)
+
+		assert.NoError(t, service.BootstrapAdmin(context.Background(), "root", "long-enough"))
+		assert.NotContains(t, repo.passwords, "root")
+	})
+
+	t.Run("InvalidPassword", func(t *testing.T) {
+		repo := newMockUserRepository(t, nil)
+		service := auth.New(discardLogger(), repo)
+
+		assert.ErrorIs(t, service.BootstrapAdmin(context.Background(), "root", ""), auth.ErrInvalidPassword)
+		assert.NotContains(t, repo.passwords, "root")
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	repo := newMockUserRepository(t, map[string]string{"alice": "correct-horse"})
+	repo.apiKeys["alice"] = 2
+	service := auth.New(discardLogger(), repo)
+
+	old, err := service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+
+	_, err = service.ChangePassword(context.Background(), "alice", "wrong-password", "battery-staple")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	_, err = service.ChangePassword(context.Background(), "alice", "correct-horse", "short")
+	assert.ErrorIs(t, err, auth.ErrInvalidPassword)
+	assert.Equal(t, 2, repo.apiKeys["alice"], "a rejected change should keep the API keys")
+
+	resp, err := service.ChangePassword(context.Background(), "alice", "correct-horse", "battery-staple")
+	assert.NoError(t, err)
+	assertTokenFor(t, resp, "alice")
+
+	revoked, err := service.IsTokenRevoked(context.Background(), tokenID(t, old))
+	assert.NoError(t, err)
+	assert.True(t, revoked, "sessions started with the old password should be revoked")
+	assert.NotContains(t, repo.apiKeys, "alice", "API keys should be deleted")
+	_, err = service.Refresh(context.Background(), old.RefreshToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidRefreshToken)
+	_, err = service.Refresh(context.Background(), resp.RefreshToken)
+	assert.NoError(t, err, "the new session should work")
+
+	_, err = service.Login(context.Background(), "alice", "correct-horse")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	_, err = service.Login(context.Background(), "alice", "battery-staple")
+	assert.NoError(t, err)
+}
+
+func TestResetPassword(t *testing.T) {
+	repo := newMockUserRepository(t, map[string]string{"alice": "correct-horse", "carol": "hunter2-hunter2"})
+	repo.apiKeys["alice"] = 1
+	repo.apiKeys["carol"] = 1
+	service := auth.New(discardLogger(), repo)
+
+	old, err := service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+
+	_, err = service.IssuePasswordReset(context.Background(), "root", "bob")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	superseded, err := service.IssuePasswordReset(context.Background(), "root", "alice")
+	assert.NoError(t, err)
+	reset, err := service.IssuePasswordReset(context.Background(), "root", "alice")
+	assert.NoError(t, err)
+	assert.NotEqual(t, superseded.Token, reset.Token)
+
+	_, err = service.ResetPassword(context.Background(), superseded.Token, "battery-staple")
+	assert.ErrorIs(t, err, auth.ErrInvalidResetToken, "issuing a new token should invalidate older ones")
+	_, err = service.ResetPassword(context.Background(), reset.Token, "short")
+	assert.ErrorIs(t, err, auth.ErrInvalidPassword)
+
+	resp, err := service.ResetPassword(context.Background(), reset.Token, "battery-staple")
+	assert.NoError(t, err, "a rejected password should not use up the token")
+	assertTokenFor(t, resp, "alice")
+
+	revoked, err := service.IsTokenRevoked(context.Background(), tokenID(t, old))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.NotContains(t, repo.apiKeys, "alice")
+	assert.Equal(t, 1, repo.apiKeys["carol"], "other users' API keys should be left alone")
+	_, err = service.Login(context.Background(), "alice", "battery-staple")
+	assert.NoError(t, err)
+
+	_, err = service.ResetPassword(context.Background(), reset.Token, "another-password")
+	assert.ErrorIs(t, err, auth.ErrInvalidResetToken, "reset tokens work once")
+}
+
+func TestResetPasswordExpired(t *testing.T) {
+	service := auth.New(discardLogger(), newMockUserRepository(t, map[string]string{"alice": "correct-horse"}),
+		auth.WithPasswordResetTTL(-time.Second))
+
+	reset, err := service.IssuePasswordReset(context.Background(), "root", "alice")
+	assert.NoError(t, err)
+
+	_, err = service.ResetPassword(context.Background(), reset.Token, "battery-staple")
+	assert.ErrorIs(t, err, auth.ErrInvalidResetToken)
+}
+
+type stubGuard struct {
+	wait          time.Duration
+	registerWait  time.Duration
+	attempts      []string
+	registrations int
+	successes     []string
+}
+
+func (g *stubGuard) Attempt(ctx context.Context, username string) (time.Duration, error) {
+	if g.wait == 0 {
+		g.attempts = append(g.attempts, username)
+	}
+	return g.wait, nil
+}
+
+func (g *stubGuard) AttemptRegistration(ctx context.Context) (time.Duration, error) {
+	if g.registerWait == 0 {
+		g.registrations++
+	}
+	return g.registerWait, nil
+}
+
+func (g *stubGuard) RecordSuccess(ctx context.Context, username string) error {
+	g.successes = append(g.successes, username)
+	return nil
+}
+
+func TestLoginGuard(t *testing.T) {
+	guard := &stubGuard{}
+	service := auth.New(discardLogger(), newMockUserRepository(t, map[string]string{"alice": "correct-horse"}),
+		auth.WithLoginGuard(guard))
+
+	_, err := service.Login(context.Background(), "alice", "wrong-password")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	_, err = service.Login(context.Background(), "bob", "wrong-password")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	_, err = service.ChangePassword(context.Background(), "alice", "wrong-password", "battery-staple")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	assert.Equal(t, []string{"alice", "bob", "alice"}, guard.attempts)
+	assert.Empty(t, guard.successes, "failed attempts stay counted")
+
+	_, err = service.Login(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, guard.successes)
+
+	guard.wait = 30 * time.Second
+	_, err = service.Login(context.Background(), "alice", "correct-horse")
+	var throttled *auth.ThrottledError
+	if assert.ErrorAs(t, err, &throttled) {
+		assert.Equal(t, 30*time.Second, throttled.RetryAfter)
+	}
+	assert.ErrorIs(t, err, auth.ErrTooManyAttempts)
+	assert.Len(t, guard.successes, 1, "throttled attempts should not be checked")
+}
+
+func TestRegistrationGuard(t *testing.T) {
+	guard := &stubGuard{}
+	repo := newMockUserRepository(t, map[string]string{})
+	service := auth.New(discardLogger(), repo, auth.WithLoginGuard(guard))
+
+	_, err := service.AuthenticateUser(context.Background(), "alice", "correct-horse")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, guard.registrations, "auto-registration should be counted")
+
+	guard.registerWait = time.Minute
+	_, err = service.RegisterUser(context.Background(), "bob", "correct-horse")
+	assert.ErrorIs(t, err, auth.ErrTooManyAttempts)
+	_, err = service.AuthenticateUser(context.Background(), "carol", "correct-horse")
+	assert.ErrorIs(t, err, auth.ErrTooManyAttempts)
+
+	_, err = service.Login(context.Background(), "bob", "correct-horse")
+	assert.ErrorIs(t, err, auth.ErrInvalidCredentials, "throttled sign-ups must not create users")
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"github.com/nglmq/avito-shop/internal/utils/jwt"
+)
+
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, session revoked")
+)
+
+// WithTokenTTL sets how long access and refresh tokens stay valid.
+func WithTokenTTL(access, refresh time.Duration) Option {
+	return func(s *Service) {
+		s.accessTTL = access
+		s.refreshTTL = refresh
+	}
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token. Each
+// refresh token works once: presenting a used one means it has leaked, so the
+// whole session is revoked, including the access tokens issued in it.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (models.AuthResponse, error) {
+	resp, username, err := s.refresh(ctx, refreshToken)
+	audit.Record(ctx, s.auditor, username, models.AuditActionRefresh, nil, err)
+
+	return resp, err
+}
+
+func (s *Service) refresh(ctx context.Context, refreshToken string) (models.AuthResponse, string, error) {
+	var (
+		resp   models.AuthResponse
+		stored models.RefreshToken
+		reused bool
+	)
+	now := time.Now().UTC()
+
+	err := s.userRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		stored, err = s.userRepo.GetRefreshTokenForUpdate(ctx, hashToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, storage.ErrRefreshTokenNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if stored.UsedAt != nil {
+			// Committed on purpose: the revocation must stick even though
+			// the refresh fails.
+			reused = true
+			return s.revokeFamily(ctx, stored.FamilyID, now)
+		}
+		if stored.RevokedAt != nil || !now.Before(stored.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		if err := s.userRepo.MarkRefreshTokenUsed(ctx, stored.ID, now); err != nil {
+			return err
+		}
+
+		resp, err = s.issueSession(ctx, stored.Username, stored.FamilyID)
+		return err
+	})
+	if err != nil {
+		return models.AuthResponse{}, stored.Username, err
+	}
+
+	if reused {
+		s.logger.Warn("Refresh token reused, session revoked",
+			slog.String("username", stored.Username),
+			slog.String("family", stored.FamilyID))
+		return models.AuthResponse{}, stored.Username, ErrRefreshTokenReused
+	}
+
+	return resp, stored.Username, nil
+}
+
+// Logout revokes the access token with the given id and the session it
+// belongs to, so that its refresh token stops working too.
+func (s *Service) Logout(ctx context.Context, username, tokenID string) error {
+	now := time.Now().UTC()
+
+	err := s.userRepo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.RevokeTokens(ctx, []string{tokenID}, now.Add(s.accessTTL)); err != nil {
+			return err
+		}
+
+		familyID, err := s.userRepo.GetRefreshTokenFamily(ctx, tokenID)
+		if err != nil {
+			if errors.Is(err, storage.ErrRefreshTokenNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		return s.revokeFamily(ctx, familyID, now)
+	})
+	if err != nil {
+		s.logger.Error("Error logging out",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+	}
+	audit.Record(ctx, s.auditor, username, models.AuditActionLogout, nil, err)
+
+	return err
+}
+
+// IsTokenRevoked reports whether the access token with the given id has been
+// revoked by a logout or a detected refresh token reuse.
+func (s *Service) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return s.userRepo.IsTokenRevoked(ctx, tokenID)
+}
+
+// DeleteExpiredTokens forgets refresh tokens, password reset tokens and
+// revocations that have expired, and returns how many were removed.
+func (s *Service) DeleteExpiredTokens(ctx context.Context) (int64, error) {
+	return s.userRepo.DeleteExpiredTokens(ctx, time.Now().UTC())
+}
+
+// issueSession signs an access token carrying the user's current role and
+// stores a new refresh token in the given family, starting a new one when
+// familyID is empty. Role changes thus reach the user on their next refresh.
+func (s *Service) issueSession(ctx context.Context, username, familyID string) (models.AuthResponse, error) {
+	role, err := s.userRepo.GetUserRole(ctx, username)
+	if err != nil {
+		s.logger.Error("Error fetching user role",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return models.AuthResponse{}, fmt.Errorf("error fetching user role: %w", err)
+	}
+
+	token, claims, err := ujwt.BuildJWTString(username, role, s.accessTTL)
+	if err != nil {
+		s.logger.Error("Error generating JWT token",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return models.AuthResponse{}, fmt.Errorf("error generating JWT token: %w", err)
+	}
+
+	if familyID == "" {
+		if familyID, err = randomToken(16); err != nil {
+			return models.AuthResponse{}, err
+		}
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	err = s.userRepo.CreateRefreshToken(ctx, hashToken(refreshToken), models.RefreshToken{
+		FamilyID:      familyID,
+		Username:      username,
+		AccessTokenID: claims.ID,
+		ExpiresAt:     time.Now().Add(s.refreshTTL).UTC(),
+	})
+	if err != nil {
+		s.logger.Error("Error storing refresh token",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return models.AuthResponse{}, err
+	}
+
+	return models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresAt:    claims.ExpiresAt.Time.UTC(),
+	}, nil
+}
+
+// revokeFamily revokes every refresh token of the family and the access
+// tokens issued with them. Access tokens expire at most accessTTL after now,
+// so their revocations can be dropped after that.
+func (s *Service) revokeFamily(ctx context.Context, familyID string, now time.Time) error {
+	ids, err := s.userRepo.RevokeRefreshTokenFamily(ctx, familyID, now)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.userRepo.RevokeTokens(ctx, ids, now.Add(s.accessTTL))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,242 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const (
+	DefaultHistorySize = 100
+	DefaultHistoryTTL  = 10 * time.Minute
+	DefaultBufferSize  = 16
+)
+
+// Bus is an in-process publish/subscribe hub for user notifications. It keeps
+// the recent events of every user so that a reconnecting client can resume
+// after the last event it saw. Event IDs are assigned by the caller, normally
+// the outbox, so they stay valid across restarts even though the buffered
+// history does not.
+type Bus struct {
+	historySize int
+	historyTTL  time.Duration
+	bufferSize  int
+
+	mu      sync.Mutex
+	history map[string][]models.Event
+	subs    map[string]map[*Subscription]struct{}
+	closed  bool
+}
+
+// Subscription delivers a user's events as they are published. Events is
+// closed when the subscriber falls too far behind or the bus shuts down;
+// the client is expected to reconnect with the last event ID it received.
+type Subscription struct {
+	// Replay holds the buffered events published after the requested ID.
+	Replay []models.Event
+	Events <-chan models.Event
+
+	bus      *Bus
+	username string
+	ch       chan models.Event
+	once     sync.Once
+}
+
+type Option func(*Bus)
+
+// WithHistorySize sets how many recent events are kept per user for
+// resumption.
+func WithHistorySize(n int) Option {
+	return func(b *Bus) {
+		b.historySize = n
+	}
+}
+
+// WithHistoryTTL sets how long an event is kept for resumption. Users whose
+// events have all expired are dropped by Prune.
+func WithHistoryTTL(d time.Duration) Option {
+	return func(b *Bus) {
+		b.historyTTL = d
+	}
+}
+
+// WithBufferSize sets how many undelivered events a subscriber may have
+// before it is disconnected.
+func WithBufferSize(n int) Option {
+	return func(b *Bus) {
+		b.bufferSize = n
+	}
+}
+
+func New(opts ...Option) *Bus {
+	b := &Bus{
+		historySize: DefaultHistorySize,
+		historyTTL:  DefaultHistoryTTL,
+		bufferSize:  DefaultBufferSize,
+		history:     make(map[string][]models.Event),
+		subs:        make(map[string]map[*Subscription]struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Publish records ev for ev.Username and delivers it to their open
+// subscriptions without blocking. An event whose ID is still in the user's
+// history is a redelivery and is dropped. IDs need not increase: outbox IDs
+// are assigned on insert, so a transaction that commits later may carry a
+// lower one.
+func (b *Bus) Publish(ev models.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	history := b.live(b.history[ev.Username], time.Now().UTC())
+	for _, seen := range history {
+		if seen.ID == ev.ID {
+			return
+		}
+	}
+
+	history = append(history, ev)
+	if len(history) > b.historySize {
+		history = history[len(history)-b.historySize:]
+	}
+	b.history[ev.Username] = history
+
+	for sub := range b.subs[ev.Username] {
+		select {
+		case sub.ch <- ev:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe opens a subscription for username. The buffered events published
+// after the one with lastEventID are returned in Replay, in the order they
+// were published; pass 0 for none. If that event is no longer buffered, the
+// events with a greater ID are returned instead.
+func (b *Bus) Subscribe(username string, lastEventID int64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan models.Event, b.bufferSize)
+	sub := &Subscription{
+		Events:   ch,
+		bus:      b,
+		username: username,
+		ch:       ch,
+	}
+
+	if lastEventID > 0 {
+		sub.Replay = replayAfter(b.live(b.history[username], time.Now().UTC()), lastEventID)
+	}
+
+	if b.closed {
+		sub.close()
+		return sub
+	}
+
+	if b.subs[username] == nil {
+		b.subs[username] = make(map[*Subscription]struct{})
+	}
+	b.subs[username][sub] = struct{}{}
+
+	return sub
+}
+
+// Prune drops expired events and forgets users with no events left. It
+// returns the number of users dropped.
+func (b *Bus) Prune(now time.Time) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var dropped int
+	for username, history := range b.history {
+		history = b.live(history, now)
+		if len(history) == 0 {
+			delete(b.history, username)
+			dropped++
+			continue
+		}
+		b.history[username] = history
+	}
+
+	return dropped
+}
+
+// replayAfter returns the events of history that follow the one with
+// lastEventID. IDs are only used for ordering when that event is gone.
+func replayAfter(history []models.Event, lastEventID int64) []models.Event {
+	for i, ev := range history {
+		if ev.ID == lastEventID {
+			return append([]models.Event(nil), history[i+1:]...)
+		}
+	}
+
+	var replay []models.Event
+	for _, ev := range history {
+		if ev.ID > lastEventID {
+			replay = append(replay, ev)
+		}
+	}
+
+	return replay
+}
+
+// live returns the suffix of history that has not expired at now.
+func (b *Bus) live(history []models.Event, now time.Time) []models.Event {
+	cutoff := now.Add(-b.historyTTL)
+	for i, ev := range history {
+		if ev.CreatedAt.After(cutoff) {
+			return history[i:]
+		}
+	}
+
+	return nil
+}
+
+// Close ends every subscription and stops accepting events.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.remove(sub)
+		}
+	}
+}
+
+// Close stops delivery to the subscription.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.remove(s)
+}
+
+// remove must be called with b.mu held.
+func (b *Bus) remove(sub *Subscription) {
+	if subs := b.subs[sub.username]; subs != nil {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(b.subs, sub.username)
+		}
+	}
+	sub.close()
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		close(s.ch)
+	})
+}
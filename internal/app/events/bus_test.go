@@ -0,0 +1,142 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/events"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func event(id int64, username, eventType string, data any) models.Event {
+	return models.Event{
+		ID:        id,
+		Type:      eventType,
+		Username:  username,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func TestBusDelivery(t *testing.T) {
+	bus := events.New()
+
+	sub := bus.Subscribe("alice", 0)
+	defer sub.Close()
+	other := bus.Subscribe("bob", 0)
+	defer other.Close()
+
+	bus.Publish(event(42, "alice", models.EventCoinsReceived, models.CoinsReceivedEvent{FromUser: "bob", Amount: 10}))
+
+	ev := <-sub.Events
+	assert.Equal(t, int64(42), ev.ID, "the event keeps the ID it was published with")
+	assert.Equal(t, models.EventCoinsReceived, ev.Type)
+	assert.Equal(t, "alice", ev.Username)
+	assert.Len(t, other.Events, 0, "events must only reach their user")
+}
+
+func TestBusReplay(t *testing.T) {
+	bus := events.New(events.WithHistorySize(2))
+
+	for id := int64(1); id <= 3; id++ {
+		bus.Publish(event(id, "alice", models.EventPurchaseCompleted, nil))
+	}
+	bus.Publish(event(4, "bob", models.EventPurchaseCompleted, nil))
+
+	sub := bus.Subscribe("alice", 1)
+	defer sub.Close()
+
+	var ids []int64
+	for _, ev := range sub.Replay {
+		ids = append(ids, ev.ID)
+	}
+	assert.Equal(t, []int64{2, 3}, ids)
+
+	fresh := bus.Subscribe("alice", 0)
+	defer fresh.Close()
+	assert.Empty(t, fresh.Replay, "a new client gets no backlog")
+}
+
+func TestBusDropsRedelivery(t *testing.T) {
+	bus := events.New()
+
+	sub := bus.Subscribe("alice", 0)
+	defer sub.Close()
+
+	bus.Publish(event(1, "alice", models.EventCoinsReceived, nil))
+	bus.Publish(event(1, "alice", models.EventCoinsReceived, nil))
+
+	assert.Len(t, sub.Events, 1, "a relayed event delivered twice must reach the client once")
+}
+
+func TestBusDeliversLateCommits(t *testing.T) {
+	bus := events.New()
+
+	sub := bus.Subscribe("alice", 0)
+	defer sub.Close()
+
+	bus.Publish(event(5, "alice", models.EventCoinsReceived, nil))
+	bus.Publish(event(4, "alice", models.EventCoinsReceived, nil))
+
+	assert.Len(t, sub.Events, 2, "an event with a lower ID that commits later must still be delivered")
+
+	resumed := bus.Subscribe("alice", 5)
+	defer resumed.Close()
+	if assert.Len(t, resumed.Replay, 1, "a client that saw 5 has yet to see 4") {
+		assert.Equal(t, int64(4), resumed.Replay[0].ID)
+	}
+}
+
+func TestBusExpiresHistory(t *testing.T) {
+	bus := events.New(events.WithHistoryTTL(time.Minute))
+
+	stale := event(1, "alice", models.EventCoinsReceived, nil)
+	stale.CreatedAt = time.Now().UTC().Add(-time.Hour)
+	bus.Publish(stale)
+	bus.Publish(event(2, "alice", models.EventCoinsReceived, nil))
+	bus.Publish(event(3, "bob", models.EventCoinsReceived, nil))
+
+	sub := bus.Subscribe("alice", 1)
+	var ids []int64
+	for _, ev := range sub.Replay {
+		ids = append(ids, ev.ID)
+	}
+	sub.Close()
+	assert.Equal(t, []int64{2}, ids, "expired events are not replayed")
+
+	assert.Equal(t, 0, bus.Prune(time.Now().UTC()), "users with live events are kept")
+	assert.Equal(t, 2, bus.Prune(time.Now().UTC().Add(2*time.Minute)), "users whose events expired are dropped")
+
+	sub = bus.Subscribe("alice", 1)
+	defer sub.Close()
+	assert.Empty(t, sub.Replay)
+}
+
+func TestBusDropsSlowSubscriber(t *testing.T) {
+	bus := events.New(events.WithBufferSize(1))
+
+	sub := bus.Subscribe("alice", 0)
+	bus.Publish(event(1, "alice", models.EventCoinsReceived, nil))
+	bus.Publish(event(2, "alice", models.EventCoinsReceived, nil))
+
+	_, ok := <-sub.Events
+	assert.True(t, ok)
+	_, ok = <-sub.Events
+	assert.False(t, ok, "subscriber should be disconnected once its buffer overflows")
+
+	sub.Close()
+}
+
+func TestBusClose(t *testing.T) {
+	bus := events.New()
+	sub := bus.Subscribe("alice", 0)
+
+	bus.Close()
+	_, ok := <-sub.Events
+	assert.False(t, ok)
+
+	late := bus.Subscribe("alice", 0)
+	_, ok = <-late.Events
+	assert.False(t, ok)
+}
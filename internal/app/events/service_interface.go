@@ -0,0 +1,5 @@
+package events
+
+type ServiceInterface interface {
+	Subscribe(username string, lastEventID int64) *Subscription
+}
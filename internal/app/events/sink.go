@@ -0,0 +1,23 @@
+package events
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// Name and Deliver let the bus act as an outbox sink.
+func (b *Bus) Name() string {
+	return "sse"
+}
+
+func (b *Bus) Deliver(ctx context.Context, ev models.OutboxEvent) error {
+	b.Publish(models.Event{
+		ID:        ev.ID,
+		Type:      ev.Type,
+		Username:  ev.Username,
+		Data:      ev.Payload,
+		CreatedAt: ev.CreatedAt,
+	})
+	return nil
+}
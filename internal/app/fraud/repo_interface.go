@@ -0,0 +1,25 @@
+package fraud
+
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type Repository interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	GetUserCreatedAt(ctx context.Context, username string) (time.Time, error)
+	CountNewAccountSenders(ctx context.Context, receiver, exclude string, since, createdAfter time.Time) (int, error)
+	CountSentTransfersSince(ctx context.Context, sender string, since time.Time) (int, error)
+	HasTransferPath(ctx context.Context, from, to string, since time.Time, maxDepth int) (bool, error)
+
+	CreateFraudFlag(ctx context.Context, transactionID int64, verdict models.FraudVerdict) (int64, error)
+	ListFraudFlags(ctx context.Context, status string) ([]models.FraudFlag, error)
+	GetFraudFlag(ctx context.Context, id int64) (models.FraudFlag, error)
+	ReviewFraudFlag(ctx context.Context, id int64, status, admin, note string) error
+
+	UpdateBalance(ctx context.Context, username string, amount int) error
+	ResolveHeldTransaction(ctx context.Context, id int64, status string) error
+	ReopenPaymentRequest(ctx context.Context, transactionID int64) error
+}
@@ -0,0 +1,153 @@
+package fraud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const (
+	RuleFanIn    = "fan_in"
+	RuleCircular = "circular_flow"
+	RuleBurst    = "burst"
+)
+
+var ErrInvalidRules = errors.New("invalid fraud rules")
+
+// Duration is a time.Duration that is written as a string such as "24h" in
+// rule files.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// FanInRule matches when a recipient collects coins from several freshly
+// registered accounts, the usual shape of coin farming.
+type FanInRule struct {
+	Enabled       bool     `json:"enabled"`
+	Action        string   `json:"action"`
+	Window        Duration `json:"window"`
+	NewAccountAge Duration `json:"newAccountAge"`
+	MinSenders    int      `json:"minSenders"`
+}
+
+// CircularRule matches when the coins would return to the sender: the
+// recipient has already passed coins back to the sender through at most
+// MaxDepth transfers within the window.
+type CircularRule struct {
+	Enabled  bool     `json:"enabled"`
+	Action   string   `json:"action"`
+	Window   Duration `json:"window"`
+	MaxDepth int      `json:"maxDepth"`
+}
+
+// BurstRule matches when a sender makes more than MaxTransfers transfers
+// within the window.
+type BurstRule struct {
+	Enabled      bool     `json:"enabled"`
+	Action       string   `json:"action"`
+	Window       Duration `json:"window"`
+	MaxTransfers int      `json:"maxTransfers"`
+}
+
+type Rules struct {
+	FanIn    FanInRule    `json:"fanIn"`
+	Circular CircularRule `json:"circular"`
+	Burst    BurstRule    `json:"burst"`
+}
+
+// DefaultRules holds transfers that look like coin farming and only flags
+// circular flows and bursts.
+func DefaultRules() Rules {
+	return Rules{
+		FanIn: FanInRule{
+			Enabled:       true,
+			Action:        models.FraudActionHold,
+			Window:        Duration(24 * time.Hour),
+			NewAccountAge: Duration(24 * time.Hour),
+			MinSenders:    3,
+		},
+		Circular: CircularRule{
+			Enabled:  true,
+			Action:   models.FraudActionFlag,
+			Window:   Duration(7 * 24 * time.Hour),
+			MaxDepth: 3,
+		},
+		Burst: BurstRule{
+			Enabled:      true,
+			Action:       models.FraudActionFlag,
+			Window:       Duration(time.Minute),
+			MaxTransfers: 10,
+		},
+	}
+}
+
+// LoadRules reads rules from a JSON file. Rules missing from the file keep
+// their default values. An empty path returns DefaultRules.
+func LoadRules(path string) (Rules, error) {
+	rules := DefaultRules()
+	if path == "" {
+		return rules, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, fmt.Errorf("error reading fraud rules: %w", err)
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return Rules{}, fmt.Errorf("%w: %w", ErrInvalidRules, err)
+	}
+	if err := rules.Validate(); err != nil {
+		return Rules{}, err
+	}
+
+	return rules, nil
+}
+
+func (r Rules) Validate() error {
+	checks := []struct {
+		name    string
+		enabled bool
+		action  string
+		window  Duration
+		limit   int
+	}{
+		{RuleFanIn, r.FanIn.Enabled, r.FanIn.Action, r.FanIn.Window, r.FanIn.MinSenders},
+		{RuleCircular, r.Circular.Enabled, r.Circular.Action, r.Circular.Window, r.Circular.MaxDepth},
+		{RuleBurst, r.Burst.Enabled, r.Burst.Action, r.Burst.Window, r.Burst.MaxTransfers},
+	}
+	for _, c := range checks {
+		if !c.enabled {
+			continue
+		}
+		if c.action != models.FraudActionFlag && c.action != models.FraudActionHold {
+			return fmt.Errorf("%w: %s: unknown action %q", ErrInvalidRules, c.name, c.action)
+		}
+		if c.window <= 0 || c.limit <= 0 {
+			return fmt.Errorf("%w: %s: window and threshold must be positive", ErrInvalidRules, c.name)
+		}
+	}
+	if r.FanIn.Enabled && r.FanIn.NewAccountAge <= 0 {
+		return fmt.Errorf("%w: %s: newAccountAge must be positive", ErrInvalidRules, RuleFanIn)
+	}
+
+	return nil
+}
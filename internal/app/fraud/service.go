@@ -0,0 +1,256 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/outbox"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+var (
+	ErrFlagNotFound        = errors.New("fraud flag not found")
+	ErrFlagAlreadyReviewed = errors.New("fraud flag already reviewed")
+	ErrInvalidDecision     = errors.New("invalid review decision")
+)
+
+type Service struct {
+	logger     *slog.Logger
+	repo       Repository
+	rules      Rules
+	events     outbox.Publisher
+	feeAccount string
+}
+
+type Option func(*Service)
+
+// WithPublisher notifies recipients when a held transfer is approved.
+func WithPublisher(p outbox.Publisher) Option {
+	return func(s *Service) {
+		s.events = p
+	}
+}
+
+// WithFeeAccount credits the fee of an approved held transfer to account. The
+// fee is burned when account is empty.
+func WithFeeAccount(account string) Option {
+	return func(s *Service) {
+		s.feeAccount = account
+	}
+}
+
+func New(logger *slog.Logger, repo Repository, rules Rules, opts ...Option) *Service {
+	s := &Service{
+		logger: logger,
+		repo:   repo,
+		rules:  rules,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Service) Rules() Rules {
+	return s.rules
+}
+
+// Evaluate runs every enabled rule against a transfer that is about to be
+// made. The verdict holds the transfer if any matching rule asks for it.
+func (s *Service) Evaluate(ctx context.Context, from, to string, amount int) (models.FraudVerdict, error) {
+	var verdict models.FraudVerdict
+	now := time.Now().UTC()
+
+	match := func(rule, action string) {
+		verdict.Rules = append(verdict.Rules, rule)
+		if verdict.Action != models.FraudActionHold {
+			verdict.Action = action
+		}
+	}
+
+	if r := s.rules.FanIn; r.Enabled {
+		matched, err := s.fanIn(ctx, r, from, to, now)
+		if err != nil {
+			return models.FraudVerdict{}, err
+		}
+		if matched {
+			match(RuleFanIn, r.Action)
+		}
+	}
+
+	if r := s.rules.Circular; r.Enabled {
+		matched, err := s.repo.HasTransferPath(ctx, to, from, now.Add(-time.Duration(r.Window)), r.MaxDepth)
+		if err != nil {
+			return models.FraudVerdict{}, err
+		}
+		if matched {
+			match(RuleCircular, r.Action)
+		}
+	}
+
+	if r := s.rules.Burst; r.Enabled {
+		sent, err := s.repo.CountSentTransfersSince(ctx, from, now.Add(-time.Duration(r.Window)))
+		if err != nil {
+			return models.FraudVerdict{}, err
+		}
+		if sent+1 > r.MaxTransfers {
+			match(RuleBurst, r.Action)
+		}
+	}
+
+	return verdict, nil
+}
+
+// RecordFlag stores the verdict for a transfer so that it shows up for review.
+func (s *Service) RecordFlag(ctx context.Context, transactionID int64, verdict models.FraudVerdict) error {
+	if _, err := s.repo.CreateFraudFlag(ctx, transactionID, verdict); err != nil {
+		return err
+	}
+
+	s.logger.Warn("Suspicious transfer",
+		slog.Int64("transactionId", transactionID),
+		slog.String("action", verdict.Action),
+		slog.Any("rules", verdict.Rules))
+
+	return nil
+}
+
+func (s *Service) ListFlags(ctx context.Context, status string) ([]models.FraudFlag, error) {
+	flags, err := s.repo.ListFraudFlags(ctx, status)
+	if err != nil {
+		s.logger.Error("Error listing fraud flags",
+			slog.String("status", status),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if flags == nil {
+		flags = []models.FraudFlag{}
+	}
+
+	return flags, nil
+}
+
+// ReviewFlag closes an open flag. Approving a held transfer credits the
+// recipient; rejecting it returns the coins to the sender. Flagged transfers
+// have already been completed, so the decision is only recorded; a rejected
+// one can then be undone with a reversal.
+func (s *Service) ReviewFlag(
+	ctx context.Context,
+	admin string,
+	id int64,
+	req models.FraudReviewRequest,
+) (models.FraudFlag, error) {
+	var status string
+	switch req.Decision {
+	case models.FraudDecisionApprove:
+		status = models.FraudFlagStatusApproved
+	case models.FraudDecisionReject:
+		status = models.FraudFlagStatusRejected
+	default:
+		return models.FraudFlag{}, ErrInvalidDecision
+	}
+
+	var reviewed models.FraudFlag
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		flag, err := s.repo.GetFraudFlag(ctx, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrFraudFlagNotFound) {
+				return ErrFlagNotFound
+			}
+			return err
+		}
+		if flag.Status != models.FraudFlagStatusOpen {
+			return ErrFlagAlreadyReviewed
+		}
+
+		if flag.Action == models.FraudActionHold {
+			// The fee was held with the transfer: it is collected on
+			// approval and refunded to the sender on rejection.
+			payee, amount, txStatus := flag.ToUser, flag.Amount, models.TransactionStatusCompleted
+			if status == models.FraudFlagStatusRejected {
+				payee, amount, txStatus = flag.FromUser, flag.Amount+flag.Fee, models.TransactionStatusDeclined
+			}
+
+			if err := s.repo.UpdateBalance(ctx, payee, amount); err != nil {
+				return fmt.Errorf("error releasing held coins: %w", err)
+			}
+			if status == models.FraudFlagStatusApproved && flag.Fee > 0 && s.feeAccount != "" {
+				if err := s.repo.UpdateBalance(ctx, s.feeAccount, flag.Fee); err != nil {
+					return fmt.Errorf("error crediting fee account: %w", err)
+				}
+			}
+			if err := s.repo.ResolveHeldTransaction(ctx, flag.TransactionID, txStatus); err != nil {
+				return err
+			}
+			if status == models.FraudFlagStatusRejected {
+				// A payment request paid with the transfer was never
+				// paid after all; the payer can pay it again.
+				if err := s.repo.ReopenPaymentRequest(ctx, flag.TransactionID); err != nil {
+					return err
+				}
+			}
+			if status == models.FraudFlagStatusApproved {
+				err := outbox.Notify(ctx, s.events, flag.ToUser, models.EventCoinsReceived, models.CoinsReceivedEvent{
+					TransactionID: flag.TransactionID,
+					FromUser:      flag.FromUser,
+					Amount:        flag.Amount,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.repo.ReviewFraudFlag(ctx, id, status, admin, req.Note); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		flag.Status = status
+		flag.ReviewedBy = admin
+		flag.ReviewNote = req.Note
+		flag.ReviewedAt = &now
+		reviewed = flag
+
+		return nil
+	})
+	if err != nil {
+		return models.FraudFlag{}, err
+	}
+
+	s.logger.Info("Fraud flag reviewed",
+		slog.Int64("id", id),
+		slog.Int64("transactionId", reviewed.TransactionID),
+		slog.String("status", status),
+		slog.String("admin", admin))
+
+	return reviewed, nil
+}
+
+// fanIn reports whether the transfer comes from a new account and would make
+// the recipient's count of new-account senders in the window reach the
+// threshold.
+func (s *Service) fanIn(ctx context.Context, r FanInRule, from, to string, now time.Time) (bool, error) {
+	createdAfter := now.Add(-time.Duration(r.NewAccountAge))
+
+	createdAt, err := s.repo.GetUserCreatedAt(ctx, from)
+	if err != nil {
+		return false, fmt.Errorf("error fetching account age: %w", err)
+	}
+	if createdAt.Before(createdAfter) {
+		return false, nil
+	}
+
+	others, err := s.repo.CountNewAccountSenders(ctx, to, from, now.Add(-time.Duration(r.Window)), createdAfter)
+	if err != nil {
+		return false, err
+	}
+
+	return others+1 >= r.MinSenders, nil
+}
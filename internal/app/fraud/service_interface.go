@@ -0,0 +1,13 @@
+package fraud
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceInterface interface {
+	Rules() Rules
+	ListFlags(ctx context.Context, status string) ([]models.FraudFlag, error)
+	ReviewFlag(ctx context.Context, admin string, id int64, req models.FraudReviewRequest) (models.FraudFlag, error)
+}
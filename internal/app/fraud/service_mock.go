@@ -0,0 +1,34 @@
+package fraud
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceMock struct {
+	RulesFunc      func() Rules
+	ListFlagsFunc  func(ctx context.Context, status string) ([]models.FraudFlag, error)
+	ReviewFlagFunc func(ctx context.Context, admin string, id int64, req models.FraudReviewRequest) (models.FraudFlag, error)
+}
+
+func (m *ServiceMock) Rules() Rules {
+	if m.RulesFunc != nil {
+		return m.RulesFunc()
+	}
+	return Rules{}
+}
+
+func (m *ServiceMock) ListFlags(ctx context.Context, status string) ([]models.FraudFlag, error) {
+	if m.ListFlagsFunc != nil {
+		return m.ListFlagsFunc(ctx, status)
+	}
+	return nil, nil
+}
+
+func (m *ServiceMock) ReviewFlag(ctx context.Context, admin string, id int64, req models.FraudReviewRequest) (models.FraudFlag, error) {
+	if m.ReviewFlagFunc != nil {
+		return m.ReviewFlagFunc(ctx, admin, id, req)
+	}
+	return models.FraudFlag{}, nil
+}
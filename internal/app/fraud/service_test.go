@@ -0,0 +1,328 @@
+package fraud_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/fraud"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type MockRepository struct {
+	GetUserCreatedAtFunc        func(ctx context.Context, username string) (time.Time, error)
+	CountNewAccountSendersFunc  func(ctx context.Context, receiver, exclude string, since, createdAfter time.Time) (int, error)
+	CountSentTransfersSinceFunc func(ctx context.Context, sender string, since time.Time) (int, error)
+	HasTransferPathFunc         func(ctx context.Context, from, to string, since time.Time, maxDepth int) (bool, error)
+	CreateFraudFlagFunc         func(ctx context.Context, transactionID int64, verdict models.FraudVerdict) (int64, error)
+	ListFraudFlagsFunc          func(ctx context.Context, status string) ([]models.FraudFlag, error)
+	GetFraudFlagFunc            func(ctx context.Context, id int64) (models.FraudFlag, error)
+	ReviewFraudFlagFunc         func(ctx context.Context, id int64, status, admin, note string) error
+	UpdateBalanceFunc           func(ctx context.Context, username string, amount int) error
+	ResolveHeldTransactionFunc  func(ctx context.Context, id int64, status string) error
+	ReopenPaymentRequestFunc    func(ctx context.Context, transactionID int64) error
+}
+
+func (m *MockRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockRepository) GetUserCreatedAt(ctx context.Context, username string) (time.Time, error) {
+	if m.GetUserCreatedAtFunc != nil {
+		return m.GetUserCreatedAtFunc(ctx, username)
+	}
+	return time.Time{}, nil
+}
+
+func (m *MockRepository) CountNewAccountSenders(ctx context.Context, receiver, exclude string, since, createdAfter time.Time) (int, error) {
+	if m.CountNewAccountSendersFunc != nil {
+		return m.CountNewAccountSendersFunc(ctx, receiver, exclude, since, createdAfter)
+	}
+	return 0, nil
+}
+
+func (m *MockRepository) CountSentTransfersSince(ctx context.Context, sender string, since time.Time) (int, error) {
+	if m.CountSentTransfersSinceFunc != nil {
+		return m.CountSentTransfersSinceFunc(ctx, sender, since)
+	}
+	return 0, nil
+}
+
+func (m *MockRepository) HasTransferPath(ctx context.Context, from, to string, since time.Time, maxDepth int) (bool, error) {
+	if m.HasTransferPathFunc != nil {
+		return m.HasTransferPathFunc(ctx, from, to, since, maxDepth)
+	}
+	return false, nil
+}
+
+func (m *MockRepository) CreateFraudFlag(ctx context.Context, transactionID int64, verdict models.FraudVerdict) (int64, error) {
+	if m.CreateFraudFlagFunc != nil {
+		return m.CreateFraudFlagFunc(ctx, transactionID, verdict)
+	}
+	return 1, nil
+}
+
+func (m *MockRepository) ListFraudFlags(ctx context.Context, status string) ([]models.FraudFlag, error) {
+	if m.ListFraudFlagsFunc != nil {
+		return m.ListFraudFlagsFunc(ctx, status)
+	}
+	return nil, nil
+}
+
+func (m *MockRepository) GetFraudFlag(ctx context.Context, id int64) (models.FraudFlag, error) {
+	if m.GetFraudFlagFunc != nil {
+		return m.GetFraudFlagFunc(ctx, id)
+	}
+	return models.FraudFlag{}, nil
+}
+
+func (m *MockRepository) ReviewFraudFlag(ctx context.Context, id int64, status, admin, note string) error {
+	if m.ReviewFraudFlagFunc != nil {
+		return m.ReviewFraudFlagFunc(ctx, id, status, admin, note)
+	}
+	return nil
+}
+
+func (m *MockRepository) UpdateBalance(ctx context.Context, username string, amount int) error {
+	if m.UpdateBalanceFunc != nil {
+		return m.UpdateBalanceFunc(ctx, username, amount)
+	}
+	return nil
+}
+
+func (m *MockRepository) ResolveHeldTransaction(ctx context.Context, id int64, status string) error {
+	if m.ResolveHeldTransactionFunc != nil {
+		return m.ResolveHeldTransactionFunc(ctx, id, status)
+	}
+	return nil
+}
+
+func (m *MockRepository) ReopenPaymentRequest(ctx context.Context, transactionID int64) error {
+	if m.ReopenPaymentRequestFunc != nil {
+		return m.ReopenPaymentRequestFunc(ctx, transactionID)
+	}
+	return nil
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		accountAge  time.Duration
+		newSenders  int
+		circular    bool
+		recentSends int
+		want        models.FraudVerdict
+	}{
+		{
+			name:       "Clean transfer",
+			accountAge: 30 * 24 * time.Hour,
+			newSenders: 5,
+		},
+		{
+			name:       "Fan-in from new account is held",
+			accountAge: time.Hour,
+			newSenders: 2,
+			want:       models.FraudVerdict{Action: models.FraudActionHold, Rules: []string{fraud.RuleFanIn}},
+		},
+		{
+			name:       "New account below fan-in threshold",
+			accountAge: time.Hour,
+			newSenders: 1,
+		},
+		{
+			name:       "Circular flow is flagged",
+			accountAge: 30 * 24 * time.Hour,
+			circular:   true,
+			want:       models.FraudVerdict{Action: models.FraudActionFlag, Rules: []string{fraud.RuleCircular}},
+		},
+		{
+			name:        "Burst is flagged",
+			accountAge:  30 * 24 * time.Hour,
+			recentSends: 10,
+			want:        models.FraudVerdict{Action: models.FraudActionFlag, Rules: []string{fraud.RuleBurst}},
+		},
+		{
+			name:        "Hold wins over flag",
+			accountAge:  time.Hour,
+			newSenders:  2,
+			recentSends: 10,
+			want:        models.FraudVerdict{Action: models.FraudActionHold, Rules: []string{fraud.RuleFanIn, fraud.RuleBurst}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockRepository{
+				GetUserCreatedAtFunc: func(ctx context.Context, username string) (time.Time, error) {
+					return time.Now().UTC().Add(-tt.accountAge), nil
+				},
+				CountNewAccountSendersFunc: func(ctx context.Context, receiver, exclude string, since, createdAfter time.Time) (int, error) {
+					return tt.newSenders, nil
+				},
+				HasTransferPathFunc: func(ctx context.Context, from, to string, since time.Time, maxDepth int) (bool, error) {
+					if from != "bob" || to != "alice" {
+						t.Errorf("expected path from recipient to sender, got %s -> %s", from, to)
+					}
+					return tt.circular, nil
+				},
+				CountSentTransfersSinceFunc: func(ctx context.Context, sender string, since time.Time) (int, error) {
+					return tt.recentSends, nil
+				},
+			}
+
+			service := fraud.New(nil, repo, fraud.DefaultRules())
+			verdict, err := service.Evaluate(context.Background(), "alice", "bob", 100)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(verdict, tt.want) {
+				t.Errorf("expected verdict %+v, got %+v", tt.want, verdict)
+			}
+		})
+	}
+}
+
+func TestReviewFlag(t *testing.T) {
+	held := models.FraudFlag{
+		ID:            1,
+		TransactionID: 10,
+		FromUser:      "alice",
+		ToUser:        "bob",
+		Amount:        100,
+		Fee:           5,
+		Action:        models.FraudActionHold,
+		Status:        models.FraudFlagStatusOpen,
+	}
+
+	tests := []struct {
+		name         string
+		flag         models.FraudFlag
+		decision     string
+		wantErr      error
+		wantCredits  map[string]int
+		wantTxStatus string
+		wantReopened bool
+	}{
+		{
+			name:         "Approve held transfer",
+			flag:         held,
+			decision:     models.FraudDecisionApprove,
+			wantCredits:  map[string]int{"bob": 100, "treasury": 5},
+			wantTxStatus: models.TransactionStatusCompleted,
+		},
+		{
+			name:         "Reject held transfer",
+			flag:         held,
+			decision:     models.FraudDecisionReject,
+			wantCredits:  map[string]int{"alice": 105},
+			wantTxStatus: models.TransactionStatusDeclined,
+			wantReopened: true,
+		},
+		{
+			name: "Reject flagged transfer",
+			flag: func() models.FraudFlag {
+				f := held
+				f.Action = models.FraudActionFlag
+				return f
+			}(),
+			decision: models.FraudDecisionReject,
+		},
+		{
+			name: "Already reviewed",
+			flag: func() models.FraudFlag {
+				f := held
+				f.Status = models.FraudFlagStatusApproved
+				return f
+			}(),
+			decision: models.FraudDecisionApprove,
+			wantErr:  fraud.ErrFlagAlreadyReviewed,
+		},
+		{
+			name:     "Invalid decision",
+			flag:     held,
+			decision: "maybe",
+			wantErr:  fraud.ErrInvalidDecision,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				txStatus string
+				reopened bool
+			)
+			credits := map[string]int{}
+			repo := &MockRepository{
+				GetFraudFlagFunc: func(ctx context.Context, id int64) (models.FraudFlag, error) {
+					return tt.flag, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+					credits[username] += amount
+					return nil
+				},
+				ResolveHeldTransactionFunc: func(ctx context.Context, id int64, status string) error {
+					txStatus = status
+					return nil
+				},
+				ReopenPaymentRequestFunc: func(ctx context.Context, transactionID int64) error {
+					reopened = transactionID == tt.flag.TransactionID
+					return nil
+				},
+			}
+
+			service := fraud.New(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, fraud.DefaultRules(),
+				fraud.WithFeeAccount("treasury"))
+			_, err := service.ReviewFlag(context.Background(), "admin", 1, models.FraudReviewRequest{Decision: tt.decision})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(credits) != len(tt.wantCredits) {
+				t.Errorf("expected credits %v, got %v", tt.wantCredits, credits)
+			}
+			for user, amount := range tt.wantCredits {
+				if credits[user] != amount {
+					t.Errorf("expected %s credited %d, got %d", user, amount, credits[user])
+				}
+			}
+			if txStatus != tt.wantTxStatus {
+				t.Errorf("expected transaction status %q, got %q", tt.wantTxStatus, txStatus)
+			}
+			if reopened != tt.wantReopened {
+				t.Errorf("expected payment request reopened %v, got %v", tt.wantReopened, reopened)
+			}
+		})
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	if err := os.WriteFile(valid, []byte(`{"burst": {"enabled": true, "action": "hold", "window": "5m", "maxTransfers": 3}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := fraud.LoadRules(valid)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if rules.Burst.Action != models.FraudActionHold || rules.Burst.Window != fraud.Duration(5*time.Minute) {
+		t.Errorf("unexpected burst rule %+v", rules.Burst)
+	}
+	if rules.FanIn != fraud.DefaultRules().FanIn {
+		t.Errorf("expected fan-in rule to keep defaults, got %+v", rules.FanIn)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"circular": {"enabled": true, "action": "block", "window": "1h", "maxDepth": 2}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fraud.LoadRules(invalid); !errors.Is(err, fraud.ErrInvalidRules) {
+		t.Errorf("expected ErrInvalidRules, got %v", err)
+	}
+}
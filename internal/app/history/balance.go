@@ -0,0 +1,43 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+var ErrBeforeAccountCreated = errors.New("account did not exist at that time")
+
+// GetBalanceAsOf reconstructs the user's balance at the given moment from
+// their transfer and purchase history. A zero time means now.
+func (s *InfoService) GetBalanceAsOf(ctx context.Context, username string, at time.Time) (models.BalanceAsOf, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	at = at.UTC()
+
+	balance, createdAt, err := s.repository.GetBalanceAsOf(ctx, username, at, s.feeAccount)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.BalanceAsOf{}, err
+		}
+		s.logger.Error("Error reconstructing balance",
+			slog.String("username", username),
+			slog.String("at", at.Format(time.RFC3339)),
+			slog.String("error", err.Error()))
+		return models.BalanceAsOf{}, err
+	}
+	if at.Before(createdAt) {
+		return models.BalanceAsOf{}, ErrBeforeAccountCreated
+	}
+
+	return models.BalanceAsOf{
+		Username: username,
+		At:       at,
+		Balance:  balance,
+	}, nil
+}
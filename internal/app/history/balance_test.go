@@ -0,0 +1,32 @@
+package history_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBalanceAsOf(t *testing.T) {
+	createdAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	var gotFeeAccount string
+	repo := &MockInfoRepository{
+		GetBalanceAsOfFunc: func(ctx context.Context, username string, at time.Time, feeAccount string) (int, time.Time, error) {
+			gotFeeAccount = feeAccount
+			return 640, createdAt, nil
+		},
+	}
+	service := history.New(nil, repo, history.WithFeeAccount("treasury"))
+
+	at := time.Date(2026, 3, 31, 23, 59, 59, 0, time.FixedZone("MSK", 3*60*60))
+	balance, err := service.GetBalanceAsOf(context.Background(), "alice", at)
+	assert.NoError(t, err)
+	assert.Equal(t, models.BalanceAsOf{Username: "alice", At: at.UTC(), Balance: 640}, balance)
+	assert.Equal(t, "treasury", gotFeeAccount)
+
+	_, err = service.GetBalanceAsOf(context.Background(), "alice", createdAt.Add(-time.Hour))
+	assert.ErrorIs(t, err, history.ErrBeforeAccountCreated)
+}
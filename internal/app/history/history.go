@@ -0,0 +1,169 @@
+package history
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+
+	exportBatchSize = 500
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidFilter = errors.New("invalid history filter")
+)
+
+// GetHistory returns one page of the user's history, newest first. Pass the
+// returned NextCursor back to fetch the following page.
+func (s *InfoService) GetHistory(
+	ctx context.Context,
+	username string,
+	filter models.HistoryFilter,
+	cursor string,
+) (models.HistoryPage, error) {
+	if err := validateFilter(filter); err != nil {
+		return models.HistoryPage{}, err
+	}
+
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = DefaultPageSize
+	case filter.Limit > MaxPageSize:
+		filter.Limit = MaxPageSize
+	}
+
+	if cursor != "" {
+		after, err := DecodeCursor(cursor)
+		if err != nil {
+			return models.HistoryPage{}, err
+		}
+		filter.After = &after
+	}
+
+	pageSize := filter.Limit
+	filter.Limit++
+
+	entries, err := s.repository.GetHistory(ctx, username, filter)
+	if err != nil {
+		s.logger.Error("Error getting user history",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return models.HistoryPage{}, err
+	}
+
+	page := models.HistoryPage{Entries: entries}
+	if len(entries) > pageSize {
+		page.Entries = entries[:pageSize]
+		page.NextCursor = EncodeCursor(page.Entries[pageSize-1])
+	}
+	if page.Entries == nil {
+		page.Entries = []models.HistoryEntry{}
+	}
+
+	return page, nil
+}
+
+// ExportHistory calls fn for every entry of the user's history matching the
+// filter, newest first. The history is read in batches, so memory use does not
+// grow with its length; the first error returned by fn stops the export.
+func (s *InfoService) ExportHistory(
+	ctx context.Context,
+	username string,
+	filter models.HistoryFilter,
+	fn func(models.HistoryEntry) error,
+) error {
+	if err := validateFilter(filter); err != nil {
+		return err
+	}
+
+	filter.Limit = exportBatchSize
+	filter.After = nil
+	for {
+		entries, err := s.repository.GetHistory(ctx, username, filter)
+		if err != nil {
+			s.logger.Error("Error exporting user history",
+				slog.String("username", username),
+				slog.String("error", err.Error()))
+			return err
+		}
+
+		for _, e := range entries {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+
+		if len(entries) < exportBatchSize {
+			return nil
+		}
+		after := cursorAfter(entries[len(entries)-1])
+		filter.After = &after
+	}
+}
+
+func validateFilter(filter models.HistoryFilter) error {
+	switch filter.Direction {
+	case "", models.HistoryDirectionSent, models.HistoryDirectionReceived, models.HistoryDirectionPurchases:
+	default:
+		return fmt.Errorf("%w: unknown direction %q", ErrInvalidFilter, filter.Direction)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidFilter)
+	}
+
+	return nil
+}
+
+// EncodeCursor returns an opaque cursor pointing right after e.
+func EncodeCursor(e models.HistoryEntry) string {
+	c := cursorAfter(e)
+	raw := c.Timestamp.Format(time.RFC3339Nano) + "|" + c.Source + "|" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (models.HistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return models.HistoryCursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return models.HistoryCursor{}, ErrInvalidCursor
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return models.HistoryCursor{}, ErrInvalidCursor
+	}
+	if parts[1] != models.HistorySourceTransaction && parts[1] != models.HistorySourcePurchase {
+		return models.HistoryCursor{}, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return models.HistoryCursor{}, ErrInvalidCursor
+	}
+
+	return models.HistoryCursor{Timestamp: ts, Source: parts[1], ID: id}, nil
+}
+
+func cursorAfter(e models.HistoryEntry) models.HistoryCursor {
+	source := models.HistorySourceTransaction
+	if e.Type == models.HistoryEntryPurchase {
+		source = models.HistorySourcePurchase
+	}
+
+	return models.HistoryCursor{Timestamp: e.Timestamp.UTC(), Source: source, ID: e.ID}
+}
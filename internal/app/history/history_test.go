@@ -0,0 +1,124 @@
+package history_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHistory(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	entries := []models.HistoryEntry{
+		{ID: 3, Type: models.HistoryEntrySent, Timestamp: now, Amount: 10, Counterparty: "bob"},
+		{ID: 7, Type: models.HistoryEntryPurchase, Timestamp: now.Add(-time.Minute), Amount: 80, Item: "cup", Quantity: 1},
+		{ID: 2, Type: models.HistoryEntryReceived, Timestamp: now.Add(-time.Hour), Amount: 5, Counterparty: "carol"},
+	}
+
+	var got models.HistoryFilter
+	repo := &MockInfoRepository{
+		GetHistoryFunc: func(ctx context.Context, username string, filter models.HistoryFilter) ([]models.HistoryEntry, error) {
+			got = filter
+			if len(entries) > filter.Limit {
+				return entries[:filter.Limit], nil
+			}
+			return entries, nil
+		},
+	}
+	service := history.New(nil, repo)
+
+	page, err := service.GetHistory(context.Background(), "alice", models.HistoryFilter{Limit: 2}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, got.Limit, "repository should be asked for one extra entry")
+	assert.Equal(t, entries[:2], page.Entries)
+	assert.NotEmpty(t, page.NextCursor)
+
+	cursor, err := history.DecodeCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, models.HistoryCursor{Timestamp: entries[1].Timestamp, Source: models.HistorySourcePurchase, ID: 7}, cursor)
+
+	page, err = service.GetHistory(context.Background(), "alice", models.HistoryFilter{}, page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, &cursor, got.After)
+	assert.Equal(t, history.DefaultPageSize+1, got.Limit)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestGetHistoryInvalidInput(t *testing.T) {
+	from := time.Now()
+	to := from.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		filter  models.HistoryFilter
+		cursor  string
+		wantErr error
+	}{
+		{
+			name:    "UnknownDirection",
+			filter:  models.HistoryFilter{Direction: "sideways"},
+			wantErr: history.ErrInvalidFilter,
+		},
+		{
+			name:    "EmptyRange",
+			filter:  models.HistoryFilter{From: &from, To: &to},
+			wantErr: history.ErrInvalidFilter,
+		},
+		{
+			name:    "MalformedCursor",
+			cursor:  "not-a-cursor",
+			wantErr: history.ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := history.New(nil, &MockInfoRepository{})
+			_, err := service.GetHistory(context.Background(), "alice", tt.filter, tt.cursor)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestExportHistory(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	var all []models.HistoryEntry
+	for i := 0; i < 503; i++ {
+		all = append(all, models.HistoryEntry{
+			ID:        int64(1000 - i),
+			Type:      models.HistoryEntrySent,
+			Timestamp: start.Add(-time.Duration(i) * time.Second),
+		})
+	}
+
+	calls := 0
+	repo := &MockInfoRepository{
+		GetHistoryFunc: func(ctx context.Context, username string, filter models.HistoryFilter) ([]models.HistoryEntry, error) {
+			calls++
+			offset := 0
+			if filter.After != nil {
+				for i, e := range all {
+					if e.ID == filter.After.ID {
+						offset = i + 1
+					}
+				}
+			}
+			end := min(offset+filter.Limit, len(all))
+			return all[offset:end], nil
+		},
+	}
+	service := history.New(nil, repo)
+
+	var exported []int64
+	err := service.ExportHistory(context.Background(), "alice", models.HistoryFilter{}, func(e models.HistoryEntry) error {
+		exported = append(exported, e.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Len(t, exported, len(all))
+	assert.Equal(t, all[len(all)-1].ID, exported[len(exported)-1])
+}
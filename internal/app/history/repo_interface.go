@@ -3,8 +3,14 @@ package history
 import (
 	"context"
 	"github.com/nglmq/avito-shop/internal/models"
+	"time"
 )
 
 type InfoRepository interface {
 	GetInfo(ctx context.Context, username string) (models.InfoResponse, error)
+	GetHistory(ctx context.Context, username string, filter models.HistoryFilter) ([]models.HistoryEntry, error)
+	GetStatsBuckets(ctx context.Context, username, bucket string, from, to time.Time) ([]models.StatsBucket, error)
+	GetTopCounterparties(ctx context.Context, username string, from, to time.Time, limit int) ([]models.CounterpartyStats, error)
+	GetTopItems(ctx context.Context, username string, from, to time.Time, limit int) ([]models.ItemStats, error)
+	GetBalanceAsOf(ctx context.Context, username string, at time.Time, feeAccount string) (int, time.Time, error)
 }
@@ -9,13 +9,29 @@ import (
 type InfoService struct {
 	repository InfoRepository
 	logger     *slog.Logger
+	feeAccount string
 }
 
-func New(logger *slog.Logger, repository InfoRepository) *InfoService {
-	return &InfoService{
+type Option func(*InfoService)
+
+// WithFeeAccount names the user credited with transfer fees so that its
+// historical balances include them.
+func WithFeeAccount(username string) Option {
+	return func(s *InfoService) {
+		s.feeAccount = username
+	}
+}
+
+func New(logger *slog.Logger, repository InfoRepository, opts ...Option) *InfoService {
+	s := &InfoService{
 		logger:     logger,
 		repository: repository,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func (s *InfoService) GetInfo(ctx context.Context, username string) (models.InfoResponse, error) {
@@ -3,8 +3,13 @@ package history
 import (
 	"context"
 	"github.com/nglmq/avito-shop/internal/models"
+	"time"
 )
 
 type InfoServiceInterface interface {
 	GetInfo(ctx context.Context, username string) (models.InfoResponse, error)
+	GetHistory(ctx context.Context, username string, filter models.HistoryFilter, cursor string) (models.HistoryPage, error)
+	ExportHistory(ctx context.Context, username string, filter models.HistoryFilter, fn func(models.HistoryEntry) error) error
+	GetStats(ctx context.Context, username string, filter models.StatsFilter) (models.StatsResponse, error)
+	GetBalanceAsOf(ctx context.Context, username string, at time.Time) (models.BalanceAsOf, error)
 }
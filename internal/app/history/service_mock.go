@@ -3,10 +3,15 @@ package history
 import (
 	"context"
 	"github.com/nglmq/avito-shop/internal/models"
+	"time"
 )
 
 type InfoServiceMock struct {
-	GetInfoFunc func(ctx context.Context, username string) (models.InfoResponse, error)
+	GetInfoFunc        func(ctx context.Context, username string) (models.InfoResponse, error)
+	GetHistoryFunc     func(ctx context.Context, username string, filter models.HistoryFilter, cursor string) (models.HistoryPage, error)
+	ExportHistoryFunc  func(ctx context.Context, username string, filter models.HistoryFilter, fn func(models.HistoryEntry) error) error
+	GetStatsFunc       func(ctx context.Context, username string, filter models.StatsFilter) (models.StatsResponse, error)
+	GetBalanceAsOfFunc func(ctx context.Context, username string, at time.Time) (models.BalanceAsOf, error)
 }
 
 func (m *InfoServiceMock) GetInfo(ctx context.Context, username string) (models.InfoResponse, error) {
@@ -15,3 +20,31 @@ func (m *InfoServiceMock) GetInfo(ctx context.Context, username string) (models.
 	}
 	return models.InfoResponse{}, nil
 }
+
+func (m *InfoServiceMock) GetHistory(ctx context.Context, username string, filter models.HistoryFilter, cursor string) (models.HistoryPage, error) {
+	if m.GetHistoryFunc != nil {
+		return m.GetHistoryFunc(ctx, username, filter, cursor)
+	}
+	return models.HistoryPage{}, nil
+}
+
+func (m *InfoServiceMock) ExportHistory(ctx context.Context, username string, filter models.HistoryFilter, fn func(models.HistoryEntry) error) error {
+	if m.ExportHistoryFunc != nil {
+		return m.ExportHistoryFunc(ctx, username, filter, fn)
+	}
+	return nil
+}
+
+func (m *InfoServiceMock) GetStats(ctx context.Context, username string, filter models.StatsFilter) (models.StatsResponse, error) {
+	if m.GetStatsFunc != nil {
+		return m.GetStatsFunc(ctx, username, filter)
+	}
+	return models.StatsResponse{}, nil
+}
+
+func (m *InfoServiceMock) GetBalanceAsOf(ctx context.Context, username string, at time.Time) (models.BalanceAsOf, error) {
+	if m.GetBalanceAsOfFunc != nil {
+		return m.GetBalanceAsOfFunc(ctx, username, at)
+	}
+	return models.BalanceAsOf{}, nil
+}
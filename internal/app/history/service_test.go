@@ -10,10 +10,17 @@ import (
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 )
 
 type MockInfoRepository struct {
-	GetInfoFunc func(ctx context.Context, userUUID string) (models.InfoResponse, error)
+	GetInfoFunc    func(ctx context.Context, userUUID string) (models.InfoResponse, error)
+	GetHistoryFunc func(ctx context.Context, username string, filter models.HistoryFilter) ([]models.HistoryEntry, error)
+
+	GetStatsBucketsFunc      func(ctx context.Context, username, bucket string, from, to time.Time) ([]models.StatsBucket, error)
+	GetTopCounterpartiesFunc func(ctx context.Context, username string, from, to time.Time, limit int) ([]models.CounterpartyStats, error)
+	GetTopItemsFunc          func(ctx context.Context, username string, from, to time.Time, limit int) ([]models.ItemStats, error)
+	GetBalanceAsOfFunc       func(ctx context.Context, username string, at time.Time, feeAccount string) (int, time.Time, error)
 }
 
 func (m *MockInfoRepository) GetInfo(ctx context.Context, userUUID string) (models.InfoResponse, error) {
@@ -23,6 +30,41 @@ func (m *MockInfoRepository) GetInfo(ctx context.Context, userUUID string) (mode
 	return models.InfoResponse{}, nil
 }
 
+func (m *MockInfoRepository) GetHistory(ctx context.Context, username string, filter models.HistoryFilter) ([]models.HistoryEntry, error) {
+	if m.GetHistoryFunc != nil {
+		return m.GetHistoryFunc(ctx, username, filter)
+	}
+	return nil, nil
+}
+
+func (m *MockInfoRepository) GetStatsBuckets(ctx context.Context, username, bucket string, from, to time.Time) ([]models.StatsBucket, error) {
+	if m.GetStatsBucketsFunc != nil {
+		return m.GetStatsBucketsFunc(ctx, username, bucket, from, to)
+	}
+	return nil, nil
+}
+
+func (m *MockInfoRepository) GetTopCounterparties(ctx context.Context, username string, from, to time.Time, limit int) ([]models.CounterpartyStats, error) {
+	if m.GetTopCounterpartiesFunc != nil {
+		return m.GetTopCounterpartiesFunc(ctx, username, from, to, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockInfoRepository) GetTopItems(ctx context.Context, username string, from, to time.Time, limit int) ([]models.ItemStats, error) {
+	if m.GetTopItemsFunc != nil {
+		return m.GetTopItemsFunc(ctx, username, from, to, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockInfoRepository) GetBalanceAsOf(ctx context.Context, username string, at time.Time, feeAccount string) (int, time.Time, error) {
+	if m.GetBalanceAsOfFunc != nil {
+		return m.GetBalanceAsOfFunc(ctx, username, at, feeAccount)
+	}
+	return 0, time.Time{}, nil
+}
+
 func TestGetInfo(t *testing.T) {
 	tests := []struct {
 		name          string
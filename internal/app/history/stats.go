@@ -0,0 +1,151 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const (
+	DefaultStatsTop = 5
+	MaxStatsTop     = 50
+	MaxStatsBuckets = 400
+)
+
+// GetStats summarises what the user spent, sent and received in
+// [filter.From, filter.To), bucketed by day, week, month or year, along with
+// their top counterparties and items. The range defaults to the last twelve
+// months in monthly buckets. Every bucket in the range is present, empty ones
+// included; buckets follow PostgreSQL date_trunc in UTC, weeks start on Monday.
+func (s *InfoService) GetStats(ctx context.Context, username string, filter models.StatsFilter) (models.StatsResponse, error) {
+	if filter.Bucket == "" {
+		filter.Bucket = models.StatsBucketMonth
+	}
+	if filter.To.IsZero() {
+		filter.To = time.Now().UTC()
+	}
+	if filter.From.IsZero() {
+		filter.From = truncateBucket(filter.To.AddDate(-1, 0, 0), models.StatsBucketMonth).AddDate(0, 1, 0)
+	}
+	filter.From, filter.To = filter.From.UTC(), filter.To.UTC()
+
+	switch filter.Bucket {
+	case models.StatsBucketDay, models.StatsBucketWeek, models.StatsBucketMonth, models.StatsBucketYear:
+	default:
+		return models.StatsResponse{}, fmt.Errorf("%w: unknown bucket %q", ErrInvalidFilter, filter.Bucket)
+	}
+	if !filter.From.Before(filter.To) {
+		return models.StatsResponse{}, fmt.Errorf("%w: from must be before to", ErrInvalidFilter)
+	}
+
+	starts := bucketStarts(filter.From, filter.To, filter.Bucket)
+	if len(starts) > MaxStatsBuckets {
+		return models.StatsResponse{}, fmt.Errorf("%w: range spans more than %d buckets", ErrInvalidFilter, MaxStatsBuckets)
+	}
+
+	switch {
+	case filter.Top <= 0:
+		filter.Top = DefaultStatsTop
+	case filter.Top > MaxStatsTop:
+		filter.Top = MaxStatsTop
+	}
+
+	buckets, err := s.repository.GetStatsBuckets(ctx, username, filter.Bucket, filter.From, filter.To)
+	if err != nil {
+		return models.StatsResponse{}, s.statsError(username, err)
+	}
+	counterparties, err := s.repository.GetTopCounterparties(ctx, username, filter.From, filter.To, filter.Top)
+	if err != nil {
+		return models.StatsResponse{}, s.statsError(username, err)
+	}
+	items, err := s.repository.GetTopItems(ctx, username, filter.From, filter.To, filter.Top)
+	if err != nil {
+		return models.StatsResponse{}, s.statsError(username, err)
+	}
+
+	resp := models.StatsResponse{
+		From:              filter.From,
+		To:                filter.To,
+		Bucket:            filter.Bucket,
+		Buckets:           make([]models.StatsBucket, len(starts)),
+		TopCounterparties: counterparties,
+		TopItems:          items,
+	}
+
+	byStart := make(map[time.Time]models.StatsBucket, len(buckets))
+	for _, b := range buckets {
+		byStart[b.Start.UTC()] = b
+	}
+	for i, start := range starts {
+		b := byStart[start]
+		b.Start = start
+		resp.Buckets[i] = b
+
+		resp.Totals.Spent += b.Spent
+		resp.Totals.Sent += b.Sent
+		resp.Totals.Fees += b.Fees
+		resp.Totals.Received += b.Received
+	}
+
+	if resp.TopCounterparties == nil {
+		resp.TopCounterparties = []models.CounterpartyStats{}
+	}
+	if resp.TopItems == nil {
+		resp.TopItems = []models.ItemStats{}
+	}
+
+	return resp, nil
+}
+
+func (s *InfoService) statsError(username string, err error) error {
+	s.logger.Error("Error getting user stats",
+		slog.String("username", username),
+		slog.String("error", err.Error()))
+	return err
+}
+
+// bucketStarts lists the start of every bucket overlapping [from, to).
+func bucketStarts(from, to time.Time, bucket string) []time.Time {
+	var starts []time.Time
+	for t := truncateBucket(from, bucket); t.Before(to); t = nextBucket(t, bucket) {
+		starts = append(starts, t)
+		if len(starts) > MaxStatsBuckets {
+			break
+		}
+	}
+
+	return starts
+}
+
+func truncateBucket(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch bucket {
+	case models.StatsBucketWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case models.StatsBucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case models.StatsBucketYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextBucket(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case models.StatsBucketWeek:
+		return t.AddDate(0, 0, 7)
+	case models.StatsBucketMonth:
+		return t.AddDate(0, 1, 0)
+	case models.StatsBucketYear:
+		return t.AddDate(1, 0, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
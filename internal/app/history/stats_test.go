@@ -0,0 +1,94 @@
+package history_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/history"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStats(t *testing.T) {
+	from := time.Date(2026, 9, 30, 15, 0, 0, 0, time.UTC) // Wednesday
+	to := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	repo := &MockInfoRepository{
+		GetStatsBucketsFunc: func(ctx context.Context, username, bucket string, from, to time.Time) ([]models.StatsBucket, error) {
+			return []models.StatsBucket{
+				{Start: time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC), Spent: 80, Received: 10},
+				{Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Sent: 50, Fees: 2},
+			}, nil
+		},
+		GetTopCounterpartiesFunc: func(ctx context.Context, username string, from, to time.Time, limit int) ([]models.CounterpartyStats, error) {
+			assert.Equal(t, history.DefaultStatsTop, limit)
+			return []models.CounterpartyStats{{Username: "bob", Sent: 50, Transfers: 1}}, nil
+		},
+	}
+	service := history.New(nil, repo)
+
+	stats, err := service.GetStats(context.Background(), "alice", models.StatsFilter{
+		From:   from,
+		To:     to,
+		Bucket: models.StatsBucketWeek,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []models.StatsBucket{
+		{Start: time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC), Spent: 80, Received: 10},
+		{Start: time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Sent: 50, Fees: 2},
+	}, stats.Buckets)
+	assert.Equal(t, models.StatsTotals{Spent: 80, Sent: 50, Fees: 2, Received: 10}, stats.Totals)
+	assert.Len(t, stats.TopCounterparties, 1)
+	assert.NotNil(t, stats.TopItems)
+}
+
+func TestGetStatsDefaults(t *testing.T) {
+	var gotFrom, gotTo time.Time
+	repo := &MockInfoRepository{
+		GetStatsBucketsFunc: func(ctx context.Context, username, bucket string, from, to time.Time) ([]models.StatsBucket, error) {
+			assert.Equal(t, models.StatsBucketMonth, bucket)
+			gotFrom, gotTo = from, to
+			return nil, nil
+		},
+	}
+	service := history.New(nil, repo)
+
+	stats, err := service.GetStats(context.Background(), "alice", models.StatsFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, stats.Buckets, 12)
+	assert.Equal(t, 1, gotFrom.Day())
+	assert.WithinDuration(t, time.Now(), gotTo, time.Minute)
+}
+
+func TestGetStatsInvalidInput(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name   string
+		filter models.StatsFilter
+	}{
+		{
+			name:   "UnknownBucket",
+			filter: models.StatsFilter{Bucket: "hour"},
+		},
+		{
+			name:   "EmptyRange",
+			filter: models.StatsFilter{From: now, To: now.Add(-time.Hour)},
+		},
+		{
+			name:   "TooManyBuckets",
+			filter: models.StatsFilter{From: now.AddDate(-5, 0, 0), To: now, Bucket: models.StatsBucketDay},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := history.New(nil, &MockInfoRepository{})
+			_, err := service.GetStats(context.Background(), "alice", tt.filter)
+			assert.ErrorIs(t, err, history.ErrInvalidFilter)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package leaderboard
+
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type Repository interface {
+	GetTopBalances(ctx context.Context, exclude string, limit int) ([]models.LeaderboardEntry, error)
+	GetTopSenders(ctx context.Context, since *time.Time, limit int) ([]models.LeaderboardEntry, error)
+	GetTopReceivers(ctx context.Context, since *time.Time, limit int) ([]models.LeaderboardEntry, error)
+	GetTopCollectors(ctx context.Context, item string, since *time.Time, limit int) ([]models.LeaderboardEntry, error)
+	SetLeaderboardOptOut(ctx context.Context, username string, optOut bool) error
+}
@@ -0,0 +1,220 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const (
+	DefaultCacheTTL = 5 * time.Minute
+	DefaultLimit    = 10
+	MaxLimit        = 100
+)
+
+var (
+	ErrUnknownBoard  = errors.New("unknown leaderboard")
+	ErrInvalidWindow = errors.New("invalid leaderboard window")
+	ErrUnknownItem   = errors.New("unknown item")
+)
+
+type cachedBoard struct {
+	board     models.Leaderboard
+	expiresAt time.Time
+}
+
+// Service serves leaderboards from an in-memory cache. Every board is
+// computed once per TTL with MaxLimit entries and sliced per request, so the
+// aggregate queries run at most once per TTL regardless of traffic.
+type Service struct {
+	logger     *slog.Logger
+	repo       Repository
+	ttl        time.Duration
+	feeAccount string
+
+	mu    sync.Mutex
+	cache map[string]cachedBoard
+}
+
+type Option func(*Service)
+
+// WithCacheTTL sets how long a computed leaderboard is served before it is
+// recomputed. A zero TTL disables caching.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.ttl = ttl
+	}
+}
+
+// WithFeeAccount names the user credited with transfer fees. It is a system
+// account and is kept off the balances board.
+func WithFeeAccount(username string) Option {
+	return func(s *Service) {
+		s.feeAccount = username
+	}
+}
+
+func New(logger *slog.Logger, repo Repository, opts ...Option) *Service {
+	s := &Service{
+		logger: logger,
+		repo:   repo,
+		ttl:    DefaultCacheTTL,
+		cache:  make(map[string]cachedBoard),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// GetLeaderboard returns the top limit users of board over window. Balances
+// are a snapshot and only support the all-time window; the collectors board
+// ranks buyers of a single item.
+func (s *Service) GetLeaderboard(ctx context.Context, board, window, item string, limit int) (models.Leaderboard, error) {
+	if window == "" {
+		window = models.LeaderboardWindowAll
+	}
+	switch {
+	case limit <= 0:
+		limit = DefaultLimit
+	case limit > MaxLimit:
+		limit = MaxLimit
+	}
+
+	since, err := windowStart(window, time.Now().UTC())
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+
+	switch board {
+	case models.LeaderboardBalances:
+		if window != models.LeaderboardWindowAll {
+			return models.Leaderboard{}, fmt.Errorf("%w: balances are only ranked all-time", ErrInvalidWindow)
+		}
+		item = ""
+	case models.LeaderboardSenders, models.LeaderboardReceivers:
+		item = ""
+	case models.LeaderboardCollectors:
+		if _, ok := models.GetItemPrice(item); !ok {
+			return models.Leaderboard{}, ErrUnknownItem
+		}
+	default:
+		return models.Leaderboard{}, ErrUnknownBoard
+	}
+
+	key := board + "|" + window + "|" + item
+	lb, ok := s.cached(key)
+	if !ok {
+		entries, err := s.fetch(ctx, board, item, since)
+		if err != nil {
+			s.logger.Error("Error computing leaderboard",
+				slog.String("board", board),
+				slog.String("window", window),
+				slog.String("error", err.Error()))
+			return models.Leaderboard{}, err
+		}
+
+		lb = models.Leaderboard{
+			Board:       board,
+			Window:      window,
+			Item:        item,
+			GeneratedAt: time.Now().UTC(),
+			Entries:     rank(entries),
+		}
+		s.store(key, lb)
+	}
+
+	if len(lb.Entries) > limit {
+		lb.Entries = lb.Entries[:limit]
+	}
+
+	return lb, nil
+}
+
+// SetOptOut hides or shows the user on every leaderboard. The cache of this
+// instance is dropped so the change shows here immediately; other instances
+// keep serving their cached boards until the cache TTL expires.
+func (s *Service) SetOptOut(ctx context.Context, username string, optOut bool) error {
+	if err := s.repo.SetLeaderboardOptOut(ctx, username, optOut); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cache = make(map[string]cachedBoard)
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Service) fetch(ctx context.Context, board, item string, since *time.Time) ([]models.LeaderboardEntry, error) {
+	switch board {
+	case models.LeaderboardBalances:
+		return s.repo.GetTopBalances(ctx, s.feeAccount, MaxLimit)
+	case models.LeaderboardSenders:
+		return s.repo.GetTopSenders(ctx, since, MaxLimit)
+	case models.LeaderboardReceivers:
+		return s.repo.GetTopReceivers(ctx, since, MaxLimit)
+	default:
+		return s.repo.GetTopCollectors(ctx, item, since, MaxLimit)
+	}
+}
+
+func (s *Service) cached(key string) (models.Leaderboard, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cache[key]
+	if !ok || !time.Now().Before(c.expiresAt) {
+		return models.Leaderboard{}, false
+	}
+
+	return c.board, true
+}
+
+func (s *Service) store(key string, lb models.Leaderboard) {
+	if s.ttl <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[key] = cachedBoard{board: lb, expiresAt: time.Now().Add(s.ttl)}
+}
+
+// windowStart returns the start of a rolling window ending at now, or nil
+// for all-time.
+func windowStart(window string, now time.Time) (*time.Time, error) {
+	var since time.Time
+	switch window {
+	case models.LeaderboardWindowAll:
+		return nil, nil
+	case models.LeaderboardWindowWeek:
+		since = now.AddDate(0, 0, -7)
+	case models.LeaderboardWindowMonth:
+		since = now.AddDate(0, -1, 0)
+	default:
+		return nil, ErrInvalidWindow
+	}
+
+	return &since, nil
+}
+
+// rank numbers entries already sorted by value, giving ties the same rank.
+func rank(entries []models.LeaderboardEntry) []models.LeaderboardEntry {
+	ranked := make([]models.LeaderboardEntry, len(entries))
+	for i, e := range entries {
+		e.Rank = i + 1
+		if i > 0 && e.Value == ranked[i-1].Value {
+			e.Rank = ranked[i-1].Rank
+		}
+		ranked[i] = e
+	}
+
+	return ranked
+}
@@ -0,0 +1,12 @@
+package leaderboard
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceInterface interface {
+	GetLeaderboard(ctx context.Context, board, window, item string, limit int) (models.Leaderboard, error)
+	SetOptOut(ctx context.Context, username string, optOut bool) error
+}
@@ -0,0 +1,26 @@
+package leaderboard
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceMock struct {
+	GetLeaderboardFunc func(ctx context.Context, board, window, item string, limit int) (models.Leaderboard, error)
+	SetOptOutFunc      func(ctx context.Context, username string, optOut bool) error
+}
+
+func (m *ServiceMock) GetLeaderboard(ctx context.Context, board, window, item string, limit int) (models.Leaderboard, error) {
+	if m.GetLeaderboardFunc != nil {
+		return m.GetLeaderboardFunc(ctx, board, window, item, limit)
+	}
+	return models.Leaderboard{}, nil
+}
+
+func (m *ServiceMock) SetOptOut(ctx context.Context, username string, optOut bool) error {
+	if m.SetOptOutFunc != nil {
+		return m.SetOptOutFunc(ctx, username, optOut)
+	}
+	return nil
+}
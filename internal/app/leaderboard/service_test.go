@@ -0,0 +1,155 @@
+package leaderboard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/leaderboard"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockLeaderboardRepository struct {
+	calls   int
+	since   *time.Time
+	item    string
+	exclude string
+	entries []models.LeaderboardEntry
+	optOut  map[string]bool
+}
+
+func (m *MockLeaderboardRepository) GetTopBalances(ctx context.Context, exclude string, limit int) ([]models.LeaderboardEntry, error) {
+	m.calls++
+	m.exclude = exclude
+	return m.entries, nil
+}
+
+func (m *MockLeaderboardRepository) GetTopSenders(ctx context.Context, since *time.Time, limit int) ([]models.LeaderboardEntry, error) {
+	m.calls++
+	m.since = since
+	return m.entries, nil
+}
+
+func (m *MockLeaderboardRepository) GetTopReceivers(ctx context.Context, since *time.Time, limit int) ([]models.LeaderboardEntry, error) {
+	m.calls++
+	m.since = since
+	return m.entries, nil
+}
+
+func (m *MockLeaderboardRepository) GetTopCollectors(ctx context.Context, item string, since *time.Time, limit int) ([]models.LeaderboardEntry, error) {
+	m.calls++
+	m.since = since
+	m.item = item
+	return m.entries, nil
+}
+
+func (m *MockLeaderboardRepository) SetLeaderboardOptOut(ctx context.Context, username string, optOut bool) error {
+	if m.optOut == nil {
+		m.optOut = make(map[string]bool)
+	}
+	m.optOut[username] = optOut
+	return nil
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	repo := &MockLeaderboardRepository{entries: []models.LeaderboardEntry{
+		{Username: "alice", Value: 300},
+		{Username: "bob", Value: 200},
+		{Username: "carol", Value: 200},
+		{Username: "dave", Value: 100},
+	}}
+	service := leaderboard.New(nil, repo)
+
+	lb, err := service.GetLeaderboard(context.Background(), models.LeaderboardSenders, models.LeaderboardWindowWeek, "", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LeaderboardEntry{
+		{Rank: 1, Username: "alice", Value: 300},
+		{Rank: 2, Username: "bob", Value: 200},
+		{Rank: 2, Username: "carol", Value: 200},
+	}, lb.Entries)
+	if assert.NotNil(t, repo.since) {
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, -7), *repo.since, time.Minute)
+	}
+
+	lb, err = service.GetLeaderboard(context.Background(), models.LeaderboardSenders, models.LeaderboardWindowWeek, "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, lb.Entries, 4)
+	assert.Equal(t, 4, lb.Entries[3].Rank)
+	assert.Equal(t, 1, repo.calls, "second request should be served from cache")
+
+	assert.NoError(t, service.SetOptOut(context.Background(), "alice", true))
+	assert.True(t, repo.optOut["alice"])
+
+	_, err = service.GetLeaderboard(context.Background(), models.LeaderboardSenders, models.LeaderboardWindowWeek, "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, repo.calls, "opt-out should invalidate the cache")
+}
+
+func TestGetLeaderboardCollectors(t *testing.T) {
+	repo := &MockLeaderboardRepository{}
+	service := leaderboard.New(nil, repo, leaderboard.WithCacheTTL(0))
+
+	lb, err := service.GetLeaderboard(context.Background(), models.LeaderboardCollectors, "", "cup", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "cup", repo.item)
+	assert.Nil(t, repo.since)
+	assert.Equal(t, models.LeaderboardWindowAll, lb.Window)
+
+	_, err = service.GetLeaderboard(context.Background(), models.LeaderboardCollectors, "", "cup", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, repo.calls, "zero TTL should disable caching")
+}
+
+func TestGetLeaderboardExcludesFeeAccount(t *testing.T) {
+	repo := &MockLeaderboardRepository{}
+	service := leaderboard.New(nil, repo, leaderboard.WithFeeAccount("treasury"))
+
+	_, err := service.GetLeaderboard(context.Background(), models.LeaderboardBalances, "", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "treasury", repo.exclude)
+}
+
+func TestGetLeaderboardInvalidInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		board   string
+		window  string
+		item    string
+		wantErr error
+	}{
+		{
+			name:    "UnknownBoard",
+			board:   "losers",
+			wantErr: leaderboard.ErrUnknownBoard,
+		},
+		{
+			name:    "UnknownWindow",
+			board:   models.LeaderboardSenders,
+			window:  "decade",
+			wantErr: leaderboard.ErrInvalidWindow,
+		},
+		{
+			name:    "WindowedBalances",
+			board:   models.LeaderboardBalances,
+			window:  models.LeaderboardWindowMonth,
+			wantErr: leaderboard.ErrInvalidWindow,
+		},
+		{
+			name:    "UnknownItem",
+			board:   models.LeaderboardCollectors,
+			item:    "yacht",
+			wantErr: leaderboard.ErrUnknownItem,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockLeaderboardRepository{}
+			service := leaderboard.New(nil, repo)
+			_, err := service.GetLeaderboard(context.Background(), tt.board, tt.window, tt.item, 0)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Zero(t, repo.calls)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type Repository interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	LockLoginAttempts(ctx context.Context, key string, now, windowStart time.Time) (models.LoginAttempts, error)
+	RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (models.LoginAttempts, error)
+	ReleaseLoginAttempt(ctx context.Context, key string) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) (int64, error)
+}
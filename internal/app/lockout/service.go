@@ -0,0 +1,209 @@
+package lockout
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+)
+
+// Policy decides how long a username or client IP has to wait after failed
+// logins. The first FreeAttempts failures cost nothing; each one after that
+// makes the next attempt wait, starting at BaseDelay and doubling up to
+// MaxDelay. LockoutAfter failures lock the key out for LockoutDuration.
+// Failures older than Window are forgotten.
+type Policy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+var (
+	DefaultUserPolicy = Policy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+		Window:          time.Hour,
+	}
+	// DefaultIPPolicy is laxer than DefaultUserPolicy, since many users can
+	// share an address behind a NAT.
+	DefaultIPPolicy = Policy{
+		FreeAttempts:    20,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    100,
+		LockoutDuration: 15 * time.Minute,
+		Window:          time.Hour,
+	}
+)
+
+// Service tracks failed logins per username and per client IP in storage, so
+// that the limits hold across replicas.
+type Service struct {
+	logger *slog.Logger
+	repo   Repository
+	user   Policy
+	ip     Policy
+}
+
+type Option func(*Service)
+
+// WithPolicies sets the policies applied per username and per client IP.
+func WithPolicies(user, ip Policy) Option {
+	return func(s *Service) {
+		s.user = user
+		s.ip = ip
+	}
+}
+
+func New(logger *slog.Logger, repo Repository, opts ...Option) *Service {
+	s := &Service{
+		logger: logger,
+		repo:   repo,
+		user:   DefaultUserPolicy,
+		ip:     DefaultIPPolicy,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Attempt counts an attempt by username to log in from the client IP of the
+// request in ctx, before its password is checked. It returns how long to wait
+// before trying again, in which case nothing is counted, or zero if the
+// attempt may go ahead. The counters stay locked from the check until the
+// attempt is counted, so concurrent guesses cannot all slip under the limit.
+// A counted attempt is a failure unless RecordSuccess follows it.
+func (s *Service) Attempt(ctx context.Context, username string) (time.Duration, error) {
+	return s.attempt(ctx, s.keys(ctx, username))
+}
+
+// AttemptRegistration counts a sign-up from the client IP of the request in
+// ctx under the per-IP policy, the same way as Attempt. Sign-ups are counted
+// apart from logins and never taken back, so a successful login cannot make
+// up for them.
+func (s *Service) AttemptRegistration(ctx context.Context) (time.Duration, error) {
+	ip := audit.RequestInfoFrom(ctx).ClientIP
+	if ip == "" {
+		return 0, nil
+	}
+
+	return s.attempt(ctx, []key{{name: "register:" + ip, policy: s.ip}})
+}
+
+func (s *Service) attempt(ctx context.Context, keys []key) (time.Duration, error) {
+	var wait time.Duration
+	now := time.Now().UTC()
+
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, k := range keys {
+			a, err := s.repo.LockLoginAttempts(ctx, k.name, now, now.Add(-k.policy.Window))
+			if err != nil {
+				return err
+			}
+			if d := k.policy.blockedUntil(a.Failures, a.LastFailureAt).Sub(now); d > wait {
+				wait = d
+			}
+		}
+		if wait > 0 {
+			return nil
+		}
+
+		for _, k := range keys {
+			a, err := s.repo.RecordLoginFailure(ctx, k.name, now, now.Add(-k.policy.Window))
+			if err != nil {
+				return err
+			}
+
+			if k.policy.LockoutAfter > 0 && a.Failures == k.policy.LockoutAfter {
+				s.logger.Warn("Login locked out after repeated failures",
+					slog.String("key", k.name),
+					slog.Int("failures", a.Failures),
+					slog.Duration("duration", k.policy.LockoutDuration))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return wait, nil
+}
+
+// RecordSuccess clears the failures of username and takes back the attempt
+// counted for the client IP. Earlier failures of the IP are kept, or a single
+// valid account would let an attacker reset them.
+func (s *Service) RecordSuccess(ctx context.Context, username string) error {
+	if err := s.repo.ResetLoginAttempts(ctx, userKey(username)); err != nil {
+		return err
+	}
+	if ip := audit.RequestInfoFrom(ctx).ClientIP; ip != "" {
+		return s.repo.ReleaseLoginAttempt(ctx, ipKey(ip))
+	}
+
+	return nil
+}
+
+// DeleteStale forgets counters that can no longer delay anyone and returns
+// how many were removed.
+func (s *Service) DeleteStale(ctx context.Context) (int64, error) {
+	retention := max(s.user.retention(), s.ip.retention())
+	return s.repo.DeleteLoginAttemptsBefore(ctx, time.Now().UTC().Add(-retention))
+}
+
+type key struct {
+	name   string
+	policy Policy
+}
+
+func (s *Service) keys(ctx context.Context, username string) []key {
+	keys := []key{{name: userKey(username), policy: s.user}}
+	if ip := audit.RequestInfoFrom(ctx).ClientIP; ip != "" {
+		keys = append(keys, key{name: ipKey(ip), policy: s.ip})
+	}
+
+	return keys
+}
+
+func userKey(username string) string {
+	return "user:" + username
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// blockedUntil returns when the next attempt is allowed after failures, the
+// last of which happened at last.
+func (p Policy) blockedUntil(failures int, last time.Time) time.Time {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return last.Add(p.LockoutDuration)
+	}
+	if failures <= p.FreeAttempts || p.BaseDelay <= 0 {
+		return time.Time{}
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return last.Add(delay)
+}
+
+func (p Policy) retention() time.Duration {
+	return max(p.Window, p.LockoutDuration, p.MaxDelay)
+}
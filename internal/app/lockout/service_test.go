@@ -0,0 +1,195 @@
+package lockout_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/app/lockout"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockRepository struct {
+	attempts map[string]models.LoginAttempts
+}
+
+func newMockRepository() *MockRepository {
+	return &MockRepository{attempts: make(map[string]models.LoginAttempts)}
+}
+
+func (m *MockRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockRepository) LockLoginAttempts(ctx context.Context, key string, now, windowStart time.Time) (models.LoginAttempts, error) {
+	a, ok := m.attempts[key]
+	if !ok {
+		a = models.LoginAttempts{Key: key, LastFailureAt: now}
+		m.attempts[key] = a
+	}
+	if a.LastFailureAt.Before(windowStart) {
+		a.Failures = 0
+	}
+	return a, nil
+}
+
+func (m *MockRepository) RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (models.LoginAttempts, error) {
+	a := m.attempts[key]
+	if a.LastFailureAt.Before(windowStart) {
+		a.Failures = 0
+	}
+	a.Key, a.Failures, a.LastFailureAt = key, a.Failures+1, now
+	m.attempts[key] = a
+	return a, nil
+}
+
+func (m *MockRepository) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	if a, ok := m.attempts[key]; ok && a.Failures > 0 {
+		a.Failures--
+		m.attempts[key] = a
+	}
+	return nil
+}
+
+func (m *MockRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *MockRepository) DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	for key, a := range m.attempts {
+		if a.LastFailureAt.Before(before) {
+			delete(m.attempts, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+var testPolicy = lockout.Policy{
+	FreeAttempts:    2,
+	BaseDelay:       time.Second,
+	MaxDelay:        4 * time.Second,
+	LockoutAfter:    7,
+	LockoutDuration: time.Hour,
+	Window:          time.Hour,
+}
+
+func newService(repo *MockRepository) *lockout.Service {
+	return lockout.New(slog.New(slog.NewTextHandler(io.Discard, nil)), repo,
+		lockout.WithPolicies(testPolicy, testPolicy))
+}
+
+func fromIP(ip string) context.Context {
+	return audit.WithRequestInfo(context.Background(), audit.RequestInfo{ClientIP: ip})
+}
+
+func TestProgressiveDelay(t *testing.T) {
+	// The wait before an attempt, by the number of failures before it.
+	wantWaits := []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second, time.Hour}
+	for failures, want := range wantWaits {
+		repo := newMockRepository()
+		repo.attempts["user:alice"] = models.LoginAttempts{Key: "user:alice", Failures: failures, LastFailureAt: time.Now().UTC()}
+		service := newService(repo)
+
+		wait, err := service.Attempt(context.Background(), "alice")
+		assert.NoError(t, err)
+		assert.InDelta(t, want.Seconds(), wait.Seconds(), 0.5, "after %d failures", failures)
+
+		counted := failures
+		if want == 0 {
+			counted++
+		}
+		assert.Equal(t, counted, repo.attempts["user:alice"].Failures, "only attempts that go ahead are counted")
+	}
+}
+
+func TestPerIP(t *testing.T) {
+	service := newService(newMockRepository())
+
+	for _, username := range []string{"a", "b", "c"} {
+		wait, err := service.Attempt(fromIP("10.0.0.1"), username)
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+	}
+
+	wait, err := service.Attempt(fromIP("10.0.0.1"), "d")
+	assert.NoError(t, err)
+	assert.Positive(t, wait, "guessing across usernames should throttle the address")
+
+	wait, err = service.Attempt(fromIP("10.0.0.2"), "d")
+	assert.NoError(t, err)
+	assert.Zero(t, wait, "other addresses should not be throttled")
+}
+
+func TestRecordSuccess(t *testing.T) {
+	repo := newMockRepository()
+	service := newService(repo)
+	ctx := fromIP("10.0.0.1")
+
+	for i := 0; i < 3; i++ {
+		_, err := service.Attempt(ctx, "alice")
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, service.RecordSuccess(ctx, "alice"))
+
+	assert.NotContains(t, repo.attempts, "user:alice")
+	assert.Equal(t, 2, repo.attempts["ip:10.0.0.1"].Failures,
+		"a successful login should take back its own attempt but not clear the address")
+}
+
+func TestAttemptRegistration(t *testing.T) {
+	repo := newMockRepository()
+	service := newService(repo)
+
+	wait, err := service.AttemptRegistration(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+	assert.Empty(t, repo.attempts, "sign-ups without a client IP are not counted")
+
+	ctx := fromIP("10.0.0.1")
+	for i := 0; i < 3; i++ {
+		wait, err = service.AttemptRegistration(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+	}
+	assert.NoError(t, service.RecordSuccess(ctx, "alice"))
+
+	wait, err = service.AttemptRegistration(ctx)
+	assert.NoError(t, err)
+	assert.Positive(t, wait, "repeated sign-ups should throttle the address")
+
+	wait, err = service.Attempt(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Zero(t, wait, "sign-ups should not count against logins")
+}
+
+func TestWindow(t *testing.T) {
+	repo := newMockRepository()
+	service := newService(repo)
+
+	repo.attempts["user:alice"] = models.LoginAttempts{
+		Key:           "user:alice",
+		Failures:      5,
+		LastFailureAt: time.Now().UTC().Add(-2 * time.Hour),
+	}
+
+	wait, err := service.Attempt(context.Background(), "alice")
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+	assert.Equal(t, 1, repo.attempts["user:alice"].Failures, "failures outside the window should be forgotten")
+
+	n, err := service.DeleteStale(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	repo.attempts["user:bob"] = models.LoginAttempts{Key: "user:bob", Failures: 1, LastFailureAt: time.Now().UTC().Add(-2 * time.Hour)}
+	n, err = service.DeleteStale(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
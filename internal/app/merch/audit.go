@@ -0,0 +1,10 @@
+package merch
+
+import "github.com/nglmq/avito-shop/internal/app/audit"
+
+// WithAuditor records purchases in the audit log.
+func WithAuditor(a audit.Auditor) Option {
+	return func(s *Service) {
+		s.auditor = a
+	}
+}
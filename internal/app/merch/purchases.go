@@ -0,0 +1,61 @@
+package merch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const (
+	DefaultPurchasesPageSize = 50
+	MaxPurchasesPageSize     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListPurchases returns one page of the user's individual purchases, newest
+// first, optionally limited to one item. Pass the returned NextCursor back to
+// fetch the following page.
+func (s *Service) ListPurchases(
+	ctx context.Context,
+	username, item, cursor string,
+	limit int,
+) (models.PurchasesPage, error) {
+	switch {
+	case limit <= 0:
+		limit = DefaultPurchasesPageSize
+	case limit > MaxPurchasesPageSize:
+		limit = MaxPurchasesPageSize
+	}
+
+	var before int64
+	if cursor != "" {
+		var err error
+		before, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || before <= 0 {
+			return models.PurchasesPage{}, ErrInvalidCursor
+		}
+	}
+
+	purchases, err := s.repo.ListPurchases(ctx, username, item, before, limit+1)
+	if err != nil {
+		s.logger.Error("Error listing purchases",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return models.PurchasesPage{}, err
+	}
+
+	page := models.PurchasesPage{Purchases: purchases}
+	if len(purchases) > limit {
+		page.Purchases = purchases[:limit]
+		page.NextCursor = strconv.FormatInt(page.Purchases[limit-1].ID, 10)
+	}
+	if page.Purchases == nil {
+		page.Purchases = []models.Purchase{}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,99 @@
+package merch_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nglmq/avito-shop/internal/app/merch"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+func TestListPurchases(t *testing.T) {
+	purchases := []models.Purchase{
+		{ID: 9, Item: "cup", Quantity: 2, UnitPrice: 20, TotalPrice: 40},
+		{ID: 5, Item: "pen", Quantity: 1, UnitPrice: 10, TotalPrice: 10},
+		{ID: 2, Item: "cup", Quantity: 1, UnitPrice: 20, TotalPrice: 20},
+	}
+
+	tests := []struct {
+		name       string
+		cursor     string
+		limit      int
+		wantBefore int64
+		wantLimit  int
+		wantIDs    []int64
+		wantCursor string
+		wantErr    error
+	}{
+		{
+			name:       "FirstPage",
+			limit:      2,
+			wantLimit:  3,
+			wantIDs:    []int64{9, 5},
+			wantCursor: "5",
+		},
+		{
+			name:       "LastPage",
+			cursor:     "5",
+			limit:      2,
+			wantBefore: 5,
+			wantLimit:  3,
+			wantIDs:    []int64{2},
+		},
+		{
+			name:      "DefaultLimit",
+			wantLimit: merch.DefaultPurchasesPageSize + 1,
+			wantIDs:   []int64{9, 5, 2},
+		},
+		{
+			name:    "InvalidCursor",
+			cursor:  "abc",
+			wantErr: merch.ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockMerchRepository{
+				ListPurchasesFunc: func(ctx context.Context, username, item string, before int64, limit int) ([]models.Purchase, error) {
+					if before != tt.wantBefore || limit != tt.wantLimit {
+						t.Errorf("expected before %d limit %d, got %d %d", tt.wantBefore, tt.wantLimit, before, limit)
+					}
+					var page []models.Purchase
+					for _, p := range purchases {
+						if (before == 0 || p.ID < before) && len(page) < limit {
+							page = append(page, p)
+						}
+					}
+					return page, nil
+				},
+			}
+
+			service := merch.New(nil, repo)
+			page, err := service.ListPurchases(context.Background(), "user1", "", tt.cursor, tt.limit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			var ids []int64
+			for _, p := range page.Purchases {
+				ids = append(ids, p.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("expected ids %v, got %v", tt.wantIDs, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("expected ids %v, got %v", tt.wantIDs, ids)
+				}
+			}
+			if page.NextCursor != tt.wantCursor {
+				t.Errorf("expected cursor %q, got %q", tt.wantCursor, page.NextCursor)
+			}
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package merch
 
-import "context"
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
 
 type Repository interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	GetBalance(ctx context.Context, username string) (int, error)
 	UpdateBalance(ctx context.Context, receiverUUID string, amount int) error
 	UpdateBalanceDeduct(ctx context.Context, senderUUID string, amount int) error
 	AddPurchase(ctx context.Context, username, itemName string, amount, totalPrice int) error
+	ListPurchases(ctx context.Context, username, item string, before int64, limit int) ([]models.Purchase, error)
 }
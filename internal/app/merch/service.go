@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/app/outbox"
 	"github.com/nglmq/avito-shop/internal/models"
 	"github.com/nglmq/avito-shop/internal/storage"
 	"log/slog"
@@ -16,18 +18,41 @@ var (
 )
 
 type Service struct {
-	logger *slog.Logger
-	repo   Repository
+	logger  *slog.Logger
+	repo    Repository
+	events  outbox.Publisher
+	auditor audit.Auditor
 }
 
-func New(logger *slog.Logger, repo Repository) *Service {
-	return &Service{
+type Option func(*Service)
+
+// WithPublisher notifies buyers when a purchase completes.
+func WithPublisher(p outbox.Publisher) Option {
+	return func(s *Service) {
+		s.events = p
+	}
+}
+
+func New(logger *slog.Logger, repo Repository, opts ...Option) *Service {
+	s := &Service{
 		logger: logger,
 		repo:   repo,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func (s *Service) BuyItem(ctx context.Context, username, itemName string, amount int) error {
+	err := s.buyItem(ctx, username, itemName, amount)
+	audit.Record(ctx, s.auditor, username, models.AuditActionBuy, map[string]any{"item": itemName, "quantity": amount}, err)
+
+	return err
+}
+
+func (s *Service) buyItem(ctx context.Context, username, itemName string, amount int) error {
 	if amount <= 0 {
 		return ErrInvalidAmount
 	}
@@ -39,25 +64,31 @@ func (s *Service) BuyItem(ctx context.Context, username, itemName string, amount
 
 	totalPrice := price * amount
 
-	balance, err := s.repo.GetBalance(ctx, username)
-	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			return err
+	return s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		balance, err := s.repo.GetBalance(ctx, username)
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				return err
+			}
+			return fmt.Errorf("error fetching user balance: %w", err)
 		}
-		return fmt.Errorf("error fetching user balance: %w", err)
-	}
 
-	if balance < totalPrice {
-		return ErrInsufficientBalance
-	}
+		if balance < totalPrice {
+			return ErrInsufficientBalance
+		}
 
-	if err := s.repo.UpdateBalanceDeduct(ctx, username, totalPrice); err != nil {
-		return fmt.Errorf("error deducting balance: %w", err)
-	}
+		if err := s.repo.UpdateBalanceDeduct(ctx, username, totalPrice); err != nil {
+			return fmt.Errorf("error deducting balance: %w", err)
+		}
 
-	if err := s.repo.AddPurchase(ctx, username, itemName, amount, totalPrice); err != nil {
-		return fmt.Errorf("error adding purchase: %w", err)
-	}
+		if err := s.repo.AddPurchase(ctx, username, itemName, amount, totalPrice); err != nil {
+			return fmt.Errorf("error adding purchase: %w", err)
+		}
 
-	return nil
+		return outbox.Notify(ctx, s.events, username, models.EventPurchaseCompleted, models.PurchaseCompletedEvent{
+			Item:       itemName,
+			Quantity:   amount,
+			TotalPrice: totalPrice,
+		})
+	})
 }
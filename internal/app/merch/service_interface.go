@@ -1,7 +1,12 @@
 package merch
 
-import "context"
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
 
 type ServiceInterface interface {
 	BuyItem(ctx context.Context, username, itemName string, amount int) error
+	ListPurchases(ctx context.Context, username, item, cursor string, limit int) (models.PurchasesPage, error)
 }
@@ -1,9 +1,14 @@
 package merch
 
-import "context"
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
 
 type ServiceMock struct {
-	BuyItemFunc func(ctx context.Context, username, item string, quantity int) error
+	BuyItemFunc       func(ctx context.Context, username, item string, quantity int) error
+	ListPurchasesFunc func(ctx context.Context, username, item, cursor string, limit int) (models.PurchasesPage, error)
 }
 
 func (m *ServiceMock) BuyItem(ctx context.Context, username, item string, quantity int) error {
@@ -12,3 +17,10 @@ func (m *ServiceMock) BuyItem(ctx context.Context, username, item string, quanti
 	}
 	return nil
 }
+
+func (m *ServiceMock) ListPurchases(ctx context.Context, username, item, cursor string, limit int) (models.PurchasesPage, error) {
+	if m.ListPurchasesFunc != nil {
+		return m.ListPurchasesFunc(ctx, username, item, cursor, limit)
+	}
+	return models.PurchasesPage{}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/nglmq/avito-shop/internal/app/merch"
+	"github.com/nglmq/avito-shop/internal/models"
 	"github.com/nglmq/avito-shop/internal/storage"
 	"testing"
 )
@@ -13,6 +14,11 @@ type MockMerchRepository struct {
 	UpdateBalanceDeductFunc func(ctx context.Context, username string, amount int) error
 	AddPurchaseFunc         func(ctx context.Context, username, itemName string, amount, totalPrice int) error
 	UpdateBalanceFunc       func(ctx context.Context, receiverUUID string, amount int) error
+	ListPurchasesFunc       func(ctx context.Context, username, item string, before int64, limit int) ([]models.Purchase, error)
+}
+
+func (m *MockMerchRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
 }
 
 func (m *MockMerchRepository) GetBalance(ctx context.Context, username string) (int, error) {
@@ -31,6 +37,10 @@ func (m *MockMerchRepository) UpdateBalance(ctx context.Context, receiverUUID st
 	return m.UpdateBalanceFunc(ctx, receiverUUID, amount)
 }
 
+func (m *MockMerchRepository) ListPurchases(ctx context.Context, username, item string, before int64, limit int) ([]models.Purchase, error) {
+	return m.ListPurchasesFunc(ctx, username, item, before, limit)
+}
+
 func TestBuyItem(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -220,3 +230,42 @@ func TestBuyItem(t *testing.T) {
 		})
 	}
 }
+
+type recordingAuditor struct {
+	actions  []string
+	outcomes []error
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, actor, action string, params any, err error) {
+	a.actions = append(a.actions, actor+":"+action)
+	a.outcomes = append(a.outcomes, err)
+}
+
+func TestBuyItemAudited(t *testing.T) {
+	auditor := &recordingAuditor{}
+	service := merch.New(nil, &MockMerchRepository{
+		GetBalanceFunc: func(ctx context.Context, username string) (int, error) {
+			return 1000, nil
+		},
+		UpdateBalanceDeductFunc: func(ctx context.Context, username string, amount int) error {
+			return nil
+		},
+		AddPurchaseFunc: func(ctx context.Context, username, itemName string, amount, totalPrice int) error {
+			return nil
+		},
+	}, merch.WithAuditor(auditor))
+
+	if err := service.BuyItem(context.Background(), "user1", "socks", 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := service.BuyItem(context.Background(), "user1", "unknown", 1); !errors.Is(err, merch.ErrItemNotFound) {
+		t.Fatalf("expected error %v, got %v", merch.ErrItemNotFound, err)
+	}
+
+	if len(auditor.actions) != 2 || auditor.actions[0] != "user1:"+models.AuditActionBuy {
+		t.Fatalf("unexpected audit entries %v", auditor.actions)
+	}
+	if auditor.outcomes[0] != nil || !errors.Is(auditor.outcomes[1], merch.ErrItemNotFound) {
+		t.Fatalf("unexpected audit outcomes %v", auditor.outcomes)
+	}
+}
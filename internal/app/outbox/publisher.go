@@ -0,0 +1,20 @@
+package outbox
+
+import "context"
+
+// Publisher records notifications, normally Service, which stores them in the
+// database transaction carried by ctx.
+type Publisher interface {
+	Publish(ctx context.Context, username, eventType string, data any) error
+}
+
+// Notify records an event with p as part of the current transaction, so that
+// it is published if and only if the change commits. It does nothing if p is
+// nil.
+func Notify(ctx context.Context, p Publisher, username, eventType string, data any) error {
+	if p == nil {
+		return nil
+	}
+
+	return p.Publish(ctx, username, eventType, data)
+}
@@ -0,0 +1,17 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type Repository interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	InsertOutboxEvent(ctx context.Context, eventType, username string, payload []byte, now time.Time) (int64, error)
+	ListUndeliveredOutboxEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error)
+	MarkOutboxEventDelivered(ctx context.Context, id int64, now time.Time) error
+	RecordOutboxFailure(ctx context.Context, id int64, lastError string) error
+	ParkOutboxEvent(ctx context.Context, id int64, lastError string, now time.Time) error
+}
@@ -0,0 +1,187 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/utils/txhook"
+)
+
+const (
+	DefaultBatchSize   = 100
+	DefaultMaxAttempts = 10
+)
+
+// Sink receives relayed events. Deliver runs inside the relay's database
+// transaction, so a sink that writes to the database commits together with
+// the event being marked delivered. Events are relayed in ID order, but IDs
+// are assigned on insert rather than on commit, so an event may arrive after
+// one with a higher ID.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, ev models.OutboxEvent) error
+}
+
+// Service implements the transactional outbox. Services publish events into
+// the outbox table within the transaction that changes balances, and the
+// relay hands them to every sink in order, marking them delivered. Delivery
+// is at least once: an event is retried until all sinks accept it, so sinks
+// may see it more than once. An event still rejected after maxAttempts is
+// parked with its last error and no longer holds up the events behind it.
+type Service struct {
+	logger      *slog.Logger
+	repo        Repository
+	sinks       []Sink
+	batchSize   int
+	maxAttempts int
+	wake        chan struct{}
+}
+
+type Option func(*Service)
+
+// WithSink adds a destination for relayed events.
+func WithSink(sink Sink) Option {
+	return func(s *Service) {
+		s.sinks = append(s.sinks, sink)
+	}
+}
+
+// WithMaxAttempts sets how many times the relay tries to deliver an event
+// before parking it.
+func WithMaxAttempts(n int) Option {
+	return func(s *Service) {
+		if n > 0 {
+			s.maxAttempts = n
+		}
+	}
+}
+
+func New(logger *slog.Logger, repo Repository, opts ...Option) *Service {
+	s := &Service{
+		logger:      logger,
+		repo:        repo,
+		batchSize:   DefaultBatchSize,
+		maxAttempts: DefaultMaxAttempts,
+		wake:        make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Publish records the event in the transaction carried by ctx and wakes the
+// relay once it commits.
+func (s *Service) Publish(ctx context.Context, username, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error encoding %s event: %w", eventType, err)
+	}
+
+	if _, err := s.repo.InsertOutboxEvent(ctx, eventType, username, payload, time.Now().UTC()); err != nil {
+		return err
+	}
+	txhook.AfterCommit(ctx, s.Wake)
+
+	return nil
+}
+
+// Wake asks the relay to run now rather than at its next tick.
+func (s *Service) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run relays events every interval and whenever it is woken, until ctx is
+// cancelled.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.logger.Info("Starting outbox relay", slog.Duration("interval", interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping outbox relay")
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+
+		for {
+			relayed, err := s.Relay(ctx)
+			if err != nil {
+				s.logger.Error("Outbox relay failed", slog.String("error", err.Error()))
+			}
+			if err != nil || relayed < s.batchSize {
+				break
+			}
+		}
+	}
+}
+
+// Relay delivers a batch of pending events in order and returns how many
+// were delivered. It stops at the first event a sink rejects so that later
+// events are not delivered ahead of it; that event is retried next run. Once
+// an event has used up its attempts it is parked instead, and the relay
+// carries on with the next one.
+func (s *Service) Relay(ctx context.Context) (int, error) {
+	relayed := 0
+
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		events, err := s.repo.ListUndeliveredOutboxEvents(ctx, s.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, ev := range events {
+			err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+				for _, sink := range s.sinks {
+					if err := sink.Deliver(ctx, ev); err != nil {
+						return fmt.Errorf("%s sink: %w", sink.Name(), err)
+					}
+				}
+
+				return s.repo.MarkOutboxEventDelivered(ctx, ev.ID, time.Now().UTC())
+			})
+			if err == nil {
+				relayed++
+				continue
+			}
+
+			attempts := ev.Attempts + 1
+			if attempts < s.maxAttempts {
+				s.logger.Error("Error relaying outbox event",
+					slog.Int64("id", ev.ID),
+					slog.String("type", ev.Type),
+					slog.Int("attempts", attempts),
+					slog.String("error", err.Error()))
+				return s.repo.RecordOutboxFailure(ctx, ev.ID, err.Error())
+			}
+
+			s.logger.Error("Parking outbox event after too many failed attempts",
+				slog.Int64("id", ev.ID),
+				slog.String("type", ev.Type),
+				slog.Int("attempts", attempts),
+				slog.String("error", err.Error()))
+			if err := s.repo.ParkOutboxEvent(ctx, ev.ID, err.Error(), time.Now().UTC()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return relayed, nil
+}
@@ -0,0 +1,198 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/outbox"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/utils/txhook"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockOutboxRepository struct {
+	events    []models.OutboxEvent
+	delivered []int64
+	failed    map[int64]string
+	parked    []int64
+}
+
+func (m *MockOutboxRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockOutboxRepository) InsertOutboxEvent(ctx context.Context, eventType, username string, payload []byte, now time.Time) (int64, error) {
+	id := int64(len(m.events) + 1)
+	m.events = append(m.events, models.OutboxEvent{ID: id, Type: eventType, Username: username, Payload: payload, CreatedAt: now})
+	return id, nil
+}
+
+func (m *MockOutboxRepository) ListUndeliveredOutboxEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var pending []models.OutboxEvent
+	for _, ev := range m.events {
+		delivered := false
+		for _, id := range append(m.delivered, m.parked...) {
+			delivered = delivered || id == ev.ID
+		}
+		if !delivered && len(pending) < limit {
+			pending = append(pending, ev)
+		}
+	}
+	return pending, nil
+}
+
+func (m *MockOutboxRepository) MarkOutboxEventDelivered(ctx context.Context, id int64, now time.Time) error {
+	m.delivered = append(m.delivered, id)
+	return nil
+}
+
+func (m *MockOutboxRepository) RecordOutboxFailure(ctx context.Context, id int64, lastError string) error {
+	if m.failed == nil {
+		m.failed = make(map[int64]string)
+	}
+	m.failed[id] = lastError
+	m.events[id-1].Attempts++
+	return nil
+}
+
+func (m *MockOutboxRepository) ParkOutboxEvent(ctx context.Context, id int64, lastError string, now time.Time) error {
+	m.parked = append(m.parked, id)
+	return m.RecordOutboxFailure(ctx, id, lastError)
+}
+
+type recordingSink struct {
+	failOn int64
+	got    []int64
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Deliver(ctx context.Context, ev models.OutboxEvent) error {
+	if ev.ID == s.failOn {
+		return errors.New("sink unavailable")
+	}
+	s.got = append(s.got, ev.ID)
+	return nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestPublishWakesRelayAfterCommit(t *testing.T) {
+	repo := &MockOutboxRepository{}
+	service := outbox.New(discardLogger(), repo)
+
+	txCtx, hooks := txhook.Begin(context.Background())
+	err := service.Publish(txCtx, "bob", models.EventCoinsReceived, models.CoinsReceivedEvent{FromUser: "alice", Amount: 5})
+	assert.NoError(t, err)
+	if assert.Len(t, repo.events, 1) {
+		assert.JSONEq(t, `{"transactionId":0,"fromUser":"alice","amount":5}`, string(repo.events[0].Payload))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, repo.delivered, "nothing is relayed before the commit")
+
+	hooks.Commit()
+	assert.Eventually(t, func() bool {
+		cancel()
+		<-done
+		return len(repo.delivered) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRelayStopsAtFailingEvent(t *testing.T) {
+	repo := &MockOutboxRepository{}
+	for i := 0; i < 3; i++ {
+		_, _ = repo.InsertOutboxEvent(context.Background(), models.EventPurchaseCompleted, "alice", []byte(`{}`), time.Now())
+	}
+
+	sink := &recordingSink{failOn: 2}
+	service := outbox.New(discardLogger(), repo, outbox.WithSink(sink))
+
+	relayed, err := service.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.Equal(t, []int64{1}, sink.got)
+	assert.Equal(t, []int64{1}, repo.delivered)
+	assert.Contains(t, repo.failed[2], "recording sink: sink unavailable")
+
+	sink.failOn = 0
+	relayed, err = service.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, relayed)
+	assert.Equal(t, []int64{1, 2, 3}, sink.got, "events are relayed in order")
+}
+
+func TestRelayParksPoisonEvent(t *testing.T) {
+	repo := &MockOutboxRepository{}
+	for i := 0; i < 3; i++ {
+		_, _ = repo.InsertOutboxEvent(context.Background(), models.EventPurchaseCompleted, "alice", []byte(`{}`), time.Now())
+	}
+
+	sink := &recordingSink{failOn: 2}
+	service := outbox.New(discardLogger(), repo, outbox.WithSink(sink), outbox.WithMaxAttempts(3))
+
+	for run := 1; run < 3; run++ {
+		_, err := service.Relay(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1}, sink.got, "run %d: later events wait while the failing one has attempts left", run)
+	}
+
+	relayed, err := service.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.Equal(t, []int64{2}, repo.parked)
+	assert.Equal(t, []int64{1, 3}, sink.got, "the events behind a parked one are delivered")
+
+	relayed, err = service.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, relayed, "parked events are not retried")
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sink, err := outbox.NewFileSink(path)
+	assert.NoError(t, err)
+
+	for id := int64(1); id <= 2; id++ {
+		err := sink.Deliver(context.Background(), models.OutboxEvent{
+			ID:       id,
+			Type:     models.EventCoinsReceived,
+			Username: "bob",
+			Payload:  json.RawMessage(`{"amount":1}`),
+		})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var ids []int64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var ev models.OutboxEvent
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+		ids = append(ids, ev.ID)
+	}
+	assert.Equal(t, []int64{1, 2}, ids)
+}
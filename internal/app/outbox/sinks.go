@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// LogSink writes every event to the application log.
+type LogSink struct {
+	logger *slog.Logger
+}
+
+func NewLogSink(logger *slog.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (l *LogSink) Name() string {
+	return "log"
+}
+
+func (l *LogSink) Deliver(ctx context.Context, ev models.OutboxEvent) error {
+	l.logger.Info("Domain event",
+		slog.Int64("id", ev.ID),
+		slog.String("type", ev.Type),
+		slog.String("username", ev.Username),
+		slog.String("payload", string(ev.Payload)))
+
+	return nil
+}
+
+// FileSink appends every event to a file as a line of JSON.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening outbox file: %w", err)
+	}
+
+	return &FileSink{file: file}, nil
+}
+
+func (f *FileSink) Name() string {
+	return "file"
+}
+
+// Deliver writes the event and syncs the file, so that an event marked
+// delivered is on disk.
+func (f *FileSink) Deliver(ctx context.Context, ev models.OutboxEvent) error {
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return f.file.Sync()
+}
+
+func (f *FileSink) Close() error {
+	return f.file.Close()
+}
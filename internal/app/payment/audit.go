@@ -0,0 +1,11 @@
+package payment
+
+import "github.com/nglmq/avito-shop/internal/app/audit"
+
+// WithAuditor records payment requests and their payment, decline or
+// cancellation in the audit log.
+func WithAuditor(a audit.Auditor) Option {
+	return func(s *Service) {
+		s.auditor = a
+	}
+}
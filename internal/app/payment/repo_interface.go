@@ -0,0 +1,23 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type Repository interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	GetUserByUsername(ctx context.Context, username string) (bool, error)
+	CreatePaymentRequest(ctx context.Context, req models.PaymentRequest) (int64, error)
+	GetPaymentRequest(ctx context.Context, id int64) (models.PaymentRequest, error)
+	ListPaymentRequests(ctx context.Context, username, status string) ([]models.PaymentRequest, error)
+	ResolvePaymentRequest(ctx context.Context, id int64, status string, transactionID *int64) error
+	ExpirePaymentRequests(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Transferer moves coins between users, normally transaction.Service.
+type Transferer interface {
+	Transfer(ctx context.Context, from, to string, amount int) (int64, error)
+}
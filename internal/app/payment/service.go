@@ -0,0 +1,259 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/app/outbox"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+const DefaultRequestTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvalidAmount     = errors.New("invalid amount")
+	ErrInvalidPayer      = errors.New("invalid payer")
+	ErrRequestNotFound   = errors.New("payment request not found")
+	ErrRequestNotPending = errors.New("payment request is not pending")
+	ErrRequestExpired    = errors.New("payment request has expired")
+)
+
+type Service struct {
+	logger     *slog.Logger
+	repo       Repository
+	transferer Transferer
+	ttl        time.Duration
+	events     outbox.Publisher
+	auditor    audit.Auditor
+}
+
+type Option func(*Service)
+
+// WithRequestTTL sets how long a payment request stays payable.
+func WithRequestTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.ttl = ttl
+	}
+}
+
+// WithPublisher notifies payers of new payment requests.
+func WithPublisher(p outbox.Publisher) Option {
+	return func(s *Service) {
+		s.events = p
+	}
+}
+
+func New(logger *slog.Logger, repo Repository, transferer Transferer, opts ...Option) *Service {
+	s := &Service{
+		logger:     logger,
+		repo:       repo,
+		transferer: transferer,
+		ttl:        DefaultRequestTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Service) CreateRequest(
+	ctx context.Context,
+	requester string,
+	req models.CreatePaymentRequest,
+) (models.PaymentRequest, error) {
+	pr, err := s.createRequest(ctx, requester, req)
+	audit.Record(ctx, s.auditor, requester, models.AuditActionPaymentRequestCreate,
+		map[string]any{"payer": req.Payer, "amount": req.Amount, "id": pr.ID}, err)
+
+	return pr, err
+}
+
+func (s *Service) createRequest(
+	ctx context.Context,
+	requester string,
+	req models.CreatePaymentRequest,
+) (models.PaymentRequest, error) {
+	if requester == req.Payer {
+		return models.PaymentRequest{}, ErrInvalidPayer
+	}
+	if req.Amount <= 0 {
+		return models.PaymentRequest{}, ErrInvalidAmount
+	}
+
+	exists, err := s.repo.GetUserByUsername(ctx, req.Payer)
+	if err != nil || !exists {
+		return models.PaymentRequest{}, storage.ErrUserNotFound
+	}
+
+	now := time.Now().UTC()
+	pr := models.PaymentRequest{
+		Requester: requester,
+		Payer:     req.Payer,
+		Amount:    req.Amount,
+		Note:      req.Note,
+		Status:    models.PaymentRequestStatusPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	err = s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		pr.ID, err = s.repo.CreatePaymentRequest(ctx, pr)
+		if err != nil {
+			return err
+		}
+
+		return outbox.Notify(ctx, s.events, pr.Payer, models.EventPaymentRequestCreated, pr)
+	})
+	if err != nil {
+		s.logger.Error("Error creating payment request",
+			slog.String("requester", requester),
+			slog.String("payer", req.Payer),
+			slog.String("error", err.Error()))
+		return models.PaymentRequest{}, err
+	}
+
+	return pr, nil
+}
+
+// ListRequests returns the user's inbox (requests they are asked to pay) and
+// the requests they have sent, optionally filtered by status.
+func (s *Service) ListRequests(ctx context.Context, username, status string) (models.PaymentRequestsResponse, error) {
+	requests, err := s.repo.ListPaymentRequests(ctx, username, status)
+	if err != nil {
+		s.logger.Error("Error listing payment requests",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return models.PaymentRequestsResponse{}, err
+	}
+
+	resp := models.PaymentRequestsResponse{
+		Incoming: []models.PaymentRequest{},
+		Outgoing: []models.PaymentRequest{},
+	}
+	for _, pr := range requests {
+		if pr.Payer == username {
+			resp.Incoming = append(resp.Incoming, pr)
+		} else {
+			resp.Outgoing = append(resp.Outgoing, pr)
+		}
+	}
+
+	return resp, nil
+}
+
+// PayRequest transfers the requested amount to the requester and links the
+// resulting transaction to the request, all in one database transaction.
+// When the transfer is held for fraud review the request is still marked
+// paid; it is reopened if the review rejects the transfer.
+func (s *Service) PayRequest(ctx context.Context, payer string, id int64) (models.PaymentRequest, error) {
+	paid, err := s.payRequest(ctx, payer, id)
+	params := map[string]any{"id": id}
+	if err == nil {
+		params["toUser"] = paid.Requester
+		params["amount"] = paid.Amount
+		params["transactionId"] = *paid.TransactionID
+	}
+	audit.Record(ctx, s.auditor, payer, models.AuditActionPaymentRequestPay, params, err)
+
+	return paid, err
+}
+
+func (s *Service) payRequest(ctx context.Context, payer string, id int64) (models.PaymentRequest, error) {
+	var paid models.PaymentRequest
+
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		pr, err := s.pendingRequest(ctx, id, func(pr models.PaymentRequest) bool { return pr.Payer == payer })
+		if err != nil {
+			return err
+		}
+
+		txID, err := s.transferer.Transfer(ctx, pr.Payer, pr.Requester, pr.Amount)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.ResolvePaymentRequest(ctx, id, models.PaymentRequestStatusPaid, &txID); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		pr.Status = models.PaymentRequestStatusPaid
+		pr.TransactionID = &txID
+		pr.ResolvedAt = &now
+		paid = pr
+
+		return nil
+	})
+	if err != nil {
+		return models.PaymentRequest{}, err
+	}
+
+	return paid, nil
+}
+
+func (s *Service) DeclineRequest(ctx context.Context, payer string, id int64) error {
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := s.pendingRequest(ctx, id, func(pr models.PaymentRequest) bool { return pr.Payer == payer })
+		if err != nil {
+			return err
+		}
+
+		return s.repo.ResolvePaymentRequest(ctx, id, models.PaymentRequestStatusDeclined, nil)
+	})
+	audit.Record(ctx, s.auditor, payer, models.AuditActionPaymentRequestDecline, map[string]any{"id": id}, err)
+
+	return err
+}
+
+func (s *Service) CancelRequest(ctx context.Context, requester string, id int64) error {
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := s.pendingRequest(ctx, id, func(pr models.PaymentRequest) bool { return pr.Requester == requester })
+		if err != nil {
+			return err
+		}
+
+		return s.repo.ResolvePaymentRequest(ctx, id, models.PaymentRequestStatusCancelled, nil)
+	})
+	audit.Record(ctx, s.auditor, requester, models.AuditActionPaymentRequestCancel, map[string]any{"id": id}, err)
+
+	return err
+}
+
+// ExpireRequests marks every pending request past its deadline as expired.
+func (s *Service) ExpireRequests(ctx context.Context) (int64, error) {
+	return s.repo.ExpirePaymentRequests(ctx, time.Now().UTC())
+}
+
+// pendingRequest loads and locks a request that is still payable and that
+// the caller is allowed to act on; requests of other users are reported as
+// not found.
+func (s *Service) pendingRequest(
+	ctx context.Context,
+	id int64,
+	allowed func(pr models.PaymentRequest) bool,
+) (models.PaymentRequest, error) {
+	pr, err := s.repo.GetPaymentRequest(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrPaymentRequestNotFound) {
+			return models.PaymentRequest{}, ErrRequestNotFound
+		}
+		return models.PaymentRequest{}, err
+	}
+
+	if !allowed(pr) {
+		return models.PaymentRequest{}, ErrRequestNotFound
+	}
+	if pr.Status != models.PaymentRequestStatusPending {
+		return models.PaymentRequest{}, ErrRequestNotPending
+	}
+	if !time.Now().Before(pr.ExpiresAt) {
+		return models.PaymentRequest{}, ErrRequestExpired
+	}
+
+	return pr, nil
+}
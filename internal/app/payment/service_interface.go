@@ -0,0 +1,15 @@
+package payment
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceInterface interface {
+	CreateRequest(ctx context.Context, requester string, req models.CreatePaymentRequest) (models.PaymentRequest, error)
+	ListRequests(ctx context.Context, username, status string) (models.PaymentRequestsResponse, error)
+	PayRequest(ctx context.Context, payer string, id int64) (models.PaymentRequest, error)
+	DeclineRequest(ctx context.Context, payer string, id int64) error
+	CancelRequest(ctx context.Context, requester string, id int64) error
+}
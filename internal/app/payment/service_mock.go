@@ -0,0 +1,50 @@
+package payment
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceMock struct {
+	CreateRequestFunc  func(ctx context.Context, requester string, req models.CreatePaymentRequest) (models.PaymentRequest, error)
+	ListRequestsFunc   func(ctx context.Context, username, status string) (models.PaymentRequestsResponse, error)
+	PayRequestFunc     func(ctx context.Context, payer string, id int64) (models.PaymentRequest, error)
+	DeclineRequestFunc func(ctx context.Context, payer string, id int64) error
+	CancelRequestFunc  func(ctx context.Context, requester string, id int64) error
+}
+
+func (m *ServiceMock) CreateRequest(ctx context.Context, requester string, req models.CreatePaymentRequest) (models.PaymentRequest, error) {
+	if m.CreateRequestFunc != nil {
+		return m.CreateRequestFunc(ctx, requester, req)
+	}
+	return models.PaymentRequest{}, nil
+}
+
+func (m *ServiceMock) ListRequests(ctx context.Context, username, status string) (models.PaymentRequestsResponse, error) {
+	if m.ListRequestsFunc != nil {
+		return m.ListRequestsFunc(ctx, username, status)
+	}
+	return models.PaymentRequestsResponse{}, nil
+}
+
+func (m *ServiceMock) PayRequest(ctx context.Context, payer string, id int64) (models.PaymentRequest, error) {
+	if m.PayRequestFunc != nil {
+		return m.PayRequestFunc(ctx, payer, id)
+	}
+	return models.PaymentRequest{}, nil
+}
+
+func (m *ServiceMock) DeclineRequest(ctx context.Context, payer string, id int64) error {
+	if m.DeclineRequestFunc != nil {
+		return m.DeclineRequestFunc(ctx, payer, id)
+	}
+	return nil
+}
+
+func (m *ServiceMock) CancelRequest(ctx context.Context, requester string, id int64) error {
+	if m.CancelRequestFunc != nil {
+		return m.CancelRequestFunc(ctx, requester, id)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package payment_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nglmq/avito-shop/internal/app/payment"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type MockPaymentRepository struct {
+	request        models.PaymentRequest
+	resolvedStatus string
+	resolvedTxID   *int64
+}
+
+func (m *MockPaymentRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockPaymentRepository) GetUserByUsername(ctx context.Context, username string) (bool, error) {
+	return username != "unknown", nil
+}
+
+func (m *MockPaymentRepository) CreatePaymentRequest(ctx context.Context, req models.PaymentRequest) (int64, error) {
+	return 1, nil
+}
+
+func (m *MockPaymentRepository) GetPaymentRequest(ctx context.Context, id int64) (models.PaymentRequest, error) {
+	if id != m.request.ID {
+		return models.PaymentRequest{}, storage.ErrPaymentRequestNotFound
+	}
+	return m.request, nil
+}
+
+func (m *MockPaymentRepository) ListPaymentRequests(ctx context.Context, username, status string) ([]models.PaymentRequest, error) {
+	return []models.PaymentRequest{m.request}, nil
+}
+
+func (m *MockPaymentRepository) ResolvePaymentRequest(ctx context.Context, id int64, status string, transactionID *int64) error {
+	m.resolvedStatus = status
+	m.resolvedTxID = transactionID
+	return nil
+}
+
+func (m *MockPaymentRepository) ExpirePaymentRequests(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func lunchRequest(status string, expiresIn time.Duration) models.PaymentRequest {
+	return models.PaymentRequest{
+		ID:        1,
+		Requester: "alice",
+		Payer:     "bob",
+		Amount:    50,
+		Note:      "lunch",
+		Status:    status,
+		ExpiresAt: time.Now().Add(expiresIn),
+	}
+}
+
+func TestCreateRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           models.CreatePaymentRequest
+		expectedError error
+	}{
+		{
+			name: "Success",
+			req:  models.CreatePaymentRequest{Payer: "bob", Amount: 50, Note: "lunch"},
+		},
+		{
+			name:          "SelfRequest",
+			req:           models.CreatePaymentRequest{Payer: "alice", Amount: 50},
+			expectedError: payment.ErrInvalidPayer,
+		},
+		{
+			name:          "InvalidAmount",
+			req:           models.CreatePaymentRequest{Payer: "bob", Amount: 0},
+			expectedError: payment.ErrInvalidAmount,
+		},
+		{
+			name:          "PayerNotFound",
+			req:           models.CreatePaymentRequest{Payer: "unknown", Amount: 50},
+			expectedError: storage.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := payment.New(nil, &MockPaymentRepository{}, &transaction.ServiceMock{})
+			pr, err := service.CreateRequest(context.Background(), "alice", tt.req)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if err == nil && (pr.Status != models.PaymentRequestStatusPending || pr.Requester != "alice") {
+				t.Fatalf("unexpected payment request %+v", pr)
+			}
+		})
+	}
+}
+
+func TestPayRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		payer          string
+		request        models.PaymentRequest
+		transferErr    error
+		expectedError  error
+		expectedStatus string
+	}{
+		{
+			name:           "Success",
+			payer:          "bob",
+			request:        lunchRequest(models.PaymentRequestStatusPending, time.Hour),
+			expectedStatus: models.PaymentRequestStatusPaid,
+		},
+		{
+			name:          "NotPayer",
+			payer:         "alice",
+			request:       lunchRequest(models.PaymentRequestStatusPending, time.Hour),
+			expectedError: payment.ErrRequestNotFound,
+		},
+		{
+			name:          "AlreadyPaid",
+			payer:         "bob",
+			request:       lunchRequest(models.PaymentRequestStatusPaid, time.Hour),
+			expectedError: payment.ErrRequestNotPending,
+		},
+		{
+			name:          "Expired",
+			payer:         "bob",
+			request:       lunchRequest(models.PaymentRequestStatusPending, -time.Hour),
+			expectedError: payment.ErrRequestExpired,
+		},
+		{
+			name:          "InsufficientBalance",
+			payer:         "bob",
+			request:       lunchRequest(models.PaymentRequestStatusPending, time.Hour),
+			transferErr:   transaction.ErrInsufficientBalance,
+			expectedError: transaction.ErrInsufficientBalance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockPaymentRepository{request: tt.request}
+			var transferred int
+			transferer := &transaction.ServiceMock{
+				TransferFunc: func(ctx context.Context, fromUser, toUser string, amount int) (int64, error) {
+					if fromUser != "bob" || toUser != "alice" {
+						t.Fatalf("unexpected transfer %s -> %s", fromUser, toUser)
+					}
+					transferred = amount
+					return 7, tt.transferErr
+				},
+			}
+
+			service := payment.New(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, transferer)
+			pr, err := service.PayRequest(context.Background(), tt.payer, 1)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if repo.resolvedStatus != tt.expectedStatus {
+				t.Fatalf("expected status %q, got %q", tt.expectedStatus, repo.resolvedStatus)
+			}
+			if err != nil {
+				return
+			}
+			if transferred != 50 {
+				t.Fatalf("expected 50 coins transferred, got %d", transferred)
+			}
+			if pr.TransactionID == nil || *pr.TransactionID != 7 || *repo.resolvedTxID != 7 {
+				t.Fatalf("expected request to be linked to transaction 7")
+			}
+		})
+	}
+}
+
+func TestDeclineAndCancelRequest(t *testing.T) {
+	repo := &MockPaymentRepository{request: lunchRequest(models.PaymentRequestStatusPending, time.Hour)}
+	service := payment.New(nil, repo, &transaction.ServiceMock{})
+
+	if err := service.DeclineRequest(context.Background(), "alice", 1); !errors.Is(err, payment.ErrRequestNotFound) {
+		t.Fatalf("requester must not decline own request, got %v", err)
+	}
+	if err := service.CancelRequest(context.Background(), "bob", 1); !errors.Is(err, payment.ErrRequestNotFound) {
+		t.Fatalf("payer must not cancel the request, got %v", err)
+	}
+
+	if err := service.DeclineRequest(context.Background(), "bob", 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if repo.resolvedStatus != models.PaymentRequestStatusDeclined {
+		t.Fatalf("expected declined, got %q", repo.resolvedStatus)
+	}
+
+	if err := service.CancelRequest(context.Background(), "alice", 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if repo.resolvedStatus != models.PaymentRequestStatusCancelled {
+		t.Fatalf("expected cancelled, got %q", repo.resolvedStatus)
+	}
+}
+
+type recordingAuditor struct {
+	actions  []string
+	outcomes []error
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, actor, action string, params any, err error) {
+	a.actions = append(a.actions, actor+":"+action)
+	a.outcomes = append(a.outcomes, err)
+}
+
+func TestPaymentRequestsAudited(t *testing.T) {
+	auditor := &recordingAuditor{}
+	repo := &MockPaymentRepository{request: lunchRequest(models.PaymentRequestStatusPending, time.Hour)}
+	service := payment.New(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, &transaction.ServiceMock{
+		TransferFunc: func(ctx context.Context, fromUser, toUser string, amount int) (int64, error) {
+			return 7, nil
+		},
+	}, payment.WithAuditor(auditor))
+
+	if _, err := service.CreateRequest(context.Background(), "alice", models.CreatePaymentRequest{Payer: "bob", Amount: 50}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.PayRequest(context.Background(), "bob", 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := service.CancelRequest(context.Background(), "bob", 1); !errors.Is(err, payment.ErrRequestNotFound) {
+		t.Fatalf("expected error %v, got %v", payment.ErrRequestNotFound, err)
+	}
+	if err := service.DeclineRequest(context.Background(), "bob", 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []string{
+		"alice:" + models.AuditActionPaymentRequestCreate,
+		"bob:" + models.AuditActionPaymentRequestPay,
+		"bob:" + models.AuditActionPaymentRequestCancel,
+		"bob:" + models.AuditActionPaymentRequestDecline,
+	}
+	if len(auditor.actions) != len(expected) {
+		t.Fatalf("unexpected audit entries %v", auditor.actions)
+	}
+	for i := range expected {
+		if auditor.actions[i] != expected[i] {
+			t.Fatalf("unexpected audit entries %v", auditor.actions)
+		}
+	}
+	if !errors.Is(auditor.outcomes[2], payment.ErrRequestNotFound) {
+		t.Fatalf("expected failed cancellation to be recorded, got %v", auditor.outcomes[2])
+	}
+}
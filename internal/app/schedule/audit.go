@@ -0,0 +1,11 @@
+package schedule
+
+import "github.com/nglmq/avito-shop/internal/app/audit"
+
+// WithAuditor records the creation, cancellation and runs of scheduled
+// transfers in the audit log.
+func WithAuditor(a audit.Auditor) Option {
+	return func(s *Service) {
+		s.auditor = a
+	}
+}
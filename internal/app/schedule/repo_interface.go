@@ -0,0 +1,24 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type Repository interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	GetUserByUsername(ctx context.Context, username string) (bool, error)
+	CreateScheduledTransfer(ctx context.Context, t models.ScheduledTransfer) (int64, error)
+	ListScheduledTransfers(ctx context.Context, username string) ([]models.ScheduledTransfer, error)
+	CancelScheduledTransfer(ctx context.Context, username string, id int64) (bool, error)
+	ClaimDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]models.ScheduledTransfer, error)
+	UpdateScheduledTransferRun(ctx context.Context, t models.ScheduledTransfer) error
+}
+
+// Sender executes a single coin transfer, normally transaction.Service. It
+// joins the caller's database transaction and leaves auditing to the caller.
+type Sender interface {
+	Transfer(ctx context.Context, from, to string, amount int) (int64, error)
+}
@@ -0,0 +1,277 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"github.com/nglmq/avito-shop/internal/utils/cron"
+)
+
+const (
+	DefaultMaxFailures = 3
+	DefaultRetryDelay  = time.Hour
+
+	runBatchSize = 50
+)
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrInvalidRecipient = errors.New("invalid recipient")
+	ErrInvalidSchedule  = errors.New("invalid schedule")
+	ErrRunAtInPast      = errors.New("run time must be in the future")
+	ErrScheduleNotFound = errors.New("scheduled transfer not found")
+)
+
+type Service struct {
+	logger      *slog.Logger
+	repo        Repository
+	sender      Sender
+	maxFailures int
+	retryDelay  time.Duration
+	auditor     audit.Auditor
+}
+
+type Option func(*Service)
+
+func New(logger *slog.Logger, repo Repository, sender Sender, opts ...Option) *Service {
+	s := &Service{
+		logger:      logger,
+		repo:        repo,
+		sender:      sender,
+		maxFailures: DefaultMaxFailures,
+		retryDelay:  DefaultRetryDelay,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Service) CreateScheduledTransfer(
+	ctx context.Context,
+	from string,
+	req models.ScheduledTransferRequest,
+) (models.ScheduledTransfer, error) {
+	t, err := s.createScheduledTransfer(ctx, from, req)
+	audit.Record(ctx, s.auditor, from, models.AuditActionScheduleCreate, map[string]any{"request": req, "id": t.ID}, err)
+
+	return t, err
+}
+
+func (s *Service) createScheduledTransfer(
+	ctx context.Context,
+	from string,
+	req models.ScheduledTransferRequest,
+) (models.ScheduledTransfer, error) {
+	if from == req.ToUser {
+		return models.ScheduledTransfer{}, ErrInvalidRecipient
+	}
+	if req.Amount <= 0 {
+		return models.ScheduledTransfer{}, ErrInvalidAmount
+	}
+
+	now := time.Now().UTC()
+	t := models.ScheduledTransfer{
+		FromUser: from,
+		ToUser:   req.ToUser,
+		Amount:   req.Amount,
+		Schedule: req.Schedule,
+		Status:   models.ScheduleStatusActive,
+	}
+
+	switch {
+	case req.Schedule != "":
+		sched, err := cron.Parse(req.Schedule)
+		if err != nil {
+			return models.ScheduledTransfer{}, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+		}
+		t.NextRunAt = sched.Next(now)
+		if t.NextRunAt.IsZero() {
+			return models.ScheduledTransfer{}, ErrInvalidSchedule
+		}
+	case req.RunAt != nil:
+		if !req.RunAt.After(now) {
+			return models.ScheduledTransfer{}, ErrRunAtInPast
+		}
+		t.NextRunAt = req.RunAt.UTC()
+	default:
+		return models.ScheduledTransfer{}, ErrInvalidSchedule
+	}
+
+	exists, err := s.repo.GetUserByUsername(ctx, req.ToUser)
+	if err != nil || !exists {
+		return models.ScheduledTransfer{}, storage.ErrUserNotFound
+	}
+
+	t.ID, err = s.repo.CreateScheduledTransfer(ctx, t)
+	if err != nil {
+		s.logger.Error("Error creating scheduled transfer",
+			slog.String("from", from),
+			slog.String("to", req.ToUser),
+			slog.String("error", err.Error()))
+		return models.ScheduledTransfer{}, err
+	}
+	t.CreatedAt = now
+
+	return t, nil
+}
+
+func (s *Service) ListScheduledTransfers(ctx context.Context, username string) ([]models.ScheduledTransfer, error) {
+	transfers, err := s.repo.ListScheduledTransfers(ctx, username)
+	if err != nil {
+		s.logger.Error("Error listing scheduled transfers",
+			slog.String("username", username),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	if transfers == nil {
+		transfers = []models.ScheduledTransfer{}
+	}
+
+	return transfers, nil
+}
+
+func (s *Service) CancelScheduledTransfer(ctx context.Context, username string, id int64) error {
+	err := s.cancelScheduledTransfer(ctx, username, id)
+	audit.Record(ctx, s.auditor, username, models.AuditActionScheduleCancel, map[string]any{"id": id}, err)
+
+	return err
+}
+
+func (s *Service) cancelScheduledTransfer(ctx context.Context, username string, id int64) error {
+	cancelled, err := s.repo.CancelScheduledTransfer(ctx, username, id)
+	if err != nil {
+		s.logger.Error("Error cancelling scheduled transfer",
+			slog.String("username", username),
+			slog.Int64("id", id),
+			slog.String("error", err.Error()))
+		return err
+	}
+	if !cancelled {
+		return ErrScheduleNotFound
+	}
+
+	return nil
+}
+
+// RunDue executes every scheduled transfer that is due and returns how many
+// were processed. Each transfer is claimed and executed in its own database
+// transaction, so several scheduler instances can run side by side.
+func (s *Service) RunDue(ctx context.Context) (int, error) {
+	processed := 0
+	for processed < runBatchSize {
+		claimed, err := s.runNext(ctx)
+		if err != nil {
+			return processed, err
+		}
+		if !claimed {
+			break
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// runNext claims the next due transfer and executes it. The run is recorded
+// in the audit log once its transaction has finished.
+func (s *Service) runNext(ctx context.Context) (bool, error) {
+	var (
+		t       models.ScheduledTransfer
+		txID    int64
+		runErr  error
+		claimed bool
+	)
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		due, err := s.repo.ClaimDueScheduledTransfers(ctx, time.Now().UTC(), 1)
+		if err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		claimed = true
+		t = due[0]
+
+		txID, runErr = s.execute(ctx, t)
+		return s.reschedule(ctx, t, runErr)
+	})
+	if !claimed {
+		return false, err
+	}
+
+	if err != nil {
+		// Rescheduling failed, so the transfer was rolled back with it.
+		runErr = err
+	}
+	params := map[string]any{"id": t.ID, "toUser": t.ToUser, "amount": t.Amount}
+	if runErr == nil {
+		params["transactionId"] = txID
+	}
+	audit.Record(ctx, s.auditor, t.FromUser, models.AuditActionScheduleRun, params, runErr)
+
+	return true, err
+}
+
+// execute performs one run of t and returns the id of the transaction it
+// recorded. A transfer held for fraud review counts as a successful run.
+func (s *Service) execute(ctx context.Context, t models.ScheduledTransfer) (int64, error) {
+	txID, err := s.sender.Transfer(ctx, t.FromUser, t.ToUser, t.Amount)
+	if err != nil {
+		s.logger.Warn("Scheduled transfer failed",
+			slog.Int64("id", t.ID),
+			slog.String("from", t.FromUser),
+			slog.String("to", t.ToUser),
+			slog.Int("amount", t.Amount),
+			slog.String("error", err.Error()))
+		return 0, err
+	}
+
+	return txID, nil
+}
+
+// reschedule records the outcome of a run of t and sets its next run. A failed
+// run is recorded on the transfer; after maxFailures consecutive failures it
+// is marked failed.
+func (s *Service) reschedule(ctx context.Context, t models.ScheduledTransfer, err error) error {
+	now := time.Now().UTC()
+	t.LastRunAt = &now
+
+	if err != nil {
+		t.LastError = err.Error()
+		t.FailureCount++
+	} else {
+		t.LastError = ""
+		t.FailureCount = 0
+	}
+
+	switch {
+	case t.FailureCount >= s.maxFailures:
+		t.Status = models.ScheduleStatusFailed
+	case t.Schedule == "" && err == nil:
+		t.Status = models.ScheduleStatusCompleted
+	case t.Schedule == "":
+		t.NextRunAt = now.Add(s.retryDelay)
+	default:
+		sched, parseErr := cron.Parse(t.Schedule)
+		if parseErr != nil {
+			t.Status = models.ScheduleStatusFailed
+			t.LastError = parseErr.Error()
+			break
+		}
+		t.NextRunAt = sched.Next(now)
+		if t.NextRunAt.IsZero() {
+			t.Status = models.ScheduleStatusCompleted
+		}
+	}
+
+	return s.repo.UpdateScheduledTransferRun(ctx, t)
+}
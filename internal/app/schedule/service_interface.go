@@ -0,0 +1,13 @@
+package schedule
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceInterface interface {
+	CreateScheduledTransfer(ctx context.Context, from string, req models.ScheduledTransferRequest) (models.ScheduledTransfer, error)
+	ListScheduledTransfers(ctx context.Context, username string) ([]models.ScheduledTransfer, error)
+	CancelScheduledTransfer(ctx context.Context, username string, id int64) error
+}
@@ -0,0 +1,34 @@
+package schedule
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceMock struct {
+	CreateScheduledTransferFunc func(ctx context.Context, from string, req models.ScheduledTransferRequest) (models.ScheduledTransfer, error)
+	ListScheduledTransfersFunc  func(ctx context.Context, username string) ([]models.ScheduledTransfer, error)
+	CancelScheduledTransferFunc func(ctx context.Context, username string, id int64) error
+}
+
+func (m *ServiceMock) CreateScheduledTransfer(ctx context.Context, from string, req models.ScheduledTransferRequest) (models.ScheduledTransfer, error) {
+	if m.CreateScheduledTransferFunc != nil {
+		return m.CreateScheduledTransferFunc(ctx, from, req)
+	}
+	return models.ScheduledTransfer{}, nil
+}
+
+func (m *ServiceMock) ListScheduledTransfers(ctx context.Context, username string) ([]models.ScheduledTransfer, error) {
+	if m.ListScheduledTransfersFunc != nil {
+		return m.ListScheduledTransfersFunc(ctx, username)
+	}
+	return nil, nil
+}
+
+func (m *ServiceMock) CancelScheduledTransfer(ctx context.Context, username string, id int64) error {
+	if m.CancelScheduledTransferFunc != nil {
+		return m.CancelScheduledTransferFunc(ctx, username, id)
+	}
+	return nil
+}
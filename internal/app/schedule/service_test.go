@@ -0,0 +1,278 @@
+package schedule_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nglmq/avito-shop/internal/app/schedule"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type MockScheduleRepository struct {
+	due     []models.ScheduledTransfer
+	updated []models.ScheduledTransfer
+	created models.ScheduledTransfer
+}
+
+func (m *MockScheduleRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockScheduleRepository) GetUserByUsername(ctx context.Context, username string) (bool, error) {
+	return username != "unknown", nil
+}
+
+func (m *MockScheduleRepository) CreateScheduledTransfer(ctx context.Context, t models.ScheduledTransfer) (int64, error) {
+	m.created = t
+	return 1, nil
+}
+
+func (m *MockScheduleRepository) ListScheduledTransfers(ctx context.Context, username string) ([]models.ScheduledTransfer, error) {
+	return nil, nil
+}
+
+func (m *MockScheduleRepository) CancelScheduledTransfer(ctx context.Context, username string, id int64) (bool, error) {
+	return id == 1, nil
+}
+
+func (m *MockScheduleRepository) ClaimDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]models.ScheduledTransfer, error) {
+	if len(m.due) == 0 {
+		return nil, nil
+	}
+	t := m.due[0]
+	m.due = m.due[1:]
+	return []models.ScheduledTransfer{t}, nil
+}
+
+func (m *MockScheduleRepository) UpdateScheduledTransferRun(ctx context.Context, t models.ScheduledTransfer) error {
+	m.updated = append(m.updated, t)
+	return nil
+}
+
+func newService(repo schedule.Repository, sendErr error) *schedule.Service {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return schedule.New(logger, repo, &transaction.ServiceMock{
+		TransferFunc: func(ctx context.Context, fromUser, toUser string, amount int) (int64, error) {
+			if sendErr != nil {
+				return 0, sendErr
+			}
+			return 42, nil
+		},
+	})
+}
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		req           models.ScheduledTransferRequest
+		expectedError error
+	}{
+		{
+			name: "OneShot",
+			req:  models.ScheduledTransferRequest{ToUser: "intern", Amount: 10, RunAt: &future},
+		},
+		{
+			name: "Recurring",
+			req:  models.ScheduledTransferRequest{ToUser: "intern", Amount: 10, Schedule: "0 9 * * 1"},
+		},
+		{
+			name:          "RunAtInPast",
+			req:           models.ScheduledTransferRequest{ToUser: "intern", Amount: 10, RunAt: &past},
+			expectedError: schedule.ErrRunAtInPast,
+		},
+		{
+			name:          "InvalidSchedule",
+			req:           models.ScheduledTransferRequest{ToUser: "intern", Amount: 10, Schedule: "every monday"},
+			expectedError: schedule.ErrInvalidSchedule,
+		},
+		{
+			name:          "SameUser",
+			req:           models.ScheduledTransferRequest{ToUser: "lead", Amount: 10, Schedule: "@weekly"},
+			expectedError: schedule.ErrInvalidRecipient,
+		},
+		{
+			name:          "InvalidAmount",
+			req:           models.ScheduledTransferRequest{ToUser: "intern", Amount: -1, Schedule: "@weekly"},
+			expectedError: schedule.ErrInvalidAmount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockScheduleRepository{}
+			transfer, err := newService(repo, nil).CreateScheduledTransfer(context.Background(), "lead", tt.req)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+			if transfer.Status != models.ScheduleStatusActive || !transfer.NextRunAt.After(time.Now()) {
+				t.Fatalf("unexpected transfer %+v", transfer)
+			}
+		})
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	tests := []struct {
+		name             string
+		transfer         models.ScheduledTransfer
+		sendErr          error
+		expectedStatus   string
+		expectedFailures int
+	}{
+		{
+			name:           "OneShotSuccess",
+			transfer:       models.ScheduledTransfer{ID: 1, FromUser: "lead", ToUser: "intern", Amount: 10},
+			expectedStatus: models.ScheduleStatusCompleted,
+		},
+		{
+			name:           "RecurringSuccess",
+			transfer:       models.ScheduledTransfer{ID: 1, FromUser: "lead", ToUser: "intern", Amount: 10, Schedule: "@weekly"},
+			expectedStatus: models.ScheduleStatusActive,
+		},
+		{
+			name:             "InsufficientBalanceRetried",
+			transfer:         models.ScheduledTransfer{ID: 1, FromUser: "lead", ToUser: "intern", Amount: 10, Schedule: "@weekly"},
+			sendErr:          transaction.ErrInsufficientBalance,
+			expectedStatus:   models.ScheduleStatusActive,
+			expectedFailures: 1,
+		},
+		{
+			name: "TooManyFailures",
+			transfer: models.ScheduledTransfer{
+				ID: 1, FromUser: "lead", ToUser: "intern", Amount: 10, FailureCount: schedule.DefaultMaxFailures - 1,
+			},
+			sendErr:          transaction.ErrInsufficientBalance,
+			expectedStatus:   models.ScheduleStatusFailed,
+			expectedFailures: schedule.DefaultMaxFailures,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.transfer.Status = models.ScheduleStatusActive
+			repo := &MockScheduleRepository{due: []models.ScheduledTransfer{tt.transfer}}
+
+			processed, err := newService(repo, tt.sendErr).RunDue(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if processed != 1 || len(repo.updated) != 1 {
+				t.Fatalf("expected one processed transfer, got %d", processed)
+			}
+
+			updated := repo.updated[0]
+			if updated.Status != tt.expectedStatus {
+				t.Fatalf("expected status %q, got %q", tt.expectedStatus, updated.Status)
+			}
+			if updated.FailureCount != tt.expectedFailures {
+				t.Fatalf("expected %d failures, got %d", tt.expectedFailures, updated.FailureCount)
+			}
+			if updated.LastRunAt == nil {
+				t.Fatalf("expected last run time to be recorded")
+			}
+			if updated.Status == models.ScheduleStatusActive && !updated.NextRunAt.After(time.Now()) {
+				t.Fatalf("expected next run in the future, got %v", updated.NextRunAt)
+			}
+		})
+	}
+}
+
+type recordingAuditor struct {
+	actions  []string
+	outcomes []error
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, actor, action string, params any, err error) {
+	a.actions = append(a.actions, actor+":"+action)
+	a.outcomes = append(a.outcomes, err)
+}
+
+func TestScheduledTransfersAudited(t *testing.T) {
+	auditor := &recordingAuditor{}
+	service := schedule.New(slog.New(slog.NewTextHandler(io.Discard, nil)), &MockScheduleRepository{},
+		&transaction.ServiceMock{}, schedule.WithAuditor(auditor))
+
+	req := models.ScheduledTransferRequest{ToUser: "intern", Amount: 10, Schedule: "@weekly"}
+	if _, err := service.CreateScheduledTransfer(context.Background(), "lead", req); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := service.CancelScheduledTransfer(context.Background(), "lead", 2); !errors.Is(err, schedule.ErrScheduleNotFound) {
+		t.Fatalf("expected error %v, got %v", schedule.ErrScheduleNotFound, err)
+	}
+
+	if len(auditor.actions) != 2 ||
+		auditor.actions[0] != "lead:"+models.AuditActionScheduleCreate ||
+		auditor.actions[1] != "lead:"+models.AuditActionScheduleCancel {
+		t.Fatalf("unexpected audit entries %v", auditor.actions)
+	}
+	if auditor.outcomes[0] != nil || !errors.Is(auditor.outcomes[1], schedule.ErrScheduleNotFound) {
+		t.Fatalf("unexpected audit outcomes %v", auditor.outcomes)
+	}
+}
+
+type txRecordingRepository struct {
+	MockScheduleRepository
+	inTx bool
+}
+
+func (m *txRecordingRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.inTx = true
+	defer func() { m.inTx = false }()
+	return fn(ctx)
+}
+
+type txCheckingAuditor struct {
+	recordingAuditor
+	repo *txRecordingRepository
+	t    *testing.T
+}
+
+func (a *txCheckingAuditor) Record(ctx context.Context, actor, action string, params any, err error) {
+	if a.repo.inTx {
+		a.t.Errorf("%s recorded inside a database transaction", action)
+	}
+	a.recordingAuditor.Record(ctx, actor, action, params, err)
+}
+
+func TestScheduledRunsAudited(t *testing.T) {
+	repo := &txRecordingRepository{MockScheduleRepository: MockScheduleRepository{due: []models.ScheduledTransfer{
+		{ID: 1, FromUser: "lead", ToUser: "intern", Amount: 10, Status: models.ScheduleStatusActive},
+		{ID: 2, FromUser: "lead", ToUser: "broke", Amount: 10, Status: models.ScheduleStatusActive},
+	}}}
+	auditor := &txCheckingAuditor{repo: repo, t: t}
+	service := schedule.New(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, &transaction.ServiceMock{
+		TransferFunc: func(ctx context.Context, fromUser, toUser string, amount int) (int64, error) {
+			if toUser == "broke" {
+				return 0, transaction.ErrInsufficientBalance
+			}
+			return 42, nil
+		},
+	}, schedule.WithAuditor(auditor))
+
+	processed, err := service.RunDue(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if processed != 2 {
+		t.Fatalf("expected two processed transfers, got %d", processed)
+	}
+
+	if len(auditor.actions) != 2 ||
+		auditor.actions[0] != "lead:"+models.AuditActionScheduleRun ||
+		auditor.actions[1] != "lead:"+models.AuditActionScheduleRun {
+		t.Fatalf("unexpected audit entries %v", auditor.actions)
+	}
+	if auditor.outcomes[0] != nil || !errors.Is(auditor.outcomes[1], transaction.ErrInsufficientBalance) {
+		t.Fatalf("unexpected audit outcomes %v", auditor.outcomes)
+	}
+}
@@ -0,0 +1,11 @@
+package transaction
+
+import "github.com/nglmq/avito-shop/internal/app/audit"
+
+// WithAuditor records transfers and the resolution of pending transfers in
+// the audit log.
+func WithAuditor(a audit.Auditor) Option {
+	return func(s *Service) {
+		s.auditor = a
+	}
+}
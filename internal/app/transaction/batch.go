@@ -0,0 +1,116 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const MaxBatchSize = 100
+
+var (
+	ErrInvalidBatch       = errors.New("invalid batch")
+	ErrDuplicateRecipient = errors.New("duplicate recipient")
+)
+
+// SendBatch transfers coins from one sender to several recipients. Either all
+// transfers succeed and are linked by a single batch id, or none is applied.
+// Transfers held for fraud review count as made, and are reported with the
+// held status.
+func (s *Service) SendBatch(ctx context.Context, from string, req models.BatchTransferRequest) (models.BatchTransferResponse, error) {
+	resp, err := s.sendBatch(ctx, from, req)
+	audit.Record(ctx, s.auditor, from, models.AuditActionSend, map[string]any{"batch": req, "batchId": resp.BatchID}, err)
+
+	return resp, err
+}
+
+func (s *Service) sendBatch(ctx context.Context, from string, req models.BatchTransferRequest) (models.BatchTransferResponse, error) {
+	recipients := req.Recipients
+	if len(req.ToUsers) > 0 {
+		var err error
+		recipients, err = splitEvenly(req.Total, req.ToUsers)
+		if err != nil {
+			return models.BatchTransferResponse{}, err
+		}
+	}
+
+	if len(recipients) == 0 || len(recipients) > MaxBatchSize {
+		return models.BatchTransferResponse{}, fmt.Errorf("%w: between 1 and %d recipients required", ErrInvalidBatch, MaxBatchSize)
+	}
+
+	seen := make(map[string]struct{}, len(recipients))
+	resp := models.BatchTransferResponse{
+		Transactions: make([]models.BatchTransaction, 0, len(recipients)),
+	}
+	for _, rcpt := range recipients {
+		if _, ok := seen[rcpt.ToUser]; ok {
+			return models.BatchTransferResponse{}, fmt.Errorf("%w: %s", ErrDuplicateRecipient, rcpt.ToUser)
+		}
+		seen[rcpt.ToUser] = struct{}{}
+		resp.Total += rcpt.Amount
+	}
+
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		ids := make([]int64, 0, len(recipients))
+		for _, rcpt := range recipients {
+			receipt, held, err := s.transfer(ctx, from, rcpt.ToUser, rcpt.Amount)
+			if err != nil {
+				return fmt.Errorf("transfer to %s: %w", rcpt.ToUser, err)
+			}
+			ids = append(ids, receipt.TransactionID)
+			status := models.TransactionStatusCompleted
+			if held {
+				status = models.TransactionStatusHeld
+			}
+			resp.Fees += receipt.Fee
+			resp.Transactions = append(resp.Transactions, models.BatchTransaction{
+				ID:     receipt.TransactionID,
+				ToUser: rcpt.ToUser,
+				Amount: receipt.Amount,
+				Fee:    receipt.Fee,
+				Status: status,
+			})
+		}
+
+		batchID, err := s.repo.CreateTransferBatch(ctx, from, resp.Total, ids)
+		if err != nil {
+			s.logger.Error("Error creating transfer batch",
+				slog.String("from", from),
+				slog.Int("total", resp.Total),
+				slog.String("error", err.Error()))
+			return err
+		}
+		resp.BatchID = batchID
+
+		return nil
+	})
+	if err != nil {
+		return models.BatchTransferResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// splitEvenly divides total between users. When total is not divisible by the
+// number of users, the remainder is handed out one coin at a time to the first
+// users in the list.
+func splitEvenly(total int, users []string) ([]models.BatchRecipient, error) {
+	if len(users) == 0 || total < len(users) {
+		return nil, fmt.Errorf("%w: total must give every recipient at least one coin", ErrInvalidAmount)
+	}
+
+	share, remainder := total/len(users), total%len(users)
+	recipients := make([]models.BatchRecipient, len(users))
+	for i, user := range users {
+		recipients[i] = models.BatchRecipient{ToUser: user, Amount: share}
+		if i < remainder {
+			recipients[i].Amount++
+		}
+	}
+
+	return recipients, nil
+}
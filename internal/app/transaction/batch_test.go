@@ -0,0 +1,150 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// newBatchRepo simulates balances so that a failing transfer in the middle of
+// a batch can be observed.
+func newBatchRepo(balances map[string]int, credited map[string]int) *MockTransactionRepository {
+	var nextID int64
+	return &MockTransactionRepository{
+		GetBalanceFunc: func(ctx context.Context, username string) (int, error) {
+			return balances[username], nil
+		},
+		GetUserByUsernameFunc: func(ctx context.Context, username string) (bool, error) {
+			_, ok := balances[username]
+			return ok, nil
+		},
+		UpdateBalanceDeductFunc: func(ctx context.Context, username string, amount int) error {
+			balances[username] -= amount
+			return nil
+		},
+		UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+			credited[username] += amount
+			return nil
+		},
+		CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+			nextID++
+			return nextID, nil
+		},
+		CreateHeldTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+			nextID++
+			return nextID, nil
+		},
+		CreateTransferBatchFunc: func(ctx context.Context, from string, total int, ids []int64) (int64, error) {
+			return 99, nil
+		},
+	}
+}
+
+func TestSendBatch(t *testing.T) {
+	tests := []struct {
+		name             string
+		req              models.BatchTransferRequest
+		expectedError    error
+		expectedCredited map[string]int
+	}{
+		{
+			name: "ExplicitAmounts",
+			req: models.BatchTransferRequest{Recipients: []models.BatchRecipient{
+				{ToUser: "bob", Amount: 10},
+				{ToUser: "carol", Amount: 20},
+			}},
+			expectedCredited: map[string]int{"bob": 10, "carol": 20},
+		},
+		{
+			name:             "EvenSplitWithRemainder",
+			req:              models.BatchTransferRequest{ToUsers: []string{"bob", "carol", "dave"}, Total: 100},
+			expectedCredited: map[string]int{"bob": 34, "carol": 33, "dave": 33},
+		},
+		{
+			name:          "SplitTooSmall",
+			req:           models.BatchTransferRequest{ToUsers: []string{"bob", "carol", "dave"}, Total: 2},
+			expectedError: transaction.ErrInvalidAmount,
+		},
+		{
+			name: "DuplicateRecipient",
+			req: models.BatchTransferRequest{Recipients: []models.BatchRecipient{
+				{ToUser: "bob", Amount: 10},
+				{ToUser: "bob", Amount: 20},
+			}},
+			expectedError: transaction.ErrDuplicateRecipient,
+		},
+		{
+			name:          "Empty",
+			req:           models.BatchTransferRequest{},
+			expectedError: transaction.ErrInvalidBatch,
+		},
+		{
+			name: "InsufficientBalanceMidBatch",
+			req: models.BatchTransferRequest{Recipients: []models.BatchRecipient{
+				{ToUser: "bob", Amount: 60},
+				{ToUser: "carol", Amount: 60},
+			}},
+			expectedError: transaction.ErrInsufficientBalance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balances := map[string]int{"alice": 100, "bob": 0, "carol": 0, "dave": 0}
+			credited := map[string]int{}
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			service := transaction.New(logger, newBatchRepo(balances, credited))
+
+			resp, err := service.SendBatch(context.Background(), "alice", tt.req)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.expectedCredited, credited)
+			assert.Equal(t, int64(99), resp.BatchID)
+			assert.Len(t, resp.Transactions, len(tt.expectedCredited))
+		})
+	}
+}
+
+// recipientChecker holds transfers to the listed recipients.
+type recipientChecker struct {
+	hold map[string]bool
+}
+
+func (c recipientChecker) Evaluate(ctx context.Context, from, to string, amount int) (models.FraudVerdict, error) {
+	if c.hold[to] {
+		return models.FraudVerdict{Action: models.FraudActionHold, Rules: []string{"fan_in"}}, nil
+	}
+	return models.FraudVerdict{}, nil
+}
+
+func (c recipientChecker) RecordFlag(ctx context.Context, transactionID int64, verdict models.FraudVerdict) error {
+	return nil
+}
+
+func TestSendBatchReportsHeldTransfers(t *testing.T) {
+	balances := map[string]int{"alice": 100, "bob": 0, "carol": 0}
+	credited := map[string]int{}
+	service := transaction.New(slog.New(slog.NewTextHandler(io.Discard, nil)), newBatchRepo(balances, credited),
+		transaction.WithFraudChecker(recipientChecker{hold: map[string]bool{"carol": true}}))
+
+	resp, err := service.SendBatch(context.Background(), "alice", models.BatchTransferRequest{Recipients: []models.BatchRecipient{
+		{ToUser: "bob", Amount: 10},
+		{ToUser: "carol", Amount: 20},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"bob": 10}, credited)
+	if assert.Len(t, resp.Transactions, 2) {
+		assert.Equal(t, models.TransactionStatusCompleted, resp.Transactions[0].Status)
+		assert.Equal(t, models.TransactionStatusHeld, resp.Transactions[1].Status)
+	}
+}
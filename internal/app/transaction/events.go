@@ -0,0 +1,10 @@
+package transaction
+
+import "github.com/nglmq/avito-shop/internal/app/outbox"
+
+// WithPublisher notifies recipients of incoming and pending transfers.
+func WithPublisher(p outbox.Publisher) Option {
+	return func(s *Service) {
+		s.events = p
+	}
+}
@@ -0,0 +1,68 @@
+package transaction_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type recordedEvent struct {
+	username  string
+	eventType string
+	data      any
+}
+
+type recordingPublisher struct {
+	events []recordedEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, username, eventType string, data any) error {
+	p.events = append(p.events, recordedEvent{username, eventType, data})
+	return nil
+}
+
+func TestTransferPublishesEvents(t *testing.T) {
+	repo := &MockTransactionRepository{
+		GetBalanceFunc: func(ctx context.Context, username string) (int, error) {
+			return 1000, nil
+		},
+		GetUserByUsernameFunc: func(ctx context.Context, username string) (bool, error) {
+			return true, nil
+		},
+		CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+			return 42, nil
+		},
+		CreatePendingTransactionFunc: func(ctx context.Context, from, to string, amount int, expiresAt time.Time) (int64, error) {
+			return 43, nil
+		},
+	}
+	publisher := &recordingPublisher{}
+	service := transaction.New(nil, repo, transaction.WithPublisher(publisher))
+
+	if _, err := service.SendCoins(context.Background(), "alice", "bob", 30); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.CreatePendingTransfer(context.Background(), "alice", "carol", 20); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(publisher.events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", publisher.events)
+	}
+
+	received := publisher.events[0]
+	if received.username != "bob" || received.eventType != models.EventCoinsReceived {
+		t.Errorf("unexpected event %+v", received)
+	}
+	if data, ok := received.data.(models.CoinsReceivedEvent); !ok || data.TransactionID != 42 || data.Amount != 30 || data.FromUser != "alice" {
+		t.Errorf("unexpected event data %+v", received.data)
+	}
+
+	pending := publisher.events[1]
+	if pending.username != "carol" || pending.eventType != models.EventTransferPending {
+		t.Errorf("unexpected event %+v", pending)
+	}
+}
@@ -0,0 +1,98 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// FeePolicy configures the fee charged to the sender on top of each transfer.
+// The percentage part is rounded up, Max caps the whole fee when positive.
+// Transfers from or to an exempt user or the fee account are free. Collected
+// fees are credited to Account, or burned when Account is empty.
+type FeePolicy struct {
+	Percent float64
+	Flat    int
+	Max     int
+	Exempt  []string
+	Account string
+}
+
+// WithFeePolicy enables transfer fees.
+func WithFeePolicy(policy FeePolicy) Option {
+	return func(s *Service) {
+		s.fees = policy
+	}
+}
+
+// QuoteTransfer tells the sender what a transfer would cost without making it.
+func (s *Service) QuoteTransfer(ctx context.Context, from, to string, amount int) (models.TransferQuote, error) {
+	if from == to {
+		return models.TransferQuote{}, ErrInvalidRecipient
+	}
+	if amount <= 0 {
+		return models.TransferQuote{}, ErrInvalidAmount
+	}
+
+	fee := s.fee(from, to, amount)
+
+	return models.TransferQuote{
+		ToUser: to,
+		Amount: amount,
+		Fee:    fee,
+		Total:  amount + fee,
+	}, nil
+}
+
+func (s *Service) fee(from, to string, amount int) int {
+	p := s.fees
+	if p.Percent <= 0 && p.Flat <= 0 {
+		return 0
+	}
+	if p.Account != "" && (from == p.Account || to == p.Account) {
+		return 0
+	}
+	if slices.Contains(p.Exempt, from) || slices.Contains(p.Exempt, to) {
+		return 0
+	}
+
+	fee := int(math.Ceil(float64(amount)*p.Percent/100)) + p.Flat
+	if p.Max > 0 && fee > p.Max {
+		fee = p.Max
+	}
+
+	return fee
+}
+
+// collectFee records the fee on the transaction and moves it to the fee
+// account. The sender must already have been charged.
+func (s *Service) collectFee(ctx context.Context, id int64, fee int) error {
+	if err := s.recordFee(ctx, id, fee); err != nil {
+		return err
+	}
+
+	return s.creditFee(ctx, fee)
+}
+
+func (s *Service) recordFee(ctx context.Context, id int64, fee int) error {
+	if fee == 0 {
+		return nil
+	}
+
+	return s.repo.SetTransactionFee(ctx, id, fee)
+}
+
+func (s *Service) creditFee(ctx context.Context, fee int) error {
+	if fee == 0 || s.fees.Account == "" {
+		return nil
+	}
+
+	if err := s.repo.UpdateBalance(ctx, s.fees.Account, fee); err != nil {
+		return fmt.Errorf("error crediting fee account: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+func TestQuoteTransfer(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  transaction.FeePolicy
+		to      string
+		amount  int
+		wantFee int
+	}{
+		{
+			name:    "No policy",
+			amount:  100,
+			wantFee: 0,
+		},
+		{
+			name:    "Percentage rounds up",
+			policy:  transaction.FeePolicy{Percent: 2.5},
+			amount:  101,
+			wantFee: 3,
+		},
+		{
+			name:    "Percentage and flat",
+			policy:  transaction.FeePolicy{Percent: 1, Flat: 2},
+			amount:  100,
+			wantFee: 3,
+		},
+		{
+			name:    "Capped",
+			policy:  transaction.FeePolicy{Percent: 10, Max: 5},
+			amount:  1000,
+			wantFee: 5,
+		},
+		{
+			name:    "Exempt recipient",
+			policy:  transaction.FeePolicy{Flat: 5, Exempt: []string{"bob"}},
+			amount:  100,
+			wantFee: 0,
+		},
+		{
+			name:    "Transfer to fee account",
+			policy:  transaction.FeePolicy{Flat: 5, Account: "treasury"},
+			to:      "treasury",
+			amount:  100,
+			wantFee: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to := tt.to
+			if to == "" {
+				to = "bob"
+			}
+
+			service := transaction.New(nil, &MockTransactionRepository{}, transaction.WithFeePolicy(tt.policy))
+			quote, err := service.QuoteTransfer(context.Background(), "alice", to, tt.amount)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if quote.Fee != tt.wantFee {
+				t.Errorf("expected fee %d, got %d", tt.wantFee, quote.Fee)
+			}
+			if quote.Total != tt.amount+tt.wantFee {
+				t.Errorf("expected total %d, got %d", tt.amount+tt.wantFee, quote.Total)
+			}
+		})
+	}
+}
+
+func TestSendCoinsWithFee(t *testing.T) {
+	tests := []struct {
+		name          string
+		account       string
+		balance       int
+		wantErr       error
+		wantDeducted  int
+		wantCredits   map[string]int
+		wantRecording int
+	}{
+		{
+			name:          "Fee credited to account",
+			account:       "treasury",
+			balance:       1000,
+			wantDeducted:  110,
+			wantCredits:   map[string]int{"bob": 100, "treasury": 10},
+			wantRecording: 10,
+		},
+		{
+			name:          "Fee burned",
+			balance:       1000,
+			wantDeducted:  110,
+			wantCredits:   map[string]int{"bob": 100},
+			wantRecording: 10,
+		},
+		{
+			name:        "Balance does not cover fee",
+			balance:     105,
+			wantErr:     transaction.ErrInsufficientBalance,
+			wantCredits: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deducted, recorded int
+			credits := map[string]int{}
+			repo := &MockTransactionRepository{
+				GetBalanceFunc: func(ctx context.Context, username string) (int, error) {
+					return tt.balance, nil
+				},
+				GetUserByUsernameFunc: func(ctx context.Context, username string) (bool, error) {
+					return true, nil
+				},
+				UpdateBalanceDeductFunc: func(ctx context.Context, username string, amount int) error {
+					deducted += amount
+					return nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+					credits[username] += amount
+					return nil
+				},
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
+				},
+				SetTransactionFeeFunc: func(ctx context.Context, id int64, fee int) error {
+					recorded = fee
+					return nil
+				},
+			}
+
+			service := transaction.New(nil, repo, transaction.WithFeePolicy(transaction.FeePolicy{
+				Percent: 10,
+				Account: tt.account,
+			}))
+			receipt, err := service.SendCoins(context.Background(), "alice", "bob", 100)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && (receipt.Fee != tt.wantRecording || receipt.Total != 100+tt.wantRecording) {
+				t.Errorf("expected a receipt with fee %d, got %+v", tt.wantRecording, receipt)
+			}
+			if deducted != tt.wantDeducted {
+				t.Errorf("expected %d deducted, got %d", tt.wantDeducted, deducted)
+			}
+			if len(credits) != len(tt.wantCredits) {
+				t.Errorf("expected credits %v, got %v", tt.wantCredits, credits)
+			}
+			for user, amount := range tt.wantCredits {
+				if credits[user] != amount {
+					t.Errorf("expected %s credited %d, got %d", user, amount, credits[user])
+				}
+			}
+			if recorded != tt.wantRecording {
+				t.Errorf("expected fee %d recorded, got %d", tt.wantRecording, recorded)
+			}
+		})
+	}
+}
+
+func TestPendingTransferFee(t *testing.T) {
+	tests := []struct {
+		name        string
+		resolve     func(s *transaction.Service) error
+		wantCredits map[string]int
+	}{
+		{
+			name: "Accepted transfer collects fee",
+			resolve: func(s *transaction.Service) error {
+				return s.AcceptPendingTransfer(context.Background(), "bob", 1)
+			},
+			wantCredits: map[string]int{"bob": 100, "treasury": 5},
+		},
+		{
+			name: "Declined transfer refunds fee",
+			resolve: func(s *transaction.Service) error {
+				return s.DeclinePendingTransfer(context.Background(), "bob", 1)
+			},
+			wantCredits: map[string]int{"alice": 105},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credits := map[string]int{}
+			repo := &MockTransactionRepository{
+				GetPendingTransactionFunc: func(ctx context.Context, id int64) (models.PendingTransfer, error) {
+					return models.PendingTransfer{
+						ID:        id,
+						FromUser:  "alice",
+						ToUser:    "bob",
+						Amount:    100,
+						Fee:       5,
+						Status:    models.TransactionStatusPending,
+						ExpiresAt: time.Now().Add(time.Hour),
+					}, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+					credits[username] += amount
+					return nil
+				},
+			}
+
+			service := transaction.New(nil, repo, transaction.WithFeePolicy(transaction.FeePolicy{
+				Flat:    5,
+				Account: "treasury",
+			}))
+			if err := tt.resolve(service); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(credits) != len(tt.wantCredits) {
+				t.Errorf("expected credits %v, got %v", tt.wantCredits, credits)
+			}
+			for user, amount := range tt.wantCredits {
+				if credits[user] != amount {
+					t.Errorf("expected %s credited %d, got %d", user, amount, credits[user])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// ErrTransferHeld is returned by SendCoins and AcceptPendingTransfer when the
+// transfer was recorded but its coins are held until an admin reviews it.
+var ErrTransferHeld = errors.New("transfer is held for review")
+
+// FraudChecker screens transfers before they are made, normally fraud.Service.
+type FraudChecker interface {
+	Evaluate(ctx context.Context, from, to string, amount int) (models.FraudVerdict, error)
+	RecordFlag(ctx context.Context, transactionID int64, verdict models.FraudVerdict) error
+}
+
+// WithFraudChecker screens every transfer with checker, pending ones when the
+// recipient accepts them. Suspicious transfers are flagged for review or
+// held: the sender is charged but the recipient is only credited once an
+// admin approves the transfer.
+func WithFraudChecker(checker FraudChecker) Option {
+	return func(s *Service) {
+		s.fraud = checker
+	}
+}
+
+func (s *Service) screen(ctx context.Context, from, to string, amount int) (models.FraudVerdict, error) {
+	if s.fraud == nil {
+		return models.FraudVerdict{}, nil
+	}
+
+	return s.fraud.Evaluate(ctx, from, to, amount)
+}
@@ -0,0 +1,178 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type fraudCheckerStub struct {
+	verdict  models.FraudVerdict
+	recorded []int64
+}
+
+func (f *fraudCheckerStub) Evaluate(ctx context.Context, from, to string, amount int) (models.FraudVerdict, error) {
+	return f.verdict, nil
+}
+
+func (f *fraudCheckerStub) RecordFlag(ctx context.Context, transactionID int64, verdict models.FraudVerdict) error {
+	f.recorded = append(f.recorded, transactionID)
+	return nil
+}
+
+func TestSendCoinsFraudScreening(t *testing.T) {
+	tests := []struct {
+		name         string
+		verdict      models.FraudVerdict
+		wantErr      error
+		wantCredited bool
+		wantHeld     bool
+		wantFlagged  bool
+	}{
+		{
+			name:         "Clean transfer",
+			wantCredited: true,
+		},
+		{
+			name:         "Flagged transfer completes",
+			verdict:      models.FraudVerdict{Action: models.FraudActionFlag, Rules: []string{"burst"}},
+			wantCredited: true,
+			wantFlagged:  true,
+		},
+		{
+			name:        "Held transfer",
+			verdict:     models.FraudVerdict{Action: models.FraudActionHold, Rules: []string{"fan_in"}},
+			wantErr:     transaction.ErrTransferHeld,
+			wantHeld:    true,
+			wantFlagged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deducted, credited, held bool
+			var recordedFee int
+			repo := &MockTransactionRepository{
+				GetBalanceFunc: func(ctx context.Context, username string) (int, error) {
+					return 1000, nil
+				},
+				GetUserByUsernameFunc: func(ctx context.Context, username string) (bool, error) {
+					return true, nil
+				},
+				UpdateBalanceDeductFunc: func(ctx context.Context, username string, amount int) error {
+					deducted = true
+					return nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+					credited = true
+					return nil
+				},
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
+				},
+				CreateHeldTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					held = true
+					return 2, nil
+				},
+				SetTransactionFeeFunc: func(ctx context.Context, id int64, fee int) error {
+					recordedFee = fee
+					return nil
+				},
+			}
+			checker := &fraudCheckerStub{verdict: tt.verdict}
+
+			service := transaction.New(nil, repo,
+				transaction.WithFraudChecker(checker),
+				transaction.WithFeePolicy(transaction.FeePolicy{Flat: 5, Account: "treasury"}))
+			_, err := service.SendCoins(context.Background(), "alice", "bob", 100)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if !deducted {
+				t.Error("expected sender to be charged")
+			}
+			if credited != tt.wantCredited {
+				t.Errorf("expected credited %v, got %v", tt.wantCredited, credited)
+			}
+			if recordedFee != 5 {
+				t.Errorf("expected fee 5 recorded, got %d", recordedFee)
+			}
+			if held != tt.wantHeld {
+				t.Errorf("expected held %v, got %v", tt.wantHeld, held)
+			}
+			if (len(checker.recorded) > 0) != tt.wantFlagged {
+				t.Errorf("expected flagged %v, got %v", tt.wantFlagged, checker.recorded)
+			}
+		})
+	}
+}
+
+func TestAcceptPendingTransferFraudScreening(t *testing.T) {
+	tests := []struct {
+		name         string
+		verdict      models.FraudVerdict
+		wantErr      error
+		wantCredited bool
+		wantHeld     bool
+		wantFlagged  bool
+	}{
+		{
+			name:         "Clean transfer",
+			wantCredited: true,
+		},
+		{
+			name:         "Flagged transfer completes",
+			verdict:      models.FraudVerdict{Action: models.FraudActionFlag, Rules: []string{"burst"}},
+			wantCredited: true,
+			wantFlagged:  true,
+		},
+		{
+			name:        "Held transfer",
+			verdict:     models.FraudVerdict{Action: models.FraudActionHold, Rules: []string{"fan_in"}},
+			wantErr:     transaction.ErrTransferHeld,
+			wantHeld:    true,
+			wantFlagged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var credited, held bool
+			repo := &MockTransactionRepository{
+				GetPendingTransactionFunc: func(ctx context.Context, id int64) (models.PendingTransfer, error) {
+					return pendingTransfer(models.TransactionStatusPending, time.Hour), nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+					credited = true
+					return nil
+				},
+				HoldPendingTransactionFunc: func(ctx context.Context, id int64) error {
+					held = true
+					return nil
+				},
+			}
+			checker := &fraudCheckerStub{verdict: tt.verdict}
+
+			service := transaction.New(nil, repo, transaction.WithFraudChecker(checker))
+			err := service.AcceptPendingTransfer(context.Background(), "user2", 1)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if credited != tt.wantCredited {
+				t.Errorf("expected credited %v, got %v", tt.wantCredited, credited)
+			}
+			if held != tt.wantHeld {
+				t.Errorf("expected held %v, got %v", tt.wantHeld, held)
+			}
+			if (len(checker.recorded) > 0) != tt.wantFlagged {
+				t.Errorf("expected flagged %v, got %v", tt.wantFlagged, checker.recorded)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package transaction_test
 
 import (
 	"context"
+	"errors"
+	"github.com/nglmq/avito-shop/internal/app/fraud"
 	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
 	"github.com/nglmq/avito-shop/internal/storage"
 	"github.com/nglmq/avito-shop/internal/storage/postgresql"
 	"log"
@@ -10,6 +13,7 @@ import (
 
 	"log/slog"
 	"os"
+	"time"
 )
 
 func TestBuyItemIntegration(t *testing.T) {
@@ -93,7 +97,7 @@ func TestBuyItemIntegration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.SendCoins(context.Background(), tt.sender, tt.receiver, tt.amount)
+			_, err := service.SendCoins(context.Background(), tt.sender, tt.receiver, tt.amount)
 			if err == nil && tt.expectedError != nil {
 				t.Fatalf("expected error %v, got nil", tt.expectedError)
 			}
@@ -106,3 +110,69 @@ func TestBuyItemIntegration(t *testing.T) {
 		})
 	}
 }
+
+// holdingChecker holds every transfer and records the flag with the real
+// fraud service.
+type holdingChecker struct {
+	*fraud.Service
+}
+
+func (holdingChecker) Evaluate(ctx context.Context, from, to string, amount int) (models.FraudVerdict, error) {
+	return models.FraudVerdict{Action: models.FraudActionHold, Rules: []string{fraud.RuleBurst}}, nil
+}
+
+func TestRejectedHoldBalanceAsOfIntegration(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_DSN")
+
+	store, err := postgresql.NewRepo(context.Background(), dsn)
+	if err != nil {
+		log.Fatalf("failed to set up test database: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, username := range []string{"holdSender", "holdReceiver", "holdTreasury"} {
+		_, _ = store.SaveUser(ctx, username, "12345")
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	fraudService := fraud.New(logger, store, fraud.DefaultRules(), fraud.WithFeeAccount("holdTreasury"))
+	service := transaction.New(logger, store,
+		transaction.WithFraudChecker(holdingChecker{fraudService}),
+		transaction.WithFeePolicy(transaction.FeePolicy{Flat: 5, Account: "holdTreasury"}),
+	)
+
+	receipt, err := service.SendCoins(ctx, "holdSender", "holdReceiver", 10)
+	if !errors.Is(err, transaction.ErrTransferHeld) {
+		t.Fatalf("expected error %v, got %v", transaction.ErrTransferHeld, err)
+	}
+
+	flags, err := store.ListFraudFlags(ctx, models.FraudFlagStatusOpen)
+	if err != nil {
+		t.Fatalf("listing fraud flags: %v", err)
+	}
+	var flagID int64
+	for _, f := range flags {
+		if f.TransactionID == receipt.TransactionID {
+			flagID = f.ID
+		}
+	}
+	if _, err := fraudService.ReviewFlag(ctx, "admin", flagID, models.FraudReviewRequest{Decision: models.FraudDecisionReject}); err != nil {
+		t.Fatalf("rejecting hold: %v", err)
+	}
+
+	// A rejected hold refunds amount and fee and never reaches the fee
+	// account, so replaying history must match the live balances.
+	for _, username := range []string{"holdSender", "holdTreasury"} {
+		balance, err := store.GetBalance(ctx, username)
+		if err != nil {
+			t.Fatalf("fetching balance of %s: %v", username, err)
+		}
+		asOf, _, err := store.GetBalanceAsOf(ctx, username, time.Now().UTC().Add(time.Minute), "holdTreasury")
+		if err != nil {
+			t.Fatalf("replaying balance of %s: %v", username, err)
+		}
+		if asOf != balance {
+			t.Errorf("expected %s balance as of now to be %d, got %d", username, balance, asOf)
+		}
+	}
+}
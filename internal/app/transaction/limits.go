@@ -0,0 +1,118 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	LimitCodeTransferAmount = "transfer_amount_limit"
+	LimitCodeDailySent      = "daily_send_limit"
+	LimitCodeWeeklySent     = "weekly_send_limit"
+	LimitCodeDailyReceived  = "daily_receive_limit"
+	LimitCodeMinAccountAge  = "account_too_new"
+	limitWindowDay          = 24 * time.Hour
+	limitWindowWeek         = 7 * limitWindowDay
+)
+
+var ErrLimitExceeded = errors.New("transfer limit exceeded")
+
+// Limits configures transfer policies. A zero value disables the
+// corresponding check. Daily and weekly limits use rolling windows and count
+// both completed and pending transfers.
+type Limits struct {
+	MaxPerTransfer    int
+	MaxSentPerDay     int
+	MaxSentPerWeek    int
+	MaxReceivedPerDay int
+	MinAccountAge     time.Duration
+}
+
+// LimitError reports which policy rejected a transfer. It matches
+// ErrLimitExceeded with errors.Is.
+type LimitError struct {
+	Code    string
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// WithLimits enables transfer limits and velocity controls.
+func WithLimits(limits Limits) Option {
+	return func(s *Service) {
+		s.limits = limits
+	}
+}
+
+func (s *Service) checkLimits(ctx context.Context, from, to string, amount int) error {
+	l := s.limits
+	now := time.Now().UTC()
+
+	if l.MaxPerTransfer > 0 && amount > l.MaxPerTransfer {
+		return &LimitError{
+			Code:    LimitCodeTransferAmount,
+			Message: fmt.Sprintf("amount exceeds the limit of %d coins per transfer", l.MaxPerTransfer),
+		}
+	}
+
+	if l.MinAccountAge > 0 {
+		createdAt, err := s.repo.GetUserCreatedAt(ctx, from)
+		if err != nil {
+			return fmt.Errorf("error fetching account age: %w", err)
+		}
+		if now.Sub(createdAt) < l.MinAccountAge {
+			return &LimitError{
+				Code:    LimitCodeMinAccountAge,
+				Message: fmt.Sprintf("account must be at least %s old to send coins", l.MinAccountAge),
+			}
+		}
+	}
+
+	sentLimits := []struct {
+		max    int
+		window time.Duration
+		code   string
+		period string
+	}{
+		{l.MaxSentPerDay, limitWindowDay, LimitCodeDailySent, "day"},
+		{l.MaxSentPerWeek, limitWindowWeek, LimitCodeWeeklySent, "week"},
+	}
+	for _, sl := range sentLimits {
+		if sl.max <= 0 {
+			continue
+		}
+		sent, err := s.repo.GetSentAmountSince(ctx, from, now.Add(-sl.window))
+		if err != nil {
+			return fmt.Errorf("error fetching sent amount: %w", err)
+		}
+		if sent+amount > sl.max {
+			return &LimitError{
+				Code:    sl.code,
+				Message: fmt.Sprintf("transfer exceeds the limit of %d coins sent per %s", sl.max, sl.period),
+			}
+		}
+	}
+
+	if l.MaxReceivedPerDay > 0 {
+		received, err := s.repo.GetReceivedAmountSince(ctx, to, now.Add(-limitWindowDay))
+		if err != nil {
+			return fmt.Errorf("error fetching received amount: %w", err)
+		}
+		if received+amount > l.MaxReceivedPerDay {
+			return &LimitError{
+				Code:    LimitCodeDailyReceived,
+				Message: fmt.Sprintf("recipient cannot receive more than %d coins per day", l.MaxReceivedPerDay),
+			}
+		}
+	}
+
+	return nil
+}
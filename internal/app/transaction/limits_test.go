@@ -0,0 +1,118 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+)
+
+func TestTransferLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   transaction.Limits
+		amount   int
+		age      time.Duration
+		sent     int
+		received int
+		wantCode string
+	}{
+		{
+			name:   "Within all limits",
+			limits: transaction.Limits{MaxPerTransfer: 100, MaxSentPerDay: 200, MaxSentPerWeek: 500, MaxReceivedPerDay: 300, MinAccountAge: time.Hour},
+			amount: 100,
+			age:    2 * time.Hour,
+			sent:   100,
+		},
+		{
+			name:     "Amount above per-transfer limit",
+			limits:   transaction.Limits{MaxPerTransfer: 100},
+			amount:   101,
+			wantCode: transaction.LimitCodeTransferAmount,
+		},
+		{
+			name:     "Account too new",
+			limits:   transaction.Limits{MinAccountAge: 24 * time.Hour},
+			amount:   10,
+			age:      time.Hour,
+			wantCode: transaction.LimitCodeMinAccountAge,
+		},
+		{
+			name:     "Daily send limit reached",
+			limits:   transaction.Limits{MaxSentPerDay: 200},
+			amount:   50,
+			sent:     160,
+			wantCode: transaction.LimitCodeDailySent,
+		},
+		{
+			name:     "Weekly send limit reached",
+			limits:   transaction.Limits{MaxSentPerWeek: 500},
+			amount:   50,
+			sent:     460,
+			wantCode: transaction.LimitCodeWeeklySent,
+		},
+		{
+			name:     "Daily receive limit reached",
+			limits:   transaction.Limits{MaxReceivedPerDay: 300},
+			amount:   50,
+			received: 260,
+			wantCode: transaction.LimitCodeDailyReceived,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transferred := false
+			repo := &MockTransactionRepository{
+				GetBalanceFunc: func(ctx context.Context, username string) (int, error) {
+					return 1000, nil
+				},
+				GetUserByUsernameFunc: func(ctx context.Context, username string) (bool, error) {
+					return true, nil
+				},
+				GetUserCreatedAtFunc: func(ctx context.Context, username string) (time.Time, error) {
+					return time.Now().UTC().Add(-tt.age), nil
+				},
+				GetSentAmountSinceFunc: func(ctx context.Context, username string, since time.Time) (int, error) {
+					return tt.sent, nil
+				},
+				GetReceivedAmountSinceFunc: func(ctx context.Context, username string, since time.Time) (int, error) {
+					return tt.received, nil
+				},
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					transferred = true
+					return 1, nil
+				},
+			}
+
+			service := transaction.New(nil, repo, transaction.WithLimits(tt.limits))
+			_, err := service.SendCoins(context.Background(), "alice", "bob", tt.amount)
+
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if !transferred {
+					t.Error("expected transfer to be recorded")
+				}
+				return
+			}
+
+			var limitErr *transaction.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected LimitError, got %v", err)
+			}
+			if limitErr.Code != tt.wantCode {
+				t.Errorf("expected code %q, got %q", tt.wantCode, limitErr.Code)
+			}
+			if !errors.Is(err, transaction.ErrLimitExceeded) {
+				t.Error("expected error to match ErrLimitExceeded")
+			}
+			if transferred {
+				t.Error("expected transfer to be rejected")
+			}
+		})
+	}
+}
@@ -1,11 +1,35 @@
 package transaction
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
 
 type Repository interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	GetUserByUsername(ctx context.Context, username string) (bool, error)
-	CreateTransaction(ctx context.Context, senderUUID, receiverUUID string, amount int) error
+	CreateTransaction(ctx context.Context, senderUUID, receiverUUID string, amount int) (int64, error)
 	GetBalance(ctx context.Context, username string) (int, error)
+	GetUserCreatedAt(ctx context.Context, username string) (time.Time, error)
+	GetSentAmountSince(ctx context.Context, username string, since time.Time) (int, error)
+	GetReceivedAmountSince(ctx context.Context, username string, since time.Time) (int, error)
 	UpdateBalance(ctx context.Context, receiverUUID string, amount int) error
 	UpdateBalanceDeduct(ctx context.Context, senderUUID string, amount int) error
+
+	CreatePendingTransaction(ctx context.Context, senderUUID, receiverUUID string, amount int, expiresAt time.Time) (int64, error)
+	GetPendingTransaction(ctx context.Context, id int64) (models.PendingTransfer, error)
+	ListPendingTransactions(ctx context.Context, username string) ([]models.PendingTransfer, error)
+	ListExpiredPendingTransactions(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	ResolvePendingTransaction(ctx context.Context, id int64, status string) error
+	HoldPendingTransaction(ctx context.Context, id int64) error
+
+	CreateHeldTransaction(ctx context.Context, senderUUID, receiverUUID string, amount int) (int64, error)
+	SetTransactionFee(ctx context.Context, id int64, fee int) error
+
+	CreateTransferBatch(ctx context.Context, senderUUID string, total int, transactionIDs []int64) (int64, error)
+
+	GetTransaction(ctx context.Context, id int64) (models.Transaction, error)
+	CreateReversal(ctx context.Context, rev models.Reversal, from, to string) (models.Reversal, error)
 }
@@ -0,0 +1,96 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+var (
+	ErrNotReversible          = errors.New("transaction cannot be reversed")
+	ErrAlreadyReversed        = errors.New("transaction already reversed")
+	ErrRecipientFundsSpent    = errors.New("recipient has already spent the coins")
+	ErrReversalReasonRequired = errors.New("reversal reason is required")
+)
+
+// ReverseTransaction moves the coins of a completed transfer back from the
+// recipient to the sender with a compensating transaction; history is never
+// deleted. When the recipient no longer holds the full amount the reversal
+// fails, unless allowPartial is set, in which case whatever is left is
+// returned and the missing part is recorded as the shortfall.
+func (s *Service) ReverseTransaction(
+	ctx context.Context,
+	admin string,
+	id int64,
+	req models.ReverseTransactionRequest,
+) (models.Reversal, error) {
+	if req.Reason == "" {
+		return models.Reversal{}, ErrReversalReasonRequired
+	}
+
+	var reversal models.Reversal
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		orig, err := s.repo.GetTransaction(ctx, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrTransactionNotFound) {
+				return ErrTransferNotFound
+			}
+			return err
+		}
+		if orig.Status != models.TransactionStatusCompleted || orig.Kind != models.TransactionKindTransfer {
+			return ErrNotReversible
+		}
+
+		balance, err := s.repo.GetBalance(ctx, orig.ToUser)
+		if err != nil {
+			return err
+		}
+
+		amount := orig.Amount
+		if balance < amount {
+			if !req.AllowPartial || balance == 0 {
+				return ErrRecipientFundsSpent
+			}
+			amount = balance
+		}
+
+		if err := s.repo.UpdateBalanceDeduct(ctx, orig.ToUser, amount); err != nil {
+			return err
+		}
+		if err := s.repo.UpdateBalance(ctx, orig.FromUser, amount); err != nil {
+			return err
+		}
+
+		reversal, err = s.repo.CreateReversal(ctx, models.Reversal{
+			TransactionID: orig.ID,
+			Amount:        amount,
+			Shortfall:     orig.Amount - amount,
+			Reason:        req.Reason,
+			Admin:         admin,
+		}, orig.ToUser, orig.FromUser)
+		if err != nil {
+			if errors.Is(err, storage.ErrAlreadyReversed) {
+				return ErrAlreadyReversed
+			}
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.Reversal{}, err
+	}
+
+	s.logger.Info("Transaction reversed",
+		slog.Int64("transactionId", id),
+		slog.Int64("compensatingTransactionId", reversal.CompensatingTransactionID),
+		slog.Int("amount", reversal.Amount),
+		slog.Int("shortfall", reversal.Shortfall),
+		slog.String("admin", admin),
+		slog.String("reason", req.Reason))
+
+	return reversal, nil
+}
@@ -0,0 +1,136 @@
+package transaction_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	completed := models.Transaction{
+		ID: 5, FromUser: "alice", ToUser: "bob", Amount: 100,
+		Status: models.TransactionStatusCompleted, Kind: models.TransactionKindTransfer,
+	}
+
+	tests := []struct {
+		name              string
+		transaction       models.Transaction
+		recipientBalance  int
+		allowPartial      bool
+		reversalErr       error
+		expectedError     error
+		expectedAmount    int
+		expectedShortfall int
+	}{
+		{
+			name:             "Full",
+			transaction:      completed,
+			recipientBalance: 500,
+			expectedAmount:   100,
+		},
+		{
+			name:             "FundsSpent",
+			transaction:      completed,
+			recipientBalance: 30,
+			expectedError:    transaction.ErrRecipientFundsSpent,
+		},
+		{
+			name:              "Partial",
+			transaction:       completed,
+			recipientBalance:  30,
+			allowPartial:      true,
+			expectedAmount:    30,
+			expectedShortfall: 70,
+		},
+		{
+			name:             "PartialWithNothingLeft",
+			transaction:      completed,
+			recipientBalance: 0,
+			allowPartial:     true,
+			expectedError:    transaction.ErrRecipientFundsSpent,
+		},
+		{
+			name: "Pending",
+			transaction: models.Transaction{
+				ID: 5, FromUser: "alice", ToUser: "bob", Amount: 100,
+				Status: models.TransactionStatusPending, Kind: models.TransactionKindTransfer,
+			},
+			expectedError: transaction.ErrNotReversible,
+		},
+		{
+			name: "ReversalOfReversal",
+			transaction: models.Transaction{
+				ID: 5, FromUser: "alice", ToUser: "bob", Amount: 100,
+				Status: models.TransactionStatusCompleted, Kind: models.TransactionKindReversal,
+			},
+			expectedError: transaction.ErrNotReversible,
+		},
+		{
+			name:             "AlreadyReversed",
+			transaction:      completed,
+			recipientBalance: 500,
+			reversalErr:      storage.ErrAlreadyReversed,
+			expectedError:    transaction.ErrAlreadyReversed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deducted := map[string]int{}
+			credited := map[string]int{}
+			var recorded models.Reversal
+
+			mockRepo := &MockTransactionRepository{
+				GetTransactionFunc: func(ctx context.Context, id int64) (models.Transaction, error) {
+					return tt.transaction, nil
+				},
+				GetBalanceFunc: func(ctx context.Context, username string) (int, error) {
+					return tt.recipientBalance, nil
+				},
+				UpdateBalanceDeductFunc: func(ctx context.Context, username string, amount int) error {
+					deducted[username] += amount
+					return nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+					credited[username] += amount
+					return nil
+				},
+				CreateReversalFunc: func(ctx context.Context, rev models.Reversal, from, to string) (models.Reversal, error) {
+					if from != "bob" || to != "alice" {
+						t.Fatalf("unexpected compensating transaction %s -> %s", from, to)
+					}
+					recorded = rev
+					return rev, tt.reversalErr
+				},
+			}
+
+			service := transaction.New(slog.New(slog.NewTextHandler(io.Discard, nil)), mockRepo)
+			rev, err := service.ReverseTransaction(context.Background(), "admin", 5, models.ReverseTransactionRequest{
+				Reason:       "sent to the wrong person",
+				AllowPartial: tt.allowPartial,
+			})
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if rev.Amount != tt.expectedAmount || rev.Shortfall != tt.expectedShortfall {
+				t.Fatalf("expected amount %d shortfall %d, got %d and %d",
+					tt.expectedAmount, tt.expectedShortfall, rev.Amount, rev.Shortfall)
+			}
+			if deducted["bob"] != tt.expectedAmount || credited["alice"] != tt.expectedAmount {
+				t.Fatalf("expected %d coins moved from bob to alice, got %v and %v", tt.expectedAmount, deducted, credited)
+			}
+			if recorded.Admin != "admin" || recorded.Reason == "" || recorded.TransactionID != 5 {
+				t.Fatalf("expected admin and reason to be recorded, got %+v", recorded)
+			}
+		})
+	}
+}
@@ -4,83 +4,446 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/nglmq/avito-shop/internal/storage"
 	"log/slog"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/app/outbox"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+const (
+	DefaultPendingTTL = 72 * time.Hour
+
+	expiryBatchSize = 100
 )
 
 var (
 	ErrInsufficientBalance = errors.New("insufficient balance")
 	ErrInvalidAmount       = errors.New("invalid amount")
 	ErrInvalidRecipient    = errors.New("invalid recipient")
+	ErrTransferNotFound    = errors.New("transfer not found")
+	ErrTransferNotPending  = errors.New("transfer is not pending")
+	ErrTransferExpired     = errors.New("transfer has expired")
 )
 
 type Service struct {
-	logger *slog.Logger
-	repo   Repository
+	logger     *slog.Logger
+	repo       Repository
+	pendingTTL time.Duration
+	limits     Limits
+	fraud      FraudChecker
+	fees       FeePolicy
+	events     outbox.Publisher
+	auditor    audit.Auditor
+}
+
+type Option func(*Service)
+
+// WithPendingTTL sets how long a pending transfer waits for the recipient
+// before it expires and the held coins are returned to the sender.
+func WithPendingTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.pendingTTL = ttl
+	}
 }
 
-func New(logger *slog.Logger, repo Repository) *Service {
-	return &Service{
-		logger: logger,
-		repo:   repo,
+func New(logger *slog.Logger, repo Repository, opts ...Option) *Service {
+	s := &Service{
+		logger:     logger,
+		repo:       repo,
+		pendingTTL: DefaultPendingTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
+}
+
+// SendCoins transfers amount coins and returns the receipt of the recorded
+// transaction. A transfer held for fraud review returns its receipt along
+// with ErrTransferHeld.
+func (s *Service) SendCoins(ctx context.Context, from, to string, amount int) (models.TransferReceipt, error) {
+	receipt, held, err := s.transfer(ctx, from, to, amount)
+	audit.Record(ctx, s.auditor, from, models.AuditActionSend, map[string]any{
+		"toUser": to,
+		"amount": amount,
+		"fee":    receipt.Fee,
+		"held":   held,
+	}, err)
+	if err != nil {
+		return models.TransferReceipt{}, err
+	}
+	if held {
+		return receipt, ErrTransferHeld
+	}
+
+	return receipt, nil
 }
 
-func (s *Service) SendCoins(ctx context.Context, from, to string, amount int) error {
+// Transfer atomically moves amount coins from one user to another and returns
+// the id of the recorded transaction. It joins the caller's database
+// transaction when ctx carries one. A transfer held for fraud review is not
+// an error here: the sender has been charged and the transaction recorded.
+// The sender pays the transfer fee on top of amount; the fee of a held
+// transfer is held along with it until the transfer is reviewed.
+// Transfer runs inside another action, so it leaves recording the action in
+// the audit log to its caller.
+func (s *Service) Transfer(ctx context.Context, from, to string, amount int) (int64, error) {
+	receipt, _, err := s.transfer(ctx, from, to, amount)
+	return receipt.TransactionID, err
+}
+
+func (s *Service) transfer(ctx context.Context, from, to string, amount int) (models.TransferReceipt, bool, error) {
 	if from == to {
-		return ErrInvalidRecipient
+		return models.TransferReceipt{}, false, ErrInvalidRecipient
 	}
 	if amount <= 0 {
-		return ErrInvalidAmount
+		return models.TransferReceipt{}, false, ErrInvalidAmount
 	}
 
-	senderBalance, err := s.repo.GetBalance(ctx, from)
-	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
+	var (
+		id   int64
+		held bool
+	)
+	fee := s.fee(from, to, amount)
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.checkTransfer(ctx, from, to, amount, fee); err != nil {
 			return err
 		}
-		return fmt.Errorf("error fetching sender balance: %w", err)
-	}
-	if senderBalance < amount {
-		return ErrInsufficientBalance
-	}
 
-	exists, err := s.repo.GetUserByUsername(ctx, to)
+		verdict, err := s.screen(ctx, from, to, amount)
+		if err != nil {
+			return err
+		}
+		held = verdict.Action == models.FraudActionHold
+
+		err = s.repo.UpdateBalanceDeduct(ctx, from, amount+fee)
+		if err != nil {
+			s.logger.Error("Error deducting balance",
+				slog.String("username", from),
+				slog.Int("amount", amount+fee),
+				slog.String("error", err.Error()))
+			return err
+		}
+
+		if held {
+			id, err = s.repo.CreateHeldTransaction(ctx, from, to, amount)
+		} else {
+			err = s.repo.UpdateBalance(ctx, to, amount)
+			if err != nil {
+				s.logger.Error("Error updating balance",
+					slog.String("username", to),
+					slog.Int("amount", amount),
+					slog.String("error", err.Error()))
+				return err
+			}
+
+			id, err = s.repo.CreateTransaction(ctx, from, to, amount)
+		}
+		if err != nil {
+			s.logger.Error("Error creating transaction",
+				slog.String("from", from),
+				slog.String("to", to),
+				slog.Int("amount", amount),
+				slog.String("error", err.Error()))
+			return err
+		}
+
+		if held {
+			err = s.recordFee(ctx, id, fee)
+		} else {
+			err = s.collectFee(ctx, id, fee)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !held {
+			err := outbox.Notify(ctx, s.events, to, models.EventCoinsReceived, models.CoinsReceivedEvent{
+				TransactionID: id,
+				FromUser:      from,
+				Amount:        amount,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if verdict.Action != "" {
+			return s.fraud.RecordFlag(ctx, id, verdict)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return storage.ErrUserNotFound
+		return models.TransferReceipt{}, false, err
 	}
 
-	if !exists {
-		return storage.ErrUserNotFound
+	return models.TransferReceipt{
+		TransactionID: id,
+		ToUser:        to,
+		Amount:        amount,
+		Fee:           fee,
+		Total:         amount + fee,
+	}, held, nil
+}
+
+// CreatePendingTransfer holds amount and the fee on the sender's balance until
+// the recipient accepts or declines the transfer, or it expires. The fee is
+// only collected if the transfer is accepted.
+func (s *Service) CreatePendingTransfer(ctx context.Context, from, to string, amount int) (models.PendingTransferResponse, error) {
+	resp, err := s.createPendingTransfer(ctx, from, to, amount)
+	audit.Record(ctx, s.auditor, from, models.AuditActionSend, map[string]any{"toUser": to, "amount": amount, "pending": true}, err)
+
+	return resp, err
+}
+
+func (s *Service) createPendingTransfer(ctx context.Context, from, to string, amount int) (models.PendingTransferResponse, error) {
+	if from == to {
+		return models.PendingTransferResponse{}, ErrInvalidRecipient
 	}
+	if amount <= 0 {
+		return models.PendingTransferResponse{}, ErrInvalidAmount
+	}
+
+	resp := models.PendingTransferResponse{
+		Fee:       s.fee(from, to, amount),
+		ExpiresAt: time.Now().Add(s.pendingTTL).UTC(),
+	}
+
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.checkTransfer(ctx, from, to, amount, resp.Fee); err != nil {
+			return err
+		}
+
+		if err := s.repo.UpdateBalanceDeduct(ctx, from, amount+resp.Fee); err != nil {
+			s.logger.Error("Error holding balance",
+				slog.String("username", from),
+				slog.Int("amount", amount+resp.Fee),
+				slog.String("error", err.Error()))
+			return err
+		}
+
+		id, err := s.repo.CreatePendingTransaction(ctx, from, to, amount, resp.ExpiresAt)
+		if err != nil {
+			s.logger.Error("Error creating pending transaction",
+				slog.String("from", from),
+				slog.String("to", to),
+				slog.Int("amount", amount),
+				slog.String("error", err.Error()))
+			return err
+		}
+		resp.ID = id
+
+		if err := s.recordFee(ctx, id, resp.Fee); err != nil {
+			return err
+		}
 
-	err = s.repo.UpdateBalanceDeduct(ctx, from, amount)
+		return outbox.Notify(ctx, s.events, to, models.EventTransferPending, models.PendingTransfer{
+			ID:        id,
+			FromUser:  from,
+			ToUser:    to,
+			Amount:    amount,
+			Status:    models.TransactionStatusPending,
+			CreatedAt: time.Now().UTC(),
+			ExpiresAt: resp.ExpiresAt,
+		})
+	})
 	if err != nil {
-		s.logger.Error("Error deducting balance",
-			slog.String("username", from),
-			slog.Int("amount", amount),
-			slog.String("error", err.Error()))
-		return err
+		return models.PendingTransferResponse{}, err
 	}
 
-	err = s.repo.UpdateBalance(ctx, to, amount)
+	return resp, nil
+}
+
+func (s *Service) ListPendingTransfers(ctx context.Context, username string) (models.PendingTransfersResponse, error) {
+	transfers, err := s.repo.ListPendingTransactions(ctx, username)
 	if err != nil {
-		s.logger.Error("Error updating balance",
-			slog.String("username", to),
-			slog.Int("amount", amount),
+		s.logger.Error("Error listing pending transfers",
+			slog.String("username", username),
 			slog.String("error", err.Error()))
-		return err
+		return models.PendingTransfersResponse{}, err
+	}
+
+	resp := models.PendingTransfersResponse{
+		Incoming: []models.PendingTransfer{},
+		Outgoing: []models.PendingTransfer{},
+	}
+	for _, t := range transfers {
+		if t.ToUser == username {
+			resp.Incoming = append(resp.Incoming, t)
+		} else {
+			resp.Outgoing = append(resp.Outgoing, t)
+		}
 	}
 
-	err = s.repo.CreateTransaction(ctx, from, to, amount)
+	return resp, nil
+}
+
+// AcceptPendingTransfer credits the held coins to the recipient. The transfer
+// is screened for fraud at this point, since this is when coins move; one
+// that is held returns ErrTransferHeld and waits for an admin instead.
+func (s *Service) AcceptPendingTransfer(ctx context.Context, username string, id int64) error {
+	held, err := s.resolvePending(ctx, username, id, models.TransactionStatusCompleted)
+	audit.Record(ctx, s.auditor, username, models.AuditActionTransferAccept, map[string]any{"id": id, "held": held}, err)
 	if err != nil {
-		s.logger.Error("Error creating transaction",
-			slog.String("from", from),
-			slog.String("to", to),
-			slog.Int("amount", amount),
-			slog.String("error", err.Error()))
 		return err
 	}
+	if held {
+		return ErrTransferHeld
+	}
 
 	return nil
 }
+
+// DeclinePendingTransfer returns the held coins to the sender.
+func (s *Service) DeclinePendingTransfer(ctx context.Context, username string, id int64) error {
+	_, err := s.resolvePending(ctx, username, id, models.TransactionStatusDeclined)
+	audit.Record(ctx, s.auditor, username, models.AuditActionTransferDecline, map[string]any{"id": id}, err)
+
+	return err
+}
+
+// ExpirePendingTransfers refunds every pending transfer whose deadline has
+// passed and returns how many were expired.
+func (s *Service) ExpirePendingTransfers(ctx context.Context) (int, error) {
+	ids, err := s.repo.ListExpiredPendingTransactions(ctx, time.Now().UTC(), expiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		refunded := false
+		err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+			t, err := s.repo.GetPendingTransaction(ctx, id)
+			if err != nil {
+				return err
+			}
+			if t.Status != models.TransactionStatusPending {
+				return nil
+			}
+
+			if err := s.repo.UpdateBalance(ctx, t.FromUser, t.Amount+t.Fee); err != nil {
+				return fmt.Errorf("error refunding sender: %w", err)
+			}
+			refunded = true
+
+			return s.repo.ResolvePendingTransaction(ctx, id, models.TransactionStatusExpired)
+		})
+		if err != nil {
+			s.logger.Error("Error expiring pending transfer",
+				slog.Int64("id", id),
+				slog.String("error", err.Error()))
+			continue
+		}
+		if refunded {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+func (s *Service) resolvePending(ctx context.Context, username string, id int64, status string) (bool, error) {
+	var held bool
+	err := s.repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		t, err := s.repo.GetPendingTransaction(ctx, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrTransactionNotFound) {
+				return ErrTransferNotFound
+			}
+			return err
+		}
+
+		if t.ToUser != username {
+			return ErrTransferNotFound
+		}
+		if t.Status != models.TransactionStatusPending {
+			return ErrTransferNotPending
+		}
+		if !time.Now().Before(t.ExpiresAt) {
+			return ErrTransferExpired
+		}
+
+		var verdict models.FraudVerdict
+		if status == models.TransactionStatusCompleted {
+			verdict, err = s.screen(ctx, t.FromUser, t.ToUser, t.Amount)
+			if err != nil {
+				return err
+			}
+			if verdict.Action == models.FraudActionHold {
+				held = true
+				if err := s.repo.HoldPendingTransaction(ctx, id); err != nil {
+					return err
+				}
+				return s.fraud.RecordFlag(ctx, id, verdict)
+			}
+		}
+
+		payee, amount := t.ToUser, t.Amount
+		if status != models.TransactionStatusCompleted {
+			payee, amount = t.FromUser, t.Amount+t.Fee
+		}
+
+		if err := s.repo.UpdateBalance(ctx, payee, amount); err != nil {
+			s.logger.Error("Error releasing held coins",
+				slog.Int64("id", id),
+				slog.String("username", payee),
+				slog.String("error", err.Error()))
+			return err
+		}
+		if status == models.TransactionStatusCompleted {
+			if err := s.creditFee(ctx, t.Fee); err != nil {
+				return err
+			}
+			err := outbox.Notify(ctx, s.events, t.ToUser, models.EventCoinsReceived, models.CoinsReceivedEvent{
+				TransactionID: t.ID,
+				FromUser:      t.FromUser,
+				Amount:        t.Amount,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.ResolvePendingTransaction(ctx, id, status); err != nil {
+			return err
+		}
+
+		if verdict.Action != "" {
+			return s.fraud.RecordFlag(ctx, id, verdict)
+		}
+
+		return nil
+	})
+
+	return held, err
+}
+
+func (s *Service) checkTransfer(ctx context.Context, from, to string, amount, fee int) error {
+	senderBalance, err := s.repo.GetBalance(ctx, from)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return err
+		}
+		return fmt.Errorf("error fetching sender balance: %w", err)
+	}
+	if senderBalance < amount+fee {
+		return ErrInsufficientBalance
+	}
+
+	exists, err := s.repo.GetUserByUsername(ctx, to)
+	if err != nil {
+		return storage.ErrUserNotFound
+	}
+
+	if !exists {
+		return storage.ErrUserNotFound
+	}
+
+	return s.checkLimits(ctx, from, to, amount)
+}
@@ -1,7 +1,19 @@
 package transaction
 
-import "context"
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
 
 type ServiceInterface interface {
-	SendCoins(ctx context.Context, from, to string, amount int) error
+	SendCoins(ctx context.Context, from, to string, amount int) (models.TransferReceipt, error)
+	Transfer(ctx context.Context, from, to string, amount int) (int64, error)
+	QuoteTransfer(ctx context.Context, from, to string, amount int) (models.TransferQuote, error)
+	SendBatch(ctx context.Context, from string, req models.BatchTransferRequest) (models.BatchTransferResponse, error)
+	CreatePendingTransfer(ctx context.Context, from, to string, amount int) (models.PendingTransferResponse, error)
+	ListPendingTransfers(ctx context.Context, username string) (models.PendingTransfersResponse, error)
+	AcceptPendingTransfer(ctx context.Context, username string, id int64) error
+	DeclinePendingTransfer(ctx context.Context, username string, id int64) error
+	ReverseTransaction(ctx context.Context, admin string, id int64, req models.ReverseTransactionRequest) (models.Reversal, error)
 }
@@ -1,14 +1,82 @@
 package transaction
 
-import "context"
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
 
 type ServiceMock struct {
-	SendCoinsFunc func(ctx context.Context, fromUser, toUser string, amount int) error
+	SendCoinsFunc              func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferReceipt, error)
+	TransferFunc               func(ctx context.Context, fromUser, toUser string, amount int) (int64, error)
+	QuoteTransferFunc          func(ctx context.Context, fromUser, toUser string, amount int) (models.TransferQuote, error)
+	SendBatchFunc              func(ctx context.Context, fromUser string, req models.BatchTransferRequest) (models.BatchTransferResponse, error)
+	CreatePendingTransferFunc  func(ctx context.Context, fromUser, toUser string, amount int) (models.PendingTransferResponse, error)
+	ListPendingTransfersFunc   func(ctx context.Context, username string) (models.PendingTransfersResponse, error)
+	AcceptPendingTransferFunc  func(ctx context.Context, username string, id int64) error
+	DeclinePendingTransferFunc func(ctx context.Context, username string, id int64) error
+	ReverseTransactionFunc     func(ctx context.Context, admin string, id int64, req models.ReverseTransactionRequest) (models.Reversal, error)
 }
 
-func (m *ServiceMock) SendCoins(ctx context.Context, fromUser, toUser string, amount int) error {
+func (m *ServiceMock) SendCoins(ctx context.Context, fromUser, toUser string, amount int) (models.TransferReceipt, error) {
 	if m.SendCoinsFunc != nil {
 		return m.SendCoinsFunc(ctx, fromUser, toUser, amount)
 	}
+	return models.TransferReceipt{}, nil
+}
+
+func (m *ServiceMock) Transfer(ctx context.Context, fromUser, toUser string, amount int) (int64, error) {
+	if m.TransferFunc != nil {
+		return m.TransferFunc(ctx, fromUser, toUser, amount)
+	}
+	return 0, nil
+}
+
+func (m *ServiceMock) QuoteTransfer(ctx context.Context, fromUser, toUser string, amount int) (models.TransferQuote, error) {
+	if m.QuoteTransferFunc != nil {
+		return m.QuoteTransferFunc(ctx, fromUser, toUser, amount)
+	}
+	return models.TransferQuote{}, nil
+}
+
+func (m *ServiceMock) SendBatch(ctx context.Context, fromUser string, req models.BatchTransferRequest) (models.BatchTransferResponse, error) {
+	if m.SendBatchFunc != nil {
+		return m.SendBatchFunc(ctx, fromUser, req)
+	}
+	return models.BatchTransferResponse{}, nil
+}
+
+func (m *ServiceMock) CreatePendingTransfer(ctx context.Context, fromUser, toUser string, amount int) (models.PendingTransferResponse, error) {
+	if m.CreatePendingTransferFunc != nil {
+		return m.CreatePendingTransferFunc(ctx, fromUser, toUser, amount)
+	}
+	return models.PendingTransferResponse{}, nil
+}
+
+func (m *ServiceMock) ListPendingTransfers(ctx context.Context, username string) (models.PendingTransfersResponse, error) {
+	if m.ListPendingTransfersFunc != nil {
+		return m.ListPendingTransfersFunc(ctx, username)
+	}
+	return models.PendingTransfersResponse{}, nil
+}
+
+func (m *ServiceMock) AcceptPendingTransfer(ctx context.Context, username string, id int64) error {
+	if m.AcceptPendingTransferFunc != nil {
+		return m.AcceptPendingTransferFunc(ctx, username, id)
+	}
 	return nil
 }
+
+func (m *ServiceMock) DeclinePendingTransfer(ctx context.Context, username string, id int64) error {
+	if m.DeclinePendingTransferFunc != nil {
+		return m.DeclinePendingTransferFunc(ctx, username, id)
+	}
+	return nil
+}
+
+func (m *ServiceMock) ReverseTransaction(ctx context.Context, admin string, id int64, req models.ReverseTransactionRequest) (models.Reversal, error) {
+	if m.ReverseTransactionFunc != nil {
+		return m.ReverseTransactionFunc(ctx, admin, id, req)
+	}
+	return models.Reversal{}, nil
+}
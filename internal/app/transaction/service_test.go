@@ -4,8 +4,12 @@ import (
 	"context"
 	"errors"
 	"github.com/nglmq/avito-shop/internal/app/transaction"
+	"github.com/nglmq/avito-shop/internal/models"
 	"github.com/nglmq/avito-shop/internal/storage"
+	"io"
+	"log/slog"
 	"testing"
+	"time"
 )
 
 type MockTransactionRepository struct {
@@ -13,7 +17,28 @@ type MockTransactionRepository struct {
 	GetUserByUsernameFunc   func(ctx context.Context, username string) (bool, error)
 	UpdateBalanceDeductFunc func(ctx context.Context, username string, amount int) error
 	UpdateBalanceFunc       func(ctx context.Context, username string, amount int) error
-	CreateTransactionFunc   func(ctx context.Context, from, to string, amount int) error
+	CreateTransactionFunc   func(ctx context.Context, from, to string, amount int) (int64, error)
+
+	CreatePendingTransactionFunc       func(ctx context.Context, from, to string, amount int, expiresAt time.Time) (int64, error)
+	GetPendingTransactionFunc          func(ctx context.Context, id int64) (models.PendingTransfer, error)
+	ListPendingTransactionsFunc        func(ctx context.Context, username string) ([]models.PendingTransfer, error)
+	ListExpiredPendingTransactionsFunc func(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	ResolvePendingTransactionFunc      func(ctx context.Context, id int64, status string) error
+	HoldPendingTransactionFunc         func(ctx context.Context, id int64) error
+	CreateTransferBatchFunc            func(ctx context.Context, from string, total int, ids []int64) (int64, error)
+	GetTransactionFunc                 func(ctx context.Context, id int64) (models.Transaction, error)
+	CreateReversalFunc                 func(ctx context.Context, rev models.Reversal, from, to string) (models.Reversal, error)
+
+	GetUserCreatedAtFunc       func(ctx context.Context, username string) (time.Time, error)
+	GetSentAmountSinceFunc     func(ctx context.Context, username string, since time.Time) (int, error)
+	GetReceivedAmountSinceFunc func(ctx context.Context, username string, since time.Time) (int, error)
+
+	CreateHeldTransactionFunc func(ctx context.Context, from, to string, amount int) (int64, error)
+	SetTransactionFeeFunc     func(ctx context.Context, id int64, fee int) error
+}
+
+func (m *MockTransactionRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
 }
 
 func (m *MockTransactionRepository) GetBalance(ctx context.Context, username string) (int, error) {
@@ -44,10 +69,108 @@ func (m *MockTransactionRepository) UpdateBalance(ctx context.Context, username
 	return nil
 }
 
-func (m *MockTransactionRepository) CreateTransaction(ctx context.Context, from, to string, amount int) error {
+func (m *MockTransactionRepository) CreateTransaction(ctx context.Context, from, to string, amount int) (int64, error) {
 	if m.CreateTransactionFunc != nil {
 		return m.CreateTransactionFunc(ctx, from, to, amount)
 	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepository) CreatePendingTransaction(ctx context.Context, from, to string, amount int, expiresAt time.Time) (int64, error) {
+	if m.CreatePendingTransactionFunc != nil {
+		return m.CreatePendingTransactionFunc(ctx, from, to, amount, expiresAt)
+	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepository) GetPendingTransaction(ctx context.Context, id int64) (models.PendingTransfer, error) {
+	if m.GetPendingTransactionFunc != nil {
+		return m.GetPendingTransactionFunc(ctx, id)
+	}
+	return models.PendingTransfer{}, storage.ErrTransactionNotFound
+}
+
+func (m *MockTransactionRepository) ListPendingTransactions(ctx context.Context, username string) ([]models.PendingTransfer, error) {
+	if m.ListPendingTransactionsFunc != nil {
+		return m.ListPendingTransactionsFunc(ctx, username)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepository) ListExpiredPendingTransactions(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	if m.ListExpiredPendingTransactionsFunc != nil {
+		return m.ListExpiredPendingTransactionsFunc(ctx, now, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepository) HoldPendingTransaction(ctx context.Context, id int64) error {
+	if m.HoldPendingTransactionFunc != nil {
+		return m.HoldPendingTransactionFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockTransactionRepository) ResolvePendingTransaction(ctx context.Context, id int64, status string) error {
+	if m.ResolvePendingTransactionFunc != nil {
+		return m.ResolvePendingTransactionFunc(ctx, id, status)
+	}
+	return nil
+}
+
+func (m *MockTransactionRepository) CreateTransferBatch(ctx context.Context, from string, total int, ids []int64) (int64, error) {
+	if m.CreateTransferBatchFunc != nil {
+		return m.CreateTransferBatchFunc(ctx, from, total, ids)
+	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepository) GetTransaction(ctx context.Context, id int64) (models.Transaction, error) {
+	if m.GetTransactionFunc != nil {
+		return m.GetTransactionFunc(ctx, id)
+	}
+	return models.Transaction{}, storage.ErrTransactionNotFound
+}
+
+func (m *MockTransactionRepository) CreateReversal(ctx context.Context, rev models.Reversal, from, to string) (models.Reversal, error) {
+	if m.CreateReversalFunc != nil {
+		return m.CreateReversalFunc(ctx, rev, from, to)
+	}
+	return rev, nil
+}
+
+func (m *MockTransactionRepository) GetUserCreatedAt(ctx context.Context, username string) (time.Time, error) {
+	if m.GetUserCreatedAtFunc != nil {
+		return m.GetUserCreatedAtFunc(ctx, username)
+	}
+	return time.Time{}, nil
+}
+
+func (m *MockTransactionRepository) GetSentAmountSince(ctx context.Context, username string, since time.Time) (int, error) {
+	if m.GetSentAmountSinceFunc != nil {
+		return m.GetSentAmountSinceFunc(ctx, username, since)
+	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepository) GetReceivedAmountSince(ctx context.Context, username string, since time.Time) (int, error) {
+	if m.GetReceivedAmountSinceFunc != nil {
+		return m.GetReceivedAmountSinceFunc(ctx, username, since)
+	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepository) CreateHeldTransaction(ctx context.Context, from, to string, amount int) (int64, error) {
+	if m.CreateHeldTransactionFunc != nil {
+		return m.CreateHeldTransactionFunc(ctx, from, to, amount)
+	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepository) SetTransactionFee(ctx context.Context, id int64, fee int) error {
+	if m.SetTransactionFeeFunc != nil {
+		return m.SetTransactionFeeFunc(ctx, id, fee)
+	}
 	return nil
 }
 
@@ -78,8 +201,8 @@ func TestSendCoins(t *testing.T) {
 				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
 					return nil
 				},
-				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) error {
-					return nil
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
 				},
 			},
 			expectedError: nil,
@@ -102,8 +225,8 @@ func TestSendCoins(t *testing.T) {
 				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
 					return nil
 				},
-				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) error {
-					return nil
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
 				},
 			},
 			expectedError: transaction.ErrInsufficientBalance,
@@ -126,8 +249,8 @@ func TestSendCoins(t *testing.T) {
 				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
 					return nil
 				},
-				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) error {
-					return nil
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
 				},
 			},
 			expectedError: storage.ErrUserNotFound,
@@ -150,8 +273,8 @@ func TestSendCoins(t *testing.T) {
 				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
 					return nil
 				},
-				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) error {
-					return nil
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
 				},
 			},
 			expectedError: errors.New("error fetching sender balance: database error"),
@@ -174,8 +297,8 @@ func TestSendCoins(t *testing.T) {
 				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
 					return nil
 				},
-				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) error {
-					return nil
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
 				},
 			},
 			expectedError: transaction.ErrInvalidAmount,
@@ -198,8 +321,8 @@ func TestSendCoins(t *testing.T) {
 				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
 					return nil
 				},
-				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) error {
-					return nil
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
 				},
 			},
 			expectedError: transaction.ErrInvalidAmount,
@@ -222,8 +345,8 @@ func TestSendCoins(t *testing.T) {
 				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
 					return nil
 				},
-				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) error {
-					return nil
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
 				},
 			},
 			expectedError: transaction.ErrInvalidRecipient,
@@ -246,8 +369,8 @@ func TestSendCoins(t *testing.T) {
 				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
 					return nil
 				},
-				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) error {
-					return nil
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
 				},
 			},
 			expectedError: transaction.ErrInsufficientBalance,
@@ -270,8 +393,8 @@ func TestSendCoins(t *testing.T) {
 				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
 					return nil
 				},
-				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) error {
-					return nil
+				CreateTransactionFunc: func(ctx context.Context, from, to string, amount int) (int64, error) {
+					return 1, nil
 				},
 			},
 			expectedError: nil,
@@ -281,7 +404,7 @@ func TestSendCoins(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			service := transaction.New(nil, tt.mockRepo)
-			err := service.SendCoins(context.Background(), tt.from, tt.to, tt.amount)
+			_, err := service.SendCoins(context.Background(), tt.from, tt.to, tt.amount)
 			if err == nil && tt.expectedError != nil {
 				t.Fatalf("expected error %v, got nil", tt.expectedError)
 			}
@@ -294,3 +417,191 @@ func TestSendCoins(t *testing.T) {
 		})
 	}
 }
+
+func pendingTransfer(status string, expiresIn time.Duration) models.PendingTransfer {
+	return models.PendingTransfer{
+		ID:        1,
+		FromUser:  "user1",
+		ToUser:    "user2",
+		Amount:    100,
+		Status:    status,
+		ExpiresAt: time.Now().Add(expiresIn),
+	}
+}
+
+func TestCreatePendingTransfer(t *testing.T) {
+	var heldAmount int
+	var expiresAt time.Time
+
+	mockRepo := &MockTransactionRepository{
+		GetBalanceFunc: func(ctx context.Context, username string) (int, error) {
+			return 1000, nil
+		},
+		GetUserByUsernameFunc: func(ctx context.Context, username string) (bool, error) {
+			return true, nil
+		},
+		UpdateBalanceDeductFunc: func(ctx context.Context, username string, amount int) error {
+			heldAmount = amount
+			return nil
+		},
+		UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+			t.Fatalf("recipient must not be credited before acceptance")
+			return nil
+		},
+		CreatePendingTransactionFunc: func(ctx context.Context, from, to string, amount int, exp time.Time) (int64, error) {
+			expiresAt = exp
+			return 42, nil
+		},
+	}
+
+	service := transaction.New(nil, mockRepo, transaction.WithPendingTTL(time.Hour))
+	resp, err := service.CreatePendingTransfer(context.Background(), "user1", "user2", 100)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.ID != 42 {
+		t.Fatalf("expected id 42, got %d", resp.ID)
+	}
+	if heldAmount != 100 {
+		t.Fatalf("expected 100 coins to be held, got %d", heldAmount)
+	}
+	if d := time.Until(expiresAt); d <= 0 || d > time.Hour {
+		t.Fatalf("unexpected expiry %v", expiresAt)
+	}
+}
+
+func TestResolvePendingTransfer(t *testing.T) {
+	tests := []struct {
+		name           string
+		accept         bool
+		username       string
+		transfer       models.PendingTransfer
+		expectedPayee  string
+		expectedStatus string
+		expectedError  error
+	}{
+		{
+			name:           "Accept",
+			accept:         true,
+			username:       "user2",
+			transfer:       pendingTransfer(models.TransactionStatusPending, time.Hour),
+			expectedPayee:  "user2",
+			expectedStatus: models.TransactionStatusCompleted,
+		},
+		{
+			name:           "Decline",
+			username:       "user2",
+			transfer:       pendingTransfer(models.TransactionStatusPending, time.Hour),
+			expectedPayee:  "user1",
+			expectedStatus: models.TransactionStatusDeclined,
+		},
+		{
+			name:          "NotRecipient",
+			accept:        true,
+			username:      "user1",
+			transfer:      pendingTransfer(models.TransactionStatusPending, time.Hour),
+			expectedError: transaction.ErrTransferNotFound,
+		},
+		{
+			name:          "AlreadyResolved",
+			accept:        true,
+			username:      "user2",
+			transfer:      pendingTransfer(models.TransactionStatusDeclined, time.Hour),
+			expectedError: transaction.ErrTransferNotPending,
+		},
+		{
+			name:          "Expired",
+			accept:        true,
+			username:      "user2",
+			transfer:      pendingTransfer(models.TransactionStatusPending, -time.Minute),
+			expectedError: transaction.ErrTransferExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payee, status string
+			mockRepo := &MockTransactionRepository{
+				GetPendingTransactionFunc: func(ctx context.Context, id int64) (models.PendingTransfer, error) {
+					return tt.transfer, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+					payee = username
+					return nil
+				},
+				ResolvePendingTransactionFunc: func(ctx context.Context, id int64, s string) error {
+					status = s
+					return nil
+				},
+			}
+
+			auditor := &recordingAuditor{}
+			service := transaction.New(nil, mockRepo, transaction.WithAuditor(auditor))
+			var err error
+			action := models.AuditActionTransferDecline
+			if tt.accept {
+				action = models.AuditActionTransferAccept
+				err = service.AcceptPendingTransfer(context.Background(), tt.username, 1)
+			} else {
+				err = service.DeclinePendingTransfer(context.Background(), tt.username, 1)
+			}
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if len(auditor.actions) != 1 || auditor.actions[0] != tt.username+":"+action || !errors.Is(auditor.outcomes[0], tt.expectedError) {
+				t.Fatalf("unexpected audit entries %v %v", auditor.actions, auditor.outcomes)
+			}
+			if payee != tt.expectedPayee {
+				t.Fatalf("expected payee %q, got %q", tt.expectedPayee, payee)
+			}
+			if status != tt.expectedStatus {
+				t.Fatalf("expected status %q, got %q", tt.expectedStatus, status)
+			}
+		})
+	}
+}
+
+type recordingAuditor struct {
+	actions  []string
+	outcomes []error
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, actor, action string, params any, err error) {
+	a.actions = append(a.actions, actor+":"+action)
+	a.outcomes = append(a.outcomes, err)
+}
+
+func TestExpirePendingTransfers(t *testing.T) {
+	transfers := map[int64]models.PendingTransfer{
+		1: pendingTransfer(models.TransactionStatusPending, -time.Minute),
+		2: pendingTransfer(models.TransactionStatusCompleted, -time.Minute),
+	}
+	refunds := map[string]int{}
+
+	mockRepo := &MockTransactionRepository{
+		ListExpiredPendingTransactionsFunc: func(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+			return []int64{1, 2}, nil
+		},
+		GetPendingTransactionFunc: func(ctx context.Context, id int64) (models.PendingTransfer, error) {
+			return transfers[id], nil
+		},
+		UpdateBalanceFunc: func(ctx context.Context, username string, amount int) error {
+			refunds[username] += amount
+			return nil
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := transaction.New(logger, mockRepo)
+
+	expired, err := service.ExpirePendingTransfers(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expired != 1 {
+		t.Fatalf("expected 1 expired transfer, got %d", expired)
+	}
+	if refunds["user1"] != 100 {
+		t.Fatalf("expected sender to be refunded 100, got %d", refunds["user1"])
+	}
+}
@@ -0,0 +1,20 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type Repository interface {
+	CreateWebhook(ctx context.Context, w models.Webhook) (models.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	DeactivateWebhook(ctx context.Context, id int64) error
+
+	EnqueueWebhookDeliveries(ctx context.Context, eventType string, payload []byte, now time.Time) (int64, error)
+	ClaimDueWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, d models.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error)
+}
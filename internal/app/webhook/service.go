@@ -0,0 +1,309 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+const (
+	DefaultMaxAttempts = 8
+	DefaultRetryBase   = 30 * time.Second
+	DefaultTimeout     = 10 * time.Second
+	MaxRetryDelay      = time.Hour
+
+	DefaultDeliveriesLimit = 50
+	MaxDeliveriesLimit     = 200
+
+	deliveryBatchSize = 50
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrInvalidEventType = errors.New("invalid event type")
+)
+
+// SupportedEvents lists the event types a webhook can subscribe to.
+var SupportedEvents = []string{
+	models.EventCoinsReceived,
+	models.EventPurchaseCompleted,
+	models.EventTransferPending,
+	models.EventPaymentRequestCreated,
+}
+
+// Service queues shop events for admin-registered webhooks and delivers them
+// as signed JSON POSTs. Deliveries live in the database and are retried with
+// exponential backoff until they succeed or run out of attempts.
+type Service struct {
+	logger      *slog.Logger
+	repo        Repository
+	client      *http.Client
+	maxAttempts int
+	retryBase   time.Duration
+}
+
+type Option func(*Service)
+
+// WithHTTPClient sets the client used to deliver webhooks. Its timeout bounds
+// a single attempt.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Service) {
+		s.client = client
+	}
+}
+
+// WithRetryPolicy sets how many attempts a delivery gets and the delay before
+// the first retry, which doubles after every failure up to MaxRetryDelay.
+func WithRetryPolicy(maxAttempts int, base time.Duration) Option {
+	return func(s *Service) {
+		s.maxAttempts = maxAttempts
+		s.retryBase = base
+	}
+}
+
+func New(logger *slog.Logger, repo Repository, opts ...Option) *Service {
+	s := &Service{
+		logger:      logger,
+		repo:        repo,
+		client:      &http.Client{Timeout: DefaultTimeout},
+		maxAttempts: DefaultMaxAttempts,
+		retryBase:   DefaultRetryBase,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CreateWebhook registers a subscription. A secret is generated unless one is
+// given; either way it is only ever returned here.
+func (s *Service) CreateWebhook(ctx context.Context, admin string, req models.CreateWebhookRequest) (models.Webhook, error) {
+	events := []string{}
+	for _, ev := range req.Events {
+		if !slices.Contains(SupportedEvents, ev) {
+			return models.Webhook{}, fmt.Errorf("%w: %q", ErrInvalidEventType, ev)
+		}
+		if !slices.Contains(events, ev) {
+			events = append(events, ev)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return models.Webhook{}, fmt.Errorf("error generating webhook secret: %w", err)
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	w, err := s.repo.CreateWebhook(ctx, models.Webhook{
+		URL:       req.URL,
+		Events:    events,
+		Secret:    secret,
+		CreatedBy: admin,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		s.logger.Error("Error creating webhook",
+			slog.String("url", req.URL),
+			slog.String("error", err.Error()))
+		return models.Webhook{}, err
+	}
+
+	s.logger.Info("Webhook created",
+		slog.Int64("id", w.ID),
+		slog.String("url", w.URL),
+		slog.String("admin", admin))
+
+	return w, nil
+}
+
+func (s *Service) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	webhooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if webhooks == nil {
+		webhooks = []models.Webhook{}
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook deactivates the webhook and drops its queued deliveries. Its
+// delivery log is kept.
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) error {
+	if err := s.repo.DeactivateWebhook(ctx, id); err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the latest deliveries of a webhook, newest first.
+func (s *Service) ListDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.GetWebhook(ctx, webhookID); err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	switch {
+	case limit <= 0:
+		limit = DefaultDeliveriesLimit
+	case limit > MaxDeliveriesLimit:
+		limit = MaxDeliveriesLimit
+	}
+
+	deliveries, err := s.repo.ListWebhookDeliveries(ctx, webhookID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	return deliveries, nil
+}
+
+func (s *Service) Name() string {
+	return "webhook"
+}
+
+// Deliver queues an outbox event for every subscribed webhook. It runs in the
+// outbox relay's transaction, so each event is queued exactly once.
+func (s *Service) Deliver(ctx context.Context, ev models.OutboxEvent) error {
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:         ev.ID,
+		Type:       ev.Type,
+		Username:   ev.Username,
+		Data:       ev.Payload,
+		OccurredAt: ev.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+
+	_, err = s.repo.EnqueueWebhookDeliveries(ctx, ev.Type, payload, time.Now().UTC())
+	return err
+}
+
+// DeliverDue sends the deliveries whose next attempt is due and returns how
+// many succeeded.
+func (s *Service) DeliverDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	lease := now.Add(2 * s.attemptTimeout())
+
+	deliveries, err := s.repo.ClaimDueWebhookDeliveries(ctx, now, lease, deliveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, d := range deliveries {
+		d = s.attempt(ctx, d)
+		if err := s.repo.RecordWebhookAttempt(ctx, d); err != nil {
+			s.logger.Error("Error recording webhook attempt",
+				slog.Int64("id", d.ID),
+				slog.String("error", err.Error()))
+			continue
+		}
+		if d.Status == models.WebhookDeliveryStatusDelivered {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// attempt sends the delivery once and returns it updated with the outcome.
+func (s *Service) attempt(ctx context.Context, d models.WebhookDelivery) models.WebhookDelivery {
+	d.Attempts++
+	now := time.Now().UTC()
+
+	status, err := s.send(ctx, d, now)
+	d.ResponseStatus = status
+	if err == nil {
+		d.Status = models.WebhookDeliveryStatusDelivered
+		d.LastError = ""
+		d.DeliveredAt = &now
+		return d
+	}
+
+	d.LastError = err.Error()
+	if d.Attempts >= s.maxAttempts {
+		d.Status = models.WebhookDeliveryStatusFailed
+		s.logger.Warn("Webhook delivery failed permanently",
+			slog.Int64("id", d.ID),
+			slog.Int64("webhookId", d.WebhookID),
+			slog.Int("attempts", d.Attempts),
+			slog.String("error", d.LastError))
+		return d
+	}
+
+	d.NextAttemptAt = now.Add(s.backoff(d.Attempts))
+	return d
+}
+
+func (s *Service) send(ctx context.Context, d models.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("error building request: %w", err)
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(d.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff is the delay after the given number of failed attempts.
+func (s *Service) backoff(attempts int) time.Duration {
+	delay := s.retryBase
+	for i := 1; i < attempts && delay < MaxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, MaxRetryDelay)
+}
+
+func (s *Service) attemptTimeout() time.Duration {
+	if s.client.Timeout > 0 {
+		return s.client.Timeout
+	}
+	return DefaultTimeout
+}
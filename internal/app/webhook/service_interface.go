@@ -0,0 +1,14 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceInterface interface {
+	CreateWebhook(ctx context.Context, admin string, req models.CreateWebhookRequest) (models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error)
+}
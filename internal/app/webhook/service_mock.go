@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+type ServiceMock struct {
+	CreateWebhookFunc  func(ctx context.Context, admin string, req models.CreateWebhookRequest) (models.Webhook, error)
+	ListWebhooksFunc   func(ctx context.Context) ([]models.Webhook, error)
+	DeleteWebhookFunc  func(ctx context.Context, id int64) error
+	ListDeliveriesFunc func(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error)
+}
+
+func (m *ServiceMock) CreateWebhook(ctx context.Context, admin string, req models.CreateWebhookRequest) (models.Webhook, error) {
+	if m.CreateWebhookFunc != nil {
+		return m.CreateWebhookFunc(ctx, admin, req)
+	}
+	return models.Webhook{}, nil
+}
+
+func (m *ServiceMock) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	if m.ListWebhooksFunc != nil {
+		return m.ListWebhooksFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *ServiceMock) DeleteWebhook(ctx context.Context, id int64) error {
+	if m.DeleteWebhookFunc != nil {
+		return m.DeleteWebhookFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *ServiceMock) ListDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	if m.ListDeliveriesFunc != nil {
+		return m.ListDeliveriesFunc(ctx, webhookID, status, limit)
+	}
+	return nil, nil
+}
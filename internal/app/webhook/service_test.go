@@ -0,0 +1,199 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/app/webhook"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockWebhookRepository struct {
+	created  models.Webhook
+	payload  []byte
+	due      []models.WebhookDelivery
+	recorded []models.WebhookDelivery
+}
+
+func (m *MockWebhookRepository) CreateWebhook(ctx context.Context, w models.Webhook) (models.Webhook, error) {
+	w.ID = 1
+	w.Active = true
+	m.created = w
+	return w, nil
+}
+
+func (m *MockWebhookRepository) GetWebhook(ctx context.Context, id int64) (models.Webhook, error) {
+	return models.Webhook{ID: id}, nil
+}
+
+func (m *MockWebhookRepository) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return nil, nil
+}
+
+func (m *MockWebhookRepository) DeactivateWebhook(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (m *MockWebhookRepository) EnqueueWebhookDeliveries(ctx context.Context, eventType string, payload []byte, now time.Time) (int64, error) {
+	m.payload = payload
+	return 1, nil
+}
+
+func (m *MockWebhookRepository) ClaimDueWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	due := m.due
+	m.due = nil
+	return due, nil
+}
+
+func (m *MockWebhookRepository) RecordWebhookAttempt(ctx context.Context, d models.WebhookDelivery) error {
+	m.recorded = append(m.recorded, d)
+	return nil
+}
+
+func (m *MockWebhookRepository) ListWebhookDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestCreateWebhook(t *testing.T) {
+	repo := &MockWebhookRepository{}
+	service := webhook.New(discardLogger(), repo)
+
+	hook, err := service.CreateWebhook(context.Background(), "admin", models.CreateWebhookRequest{
+		URL:    "http://hr.internal/hooks/shop",
+		Events: []string{models.EventCoinsReceived, models.EventCoinsReceived},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.EventCoinsReceived}, hook.Events)
+	assert.Len(t, hook.Secret, 64, "a secret should be generated")
+	assert.Equal(t, "admin", repo.created.CreatedBy)
+
+	_, err = service.CreateWebhook(context.Background(), "admin", models.CreateWebhookRequest{
+		URL:    "http://hr.internal/hooks/shop",
+		Events: []string{"user.deleted"},
+	})
+	assert.ErrorIs(t, err, webhook.ErrInvalidEventType)
+}
+
+func TestDeliverEnqueuesPayload(t *testing.T) {
+	repo := &MockWebhookRepository{}
+	service := webhook.New(discardLogger(), repo)
+
+	err := service.Deliver(context.Background(), models.OutboxEvent{
+		ID:       3,
+		Type:     models.EventCoinsReceived,
+		Username: "bob",
+		Payload:  json.RawMessage(`{"transactionId":7,"fromUser":"alice","amount":10}`),
+	})
+	assert.NoError(t, err)
+
+	var payload struct {
+		ID       int64                     `json:"id"`
+		Type     string                    `json:"type"`
+		Username string                    `json:"username"`
+		Data     models.CoinsReceivedEvent `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(repo.payload, &payload))
+	assert.Equal(t, int64(3), payload.ID)
+	assert.Equal(t, models.EventCoinsReceived, payload.Type)
+	assert.Equal(t, "bob", payload.Username)
+	assert.Equal(t, int64(7), payload.Data.TransactionID)
+}
+
+func TestDeliverDue(t *testing.T) {
+	const secret = "0123456789abcdef"
+	body := []byte(`{"type":"coins.received","username":"bob"}`)
+
+	fail := true
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if !webhook.Verify(secret, timestamp, got, r.Header.Get(webhook.HeaderSignature)) {
+			t.Errorf("invalid signature %q", r.Header.Get(webhook.HeaderSignature))
+		}
+		if r.Header.Get(webhook.HeaderEvent) != models.EventCoinsReceived || r.Header.Get(webhook.HeaderDelivery) != "5" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	delivery := models.WebhookDelivery{
+		ID:        5,
+		WebhookID: 1,
+		EventType: models.EventCoinsReceived,
+		Payload:   body,
+		Status:    models.WebhookDeliveryStatusPending,
+		URL:       receiver.URL,
+		Secret:    secret,
+	}
+	repo := &MockWebhookRepository{}
+	service := webhook.New(discardLogger(), repo, webhook.WithRetryPolicy(3, time.Minute))
+
+	// First attempt fails and is retried after the base delay.
+	repo.due = []models.WebhookDelivery{delivery}
+	delivered, err := service.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, delivered)
+	first := repo.recorded[0]
+	assert.Equal(t, models.WebhookDeliveryStatusPending, first.Status)
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, first.ResponseStatus)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), first.NextAttemptAt, 5*time.Second)
+
+	// Second failure doubles the delay.
+	repo.due = []models.WebhookDelivery{first}
+	_, err = service.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	second := repo.recorded[1]
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), second.NextAttemptAt, 5*time.Second)
+
+	// A success marks the delivery delivered.
+	fail = false
+	repo.due = []models.WebhookDelivery{second}
+	delivered, err = service.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	third := repo.recorded[2]
+	assert.Equal(t, models.WebhookDeliveryStatusDelivered, third.Status)
+	assert.Equal(t, 3, third.Attempts)
+	assert.NotNil(t, third.DeliveredAt)
+	assert.Empty(t, third.LastError)
+}
+
+func TestDeliverDueGivesUp(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	repo := &MockWebhookRepository{due: []models.WebhookDelivery{{
+		ID:       9,
+		Payload:  []byte(`{}`),
+		Status:   models.WebhookDeliveryStatusPending,
+		Attempts: 2,
+		URL:      receiver.URL,
+	}}}
+	service := webhook.New(discardLogger(), repo, webhook.WithRetryPolicy(3, time.Minute))
+
+	_, err := service.DeliverDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryStatusFailed, repo.recorded[0].Status)
+	assert.Equal(t, 3, repo.recorded[0].Attempts)
+}
@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+const (
+	HeaderSignature = "X-Shop-Signature"
+	HeaderTimestamp = "X-Shop-Timestamp"
+	HeaderEvent     = "X-Shop-Event"
+	HeaderDelivery  = "X-Shop-Delivery"
+)
+
+// Sign computes the value of the X-Shop-Signature header: the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the webhook secret. Including the
+// timestamp lets receivers reject replayed requests.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature matches the body and timestamp.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
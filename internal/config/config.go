@@ -3,18 +3,178 @@ package config
 import (
 	"flag"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
-	DatabaseDSN string
+	DatabaseDSN        string
+	PendingTransferTTL time.Duration
+	PaymentRequestTTL  time.Duration
+	WorkerInterval     time.Duration
+	AutoRegister       bool
+	AccessTokenTTL     time.Duration
+	RefreshTokenTTL    time.Duration
+	PasswordResetTTL   time.Duration
+
+	LoginLockoutAfter      int
+	LoginLockoutAfterPerIP int
+	LoginLockoutDuration   time.Duration
+
+	BootstrapAdmin         string
+	BootstrapAdminPassword string
+
+	JWTSigningKeyFile       string
+	JWTVerificationKeyFiles []string
+
+	MaxTransferAmount int
+	MaxSentPerDay     int
+	MaxSentPerWeek    int
+	MaxReceivedPerDay int
+	MinAccountAge     time.Duration
+
+	FraudRulesFile string
+
+	TransferFeePercent float64
+	TransferFeeFlat    int
+	TransferFeeMax     int
+	FeeExemptUsers     []string
+	FeeAccount         string
+
+	LeaderboardCacheTTL time.Duration
+
+	WebhookMaxAttempts int
+	WebhookRetryBase   time.Duration
+	WebhookTimeout     time.Duration
+
+	OutboxFile        string
+	OutboxLog         bool
+	OutboxMaxAttempts int
 )
 
 func ParseFlags() {
 	flag.StringVar(&DatabaseDSN, "d", "postgres://postgres:password@db:5432/shop", "postgres connection url")
+	flag.DurationVar(&PendingTransferTTL, "pending-ttl", 72*time.Hour, "time a pending transfer waits for acceptance")
+	flag.DurationVar(&PaymentRequestTTL, "payment-request-ttl", 7*24*time.Hour, "time a payment request stays payable")
+	flag.BoolVar(&AutoRegister, "auto-register", true, "register unknown usernames on /api/auth instead of rejecting them")
+	flag.DurationVar(&AccessTokenTTL, "access-ttl", 15*time.Minute, "lifetime of access tokens")
+	flag.DurationVar(&RefreshTokenTTL, "refresh-ttl", 30*24*time.Hour, "lifetime of refresh tokens")
+	flag.DurationVar(&PasswordResetTTL, "password-reset-ttl", time.Hour, "time an admin-issued password reset token can be redeemed")
+	flag.DurationVar(&WorkerInterval, "worker-interval", time.Minute, "interval between background worker runs")
+	flag.IntVar(&MaxTransferAmount, "max-transfer", 0, "maximum coins per transfer, 0 disables the limit")
+	flag.IntVar(&MaxSentPerDay, "max-sent-daily", 0, "maximum coins a user can send per day, 0 disables the limit")
+	flag.IntVar(&MaxSentPerWeek, "max-sent-weekly", 0, "maximum coins a user can send per week, 0 disables the limit")
+	flag.IntVar(&MaxReceivedPerDay, "max-received-daily", 0, "maximum coins a user can receive per day, 0 disables the limit")
+	flag.DurationVar(&MinAccountAge, "min-account-age", 0, "minimum account age before sending coins")
+	flag.StringVar(&FraudRulesFile, "fraud-rules", "", "path to a JSON file overriding the default fraud rules")
+	flag.Float64Var(&TransferFeePercent, "fee-percent", 0, "transfer fee as a percentage of the amount")
+	flag.IntVar(&TransferFeeFlat, "fee-flat", 0, "flat transfer fee in coins")
+	flag.IntVar(&TransferFeeMax, "fee-max", 0, "maximum transfer fee, 0 means no cap")
+	flag.StringVar(&FeeAccount, "fee-account", "", "user credited with transfer fees, empty burns them")
+	flag.DurationVar(&LeaderboardCacheTTL, "leaderboard-ttl", 5*time.Minute, "how long computed leaderboards are cached, and so how long an opt-out can take to show on other instances")
+	flag.IntVar(&WebhookMaxAttempts, "webhook-max-attempts", 8, "delivery attempts before a webhook delivery is given up")
+	flag.DurationVar(&WebhookRetryBase, "webhook-retry-base", 30*time.Second, "delay before the first webhook retry, doubled after each failure")
+	flag.DurationVar(&WebhookTimeout, "webhook-timeout", 10*time.Second, "timeout of a single webhook delivery attempt")
+	flag.StringVar(&OutboxFile, "outbox-file", "", "file that relayed domain events are appended to, empty disables it")
+	flag.BoolVar(&OutboxLog, "outbox-log", false, "log every relayed domain event")
+	flag.IntVar(&OutboxMaxAttempts, "outbox-max-attempts", 10, "delivery attempts before a domain event is parked")
+	flag.IntVar(&LoginLockoutAfter, "login-lockout-after", 10, "failed logins that lock a username out, 0 disables the lockout")
+	flag.IntVar(&LoginLockoutAfterPerIP, "login-lockout-after-ip", 100, "failed logins, or sign-ups, that lock a client IP out, 0 disables the lockout")
+	flag.DurationVar(&LoginLockoutDuration, "login-lockout", 15*time.Minute, "how long a locked out username or client IP has to wait")
+	flag.StringVar(&BootstrapAdmin, "bootstrap-admin", "", "user made admin on start while there is no admin yet, registered if missing")
+	flag.StringVar(&BootstrapAdminPassword, "bootstrap-admin-password", "", "password the bootstrap admin is registered with if missing")
+	flag.StringVar(&JWTSigningKeyFile, "jwt-key", "", "PEM file with the RSA or Ed25519 private key access tokens are signed with")
+	feeExempt := flag.String("fee-exempt", "", "comma-separated usernames exempt from transfer fees")
+	verificationKeys := flag.String("jwt-verify-keys", "", "comma-separated PEM files with extra keys tokens are accepted from, such as the previous signing key")
 	flag.Parse()
 
 	envDatabaseDSN := os.Getenv("DATABASE_DSN")
 	if envDatabaseDSN != "" {
 		DatabaseDSN = envDatabaseDSN
 	}
+
+	if envFraudRules := os.Getenv("FRAUD_RULES_FILE"); envFraudRules != "" {
+		FraudRulesFile = envFraudRules
+	}
+
+	if v, err := strconv.ParseBool(os.Getenv("AUTO_REGISTER")); err == nil {
+		AutoRegister = v
+	}
+
+	if envSigningKey := os.Getenv("JWT_SIGNING_KEY_FILE"); envSigningKey != "" {
+		JWTSigningKeyFile = envSigningKey
+	}
+	if envVerificationKeys := os.Getenv("JWT_VERIFICATION_KEY_FILES"); envVerificationKeys != "" {
+		*verificationKeys = envVerificationKeys
+	}
+	JWTVerificationKeyFiles = splitList(*verificationKeys)
+
+	if envOutboxFile := os.Getenv("OUTBOX_FILE"); envOutboxFile != "" {
+		OutboxFile = envOutboxFile
+	}
+	if v, err := strconv.ParseBool(os.Getenv("OUTBOX_LOG")); err == nil {
+		OutboxLog = v
+	}
+
+	durationFromEnv(&PendingTransferTTL, "PENDING_TRANSFER_TTL")
+	durationFromEnv(&PaymentRequestTTL, "PAYMENT_REQUEST_TTL")
+	durationFromEnv(&WorkerInterval, "WORKER_INTERVAL")
+	durationFromEnv(&AccessTokenTTL, "ACCESS_TOKEN_TTL")
+	durationFromEnv(&RefreshTokenTTL, "REFRESH_TOKEN_TTL")
+	durationFromEnv(&PasswordResetTTL, "PASSWORD_RESET_TTL")
+	durationFromEnv(&LoginLockoutDuration, "LOGIN_LOCKOUT_DURATION")
+	durationFromEnv(&MinAccountAge, "MIN_ACCOUNT_AGE")
+	durationFromEnv(&LeaderboardCacheTTL, "LEADERBOARD_CACHE_TTL")
+	durationFromEnv(&WebhookRetryBase, "WEBHOOK_RETRY_BASE")
+	durationFromEnv(&WebhookTimeout, "WEBHOOK_TIMEOUT")
+	intFromEnv(&LoginLockoutAfter, "LOGIN_LOCKOUT_AFTER")
+	intFromEnv(&LoginLockoutAfterPerIP, "LOGIN_LOCKOUT_AFTER_IP")
+	intFromEnv(&MaxTransferAmount, "MAX_TRANSFER_AMOUNT")
+	intFromEnv(&MaxSentPerDay, "MAX_SENT_PER_DAY")
+	intFromEnv(&MaxSentPerWeek, "MAX_SENT_PER_WEEK")
+	intFromEnv(&MaxReceivedPerDay, "MAX_RECEIVED_PER_DAY")
+	intFromEnv(&TransferFeeFlat, "TRANSFER_FEE_FLAT")
+	intFromEnv(&TransferFeeMax, "TRANSFER_FEE_MAX")
+	intFromEnv(&WebhookMaxAttempts, "WEBHOOK_MAX_ATTEMPTS")
+	intFromEnv(&OutboxMaxAttempts, "OUTBOX_MAX_ATTEMPTS")
+	if v, err := strconv.ParseFloat(os.Getenv("TRANSFER_FEE_PERCENT"), 64); err == nil {
+		TransferFeePercent = v
+	}
+	if envFeeAccount := os.Getenv("FEE_ACCOUNT"); envFeeAccount != "" {
+		FeeAccount = envFeeAccount
+	}
+	if envFeeExempt := os.Getenv("FEE_EXEMPT_USERS"); envFeeExempt != "" {
+		*feeExempt = envFeeExempt
+	}
+	FeeExemptUsers = splitList(*feeExempt)
+
+	if envBootstrapAdmin := os.Getenv("BOOTSTRAP_ADMIN"); envBootstrapAdmin != "" {
+		BootstrapAdmin = envBootstrapAdmin
+	}
+	if envBootstrapPassword := os.Getenv("BOOTSTRAP_ADMIN_PASSWORD"); envBootstrapPassword != "" {
+		BootstrapAdminPassword = envBootstrapPassword
+	}
+}
+
+func durationFromEnv(dst *time.Duration, key string) {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		*dst = d
+	}
+}
+
+func intFromEnv(dst *int, key string) {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		*dst = v
+	}
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/nglmq/avito-shop/internal/app/apikey"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const ContextAPIKeyID = "apiKeyId"
+
+// APIKeyAuthenticator looks up the API key a request was made with, normally
+// apikey.Service.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (models.APIKey, error)
+}
+
+// CheckAuthOrAPIKeyMiddleware works like CheckAuthMiddleware, but also
+// accepts an "Authorization: ApiKey <key>" header from keys granted scope.
+// Requests made with a key carry no role, so they never pass RequireRole or
+// RequirePermission.
+func CheckAuthOrAPIKeyMiddleware(
+	logger *slog.Logger,
+	revocations RevocationChecker,
+	keys APIKeyAuthenticator,
+	scope string,
+) func(http.Handler) http.Handler {
+	checkJWT := CheckAuthMiddleware(logger, revocations)
+
+	return func(next http.Handler) http.Handler {
+		withJWT := checkJWT(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			plain, ok := strings.CutPrefix(r.Header.Get("Authorization"), "ApiKey ")
+			if !ok {
+				withJWT.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := keys.Authenticate(r.Context(), plain)
+			if err != nil {
+				if errors.Is(err, apikey.ErrInvalidKey) {
+					logger.Error("Invalid api key",
+						slog.String("path", r.URL.Path))
+					http.Error(w, "Invalid api key", http.StatusUnauthorized)
+					return
+				}
+
+				logger.Error("Error checking api key",
+					slog.String("path", r.URL.Path),
+					slog.String("error", err.Error()))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			if !slices.Contains(key.Scopes, scope) {
+				logger.Warn("API key lacks scope",
+					slog.String("path", r.URL.Path),
+					slog.String("username", key.Username),
+					slog.Int64("key", key.ID),
+					slog.String("scope", scope))
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ContextUserID, key.Username)
+			ctx = context.WithValue(ctx, ContextAPIKeyID, key.ID)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
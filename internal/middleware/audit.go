@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/nglmq/avito-shop/internal/app/audit"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// maxAuditBody caps how much of a request body is copied into an audit entry.
+const maxAuditBody = 64 << 10
+
+// AuditRequestMiddleware attaches the request id and client IP to the request
+// context, so that services can record them in the audit log. It must be
+// chained after chi's RequestID middleware.
+func AuditRequestMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := audit.WithRequestInfo(r.Context(), audit.RequestInfo{
+			ID:       middleware.GetReqID(r.Context()),
+			ClientIP: clientIP(r),
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AuditAdminMiddleware records every state-changing request made to the
+// routes it guards as an admin action, including those the admin check
+// rejects. It must be chained after CheckAuthMiddleware. Body fields that
+// look like credentials are redacted.
+func AuditAdminMiddleware(auditor audit.Auditor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil {
+				body, _ = io.ReadAll(io.LimitReader(r.Body, maxAuditBody))
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			params := map[string]any{
+				"method": r.Method,
+				"path":   r.URL.Path,
+			}
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				params["route"] = rctx.RoutePattern()
+				urlParams := make(map[string]string, len(rctx.URLParams.Keys))
+				for i, key := range rctx.URLParams.Keys {
+					if key != "*" {
+						urlParams[key] = rctx.URLParams.Values[i]
+					}
+				}
+				if len(urlParams) > 0 {
+					params["urlParams"] = urlParams
+				}
+			}
+			var fields map[string]any
+			if json.Unmarshal(body, &fields) == nil && len(fields) > 0 {
+				params["body"] = redact(fields)
+			}
+
+			var err error
+			if status := ww.Status(); status >= http.StatusBadRequest {
+				err = fmt.Errorf("%d %s", status, http.StatusText(status))
+			}
+
+			username, _ := r.Context().Value(ContextUserID).(string)
+			auditor.Record(r.Context(), username, models.AuditActionAdmin, params, err)
+		})
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func redact(fields map[string]any) map[string]any {
+	for key := range fields {
+		lower := strings.ToLower(key)
+		if strings.Contains(lower, "password") || strings.Contains(lower, "secret") || strings.Contains(lower, "token") {
+			fields[key] = "[redacted]"
+		}
+	}
+
+	return fields
+}
@@ -5,24 +5,31 @@ import (
 	ujwt "github.com/nglmq/avito-shop/internal/utils/jwt"
 	"log/slog"
 	"net/http"
+	"strings"
 )
 
 const (
-	ContextUserID = "user"
+	ContextUserID  = "user"
+	ContextTokenID = "tokenId"
+	ContextRole    = "role"
 )
 
-func CheckAuthMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+// RevocationChecker reports whether an access token has been revoked before
+// it expired, normally auth.Service.
+type RevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+func CheckAuthMiddleware(logger *slog.Logger, revocations RevocationChecker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			tokenString := r.Header.Get("Authorization")
-			if tokenString == "" {
+			tokenString, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || tokenString == "" {
 				http.Error(w, "Authorization token is required", http.StatusUnauthorized)
 				return
 			}
 
-			tokenString = tokenString[7:]
-
-			userID, err := ujwt.GetUserID(tokenString)
+			claims, err := ujwt.ParseToken(tokenString)
 			if err != nil {
 				logger.Error("Invalid auth token",
 					slog.String("path", r.URL.Path),
@@ -31,7 +38,25 @@ func CheckAuthMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), ContextUserID, userID)
+			revoked, err := revocations.IsTokenRevoked(r.Context(), claims.ID)
+			if err != nil {
+				logger.Error("Error checking token revocation",
+					slog.String("path", r.URL.Path),
+					slog.String("error", err.Error()))
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if revoked {
+				logger.Warn("Revoked auth token",
+					slog.String("path", r.URL.Path),
+					slog.String("username", claims.UserID))
+				http.Error(w, "Invalid auth token", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ContextUserID, claims.UserID)
+			ctx = context.WithValue(ctx, ContextTokenID, claims.ID)
+			ctx = context.WithValue(ctx, ContextRole, claims.Role)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// RequireRole lets through only users with one of the given roles. Like
+// RequirePermission it must be chained after CheckAuthMiddleware, which puts
+// the role from the token into the request context.
+func RequireRole(logger *slog.Logger, roles ...string) func(http.Handler) http.Handler {
+	return authorize(logger, func(role string) bool {
+		return slices.Contains(roles, role)
+	})
+}
+
+// RequirePermission lets through only users whose role grants permission.
+func RequirePermission(logger *slog.Logger, permission string) func(http.Handler) http.Handler {
+	return authorize(logger, func(role string) bool {
+		return models.HasPermission(role, permission)
+	})
+}
+
+func authorize(logger *slog.Logger, allowed func(role string) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value(ContextRole).(string)
+			if !allowed(role) {
+				username, _ := r.Context().Value(ContextUserID).(string)
+				logger.Warn("Access denied",
+					slog.String("path", r.URL.Path),
+					slog.String("username", username),
+					slog.String("role", role))
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Scopes an API key can be granted. Each one opens a group of user routes to
+// requests authenticated with the key; admin routes never accept API keys.
+const (
+	APIKeyScopeReadInfo  = "info:read"
+	APIKeyScopeSendCoins = "coins:send"
+	APIKeyScopeBuy       = "merch:buy"
+)
+
+var APIKeyScopes = []string{APIKeyScopeReadInfo, APIKeyScopeSendCoins, APIKeyScopeBuy}
+
+// APIKey lets a bot or service account act as Username without a password.
+// Only a hash of the key is stored; Key is set once, in the response to its
+// creation, and Prefix lets the owner tell their keys apart afterwards.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Username   string     `json:"username"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=64"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionRegister              = "register"
+	AuditActionLogin                 = "login"
+	AuditActionRefresh               = "refresh"
+	AuditActionLogout                = "logout"
+	AuditActionPasswordChange        = "password_change"
+	AuditActionPasswordReset         = "password_reset"
+	AuditActionAPIKeyCreate          = "api_key_create"
+	AuditActionAPIKeyDelete          = "api_key_delete"
+	AuditActionSend                  = "send"
+	AuditActionTransferAccept        = "transfer_accept"
+	AuditActionTransferDecline       = "transfer_decline"
+	AuditActionPaymentRequestCreate  = "payment_request_create"
+	AuditActionPaymentRequestPay     = "payment_request_pay"
+	AuditActionPaymentRequestDecline = "payment_request_decline"
+	AuditActionPaymentRequestCancel  = "payment_request_cancel"
+	AuditActionScheduleCreate        = "schedule_create"
+	AuditActionScheduleCancel        = "schedule_cancel"
+	AuditActionScheduleRun           = "schedule_run"
+	AuditActionBuy                   = "buy"
+	AuditActionAdmin                 = "admin"
+
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEntry records one state-changing action. Entries are never updated or
+// deleted once written.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	ClientIP  string          `json:"clientIp,omitempty"`
+	Outcome   string          `json:"outcome"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// AuditFilter narrows an audit log query. Entries are returned newest first;
+// Before continues a previous page from the given entry id.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	From   *time.Time
+	To     *time.Time
+	Before int64
+	Limit  int
+}
+
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextBefore int64        `json:"nextBefore,omitempty"`
+}
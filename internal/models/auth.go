@@ -1,10 +1,61 @@
 package models
 
+import "time"
+
 type AuthRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
 }
 
+// AuthResponse carries a short-lived access token and the refresh token that
+// exchanges for the next one. Each refresh token can be used only once.
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	ExpiresAt    time.Time `json:"expiresAt,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// RefreshToken is the server-side record of an opaque refresh token; only
+// its hash is stored. Tokens rotated from the same login share a family, and
+// AccessTokenID is the jti of the access token issued alongside.
+type RefreshToken struct {
+	ID            int64
+	FamilyID      string
+	Username      string
+	AccessTokenID string
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	RevokedAt     *time.Time
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required"`
+}
+
+// PasswordReset is the one-time token an admin hands to a user so that they
+// can set a new password without knowing the old one.
+type PasswordReset struct {
+	Username  string    `json:"username"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PasswordResetToken is the server-side record of a reset token; like
+// refresh tokens, only its hash is stored.
+type PasswordResetToken struct {
+	ID        int64
+	Username  string
+	CreatedBy string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
 }
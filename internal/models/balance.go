@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// BalanceAsOf is a user's balance reconstructed from history as it stood at
+// the given moment.
+type BalanceAsOf struct {
+	Username string    `json:"username"`
+	At       time.Time `json:"at"`
+	Balance  int       `json:"balance"`
+}
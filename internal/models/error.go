@@ -2,4 +2,5 @@ package models
 
 type ErrorResponse struct {
 	Errors string `json:"errors"`
+	Code   string `json:"code,omitempty"`
 }
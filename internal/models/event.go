@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	EventCoinsReceived         = "coins.received"
+	EventPurchaseCompleted     = "purchase.completed"
+	EventTransferPending       = "transfer.pending"
+	EventPaymentRequestCreated = "paymentRequest.created"
+)
+
+// Event is a notification pushed to a single user. Its ID is the ID of the
+// outbox event it was relayed from, so IDs are unique across all users and
+// are not reused after a restart, but they may arrive out of order.
+type Event struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	Username  string    `json:"-"`
+	Data      any       `json:"data"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CoinsReceivedEvent struct {
+	TransactionID int64  `json:"transactionId"`
+	FromUser      string `json:"fromUser"`
+	Amount        int    `json:"amount"`
+}
+
+type PurchaseCompletedEvent struct {
+	Item       string `json:"item"`
+	Quantity   int    `json:"quantity"`
+	TotalPrice int    `json:"totalPrice"`
+}
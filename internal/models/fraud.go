@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	FraudActionFlag = "flag"
+	FraudActionHold = "hold"
+
+	FraudFlagStatusOpen     = "open"
+	FraudFlagStatusApproved = "approved"
+	FraudFlagStatusRejected = "rejected"
+
+	FraudDecisionApprove = "approve"
+	FraudDecisionReject  = "reject"
+)
+
+// FraudVerdict is the outcome of screening a transfer. Action is empty when no
+// rule matched.
+type FraudVerdict struct {
+	Action string
+	Rules  []string
+}
+
+type FraudFlag struct {
+	ID            int64      `json:"id"`
+	TransactionID int64      `json:"transactionId"`
+	FromUser      string     `json:"fromUser"`
+	ToUser        string     `json:"toUser"`
+	Amount        int        `json:"amount"`
+	Fee           int        `json:"fee,omitempty"`
+	Rules         []string   `json:"rules"`
+	Action        string     `json:"action"`
+	Status        string     `json:"status"`
+	ReviewedBy    string     `json:"reviewedBy,omitempty"`
+	ReviewNote    string     `json:"reviewNote,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+type FraudReviewRequest struct {
+	Decision string `json:"decision" validate:"required,oneof=approve reject"`
+	Note     string `json:"note" validate:"max=500"`
+}
+
+type TransferHeldResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
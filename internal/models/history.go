@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+const (
+	HistoryEntrySent     = "sent"
+	HistoryEntryReceived = "received"
+	HistoryEntryPurchase = "purchase"
+
+	HistoryDirectionSent      = "sent"
+	HistoryDirectionReceived  = "received"
+	HistoryDirectionPurchases = "purchases"
+
+	HistorySourceTransaction = "t"
+	HistorySourcePurchase    = "p"
+)
+
+// HistoryEntry is a single coin movement of a user. Transfers and purchases
+// have separate id sequences, so an entry is identified by its type and id.
+type HistoryEntry struct {
+	ID           int64     `json:"id"`
+	Type         string    `json:"type"`
+	Timestamp    time.Time `json:"timestamp"`
+	Amount       int       `json:"amount"`
+	Fee          int       `json:"fee,omitempty"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Item         string    `json:"item,omitempty"`
+	Quantity     int       `json:"quantity,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	Kind         string    `json:"kind,omitempty"`
+}
+
+// HistoryCursor points at the last entry of a page; the next page starts
+// right after it in (timestamp, source, id) descending order.
+type HistoryCursor struct {
+	Timestamp time.Time
+	Source    string
+	ID        int64
+}
+
+type HistoryFilter struct {
+	Direction    string
+	From         *time.Time
+	To           *time.Time
+	Counterparty string
+	After        *HistoryCursor
+	Limit        int
+}
+
+type HistoryPage struct {
+	Entries    []HistoryEntry `json:"entries"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
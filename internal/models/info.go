@@ -19,4 +19,5 @@ type TransactionReceivedHistory struct {
 type TransactionSentHistory struct {
 	ToUser string `json:"toUser"`
 	Amount int    `json:"amount"`
+	Fee    int    `json:"fee,omitempty"`
 }
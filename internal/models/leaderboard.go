@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	LeaderboardBalances   = "balances"
+	LeaderboardSenders    = "senders"
+	LeaderboardReceivers  = "receivers"
+	LeaderboardCollectors = "collectors"
+
+	LeaderboardWindowWeek  = "week"
+	LeaderboardWindowMonth = "month"
+	LeaderboardWindowAll   = "all"
+)
+
+type LeaderboardEntry struct {
+	Rank     int    `json:"rank"`
+	Username string `json:"username"`
+	Value    int    `json:"value"`
+}
+
+// Leaderboard is a ranking snapshot taken at GeneratedAt. Item is only set
+// for the collectors board.
+type Leaderboard struct {
+	Board       string             `json:"board"`
+	Window      string             `json:"window"`
+	Item        string             `json:"item,omitempty"`
+	GeneratedAt time.Time          `json:"generatedAt"`
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+type LeaderboardOptOutRequest struct {
+	OptOut *bool `json:"optOut" validate:"required"`
+}
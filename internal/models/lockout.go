@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LoginAttempts counts the recent failed logins for a username or client IP,
+// or the sign-ups from a client IP, identified by Key.
+type LoginAttempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+}
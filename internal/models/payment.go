@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	PaymentRequestStatusPending   = "pending"
+	PaymentRequestStatusPaid      = "paid"
+	PaymentRequestStatusDeclined  = "declined"
+	PaymentRequestStatusCancelled = "cancelled"
+	PaymentRequestStatusExpired   = "expired"
+)
+
+type CreatePaymentRequest struct {
+	Payer  string `json:"payer" validate:"required"`
+	Amount int    `json:"amount" validate:"required,gt=0"`
+	Note   string `json:"note" validate:"max=255"`
+}
+
+type PaymentRequest struct {
+	ID            int64      `json:"id"`
+	Requester     string     `json:"requester"`
+	Payer         string     `json:"payer"`
+	Amount        int        `json:"amount"`
+	Note          string     `json:"note"`
+	Status        string     `json:"status"`
+	TransactionID *int64     `json:"transactionId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	ResolvedAt    *time.Time `json:"resolvedAt,omitempty"`
+}
+
+type PaymentRequestsResponse struct {
+	Incoming []PaymentRequest `json:"incoming"`
+	Outgoing []PaymentRequest `json:"outgoing"`
+}
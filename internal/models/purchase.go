@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type Purchase struct {
+	ID          int64     `json:"id"`
+	Item        string    `json:"item"`
+	Quantity    int       `json:"quantity"`
+	UnitPrice   int       `json:"unitPrice"`
+	TotalPrice  int       `json:"totalPrice"`
+	PurchasedAt time.Time `json:"purchasedAt"`
+}
+
+type PurchasesPage struct {
+	Purchases  []Purchase `json:"purchases"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+// Roles a user can have. Every user starts as RoleUser.
+const (
+	RoleUser    = "user"
+	RoleAuditor = "auditor"
+	RoleAdmin   = "admin"
+)
+
+// Permissions guarding the admin routes.
+const (
+	PermissionReadUsers           = "users:read"
+	PermissionManageUsers         = "users:manage"
+	PermissionReverseTransactions = "transactions:reverse"
+	PermissionReadFraud           = "fraud:read"
+	PermissionReviewFraud         = "fraud:review"
+	PermissionManageWebhooks      = "webhooks:manage"
+	PermissionReadAudit           = "audit:read"
+)
+
+var rolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleAuditor: {
+		PermissionReadUsers,
+		PermissionReadFraud,
+		PermissionReadAudit,
+	},
+	RoleAdmin: {
+		PermissionReadUsers,
+		PermissionManageUsers,
+		PermissionReverseTransactions,
+		PermissionReadFraud,
+		PermissionReviewFraud,
+		PermissionManageWebhooks,
+		PermissionReadAudit,
+	},
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role grants permission. Unknown roles grant
+// nothing.
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	ScheduleStatusActive    = "active"
+	ScheduleStatusCompleted = "completed"
+	ScheduleStatusCancelled = "cancelled"
+	ScheduleStatusFailed    = "failed"
+)
+
+type ScheduledTransferRequest struct {
+	ToUser   string     `json:"toUser" validate:"required"`
+	Amount   int        `json:"amount" validate:"required,gt=0"`
+	RunAt    *time.Time `json:"runAt,omitempty" validate:"required_without=Schedule"`
+	Schedule string     `json:"schedule,omitempty" validate:"required_without=RunAt,excluded_with=RunAt"`
+}
+
+type ScheduledTransfer struct {
+	ID           int64      `json:"id"`
+	FromUser     string     `json:"fromUser"`
+	ToUser       string     `json:"toUser"`
+	Amount       int        `json:"amount"`
+	Schedule     string     `json:"schedule,omitempty"`
+	Status       string     `json:"status"`
+	NextRunAt    time.Time  `json:"nextRunAt"`
+	LastRunAt    *time.Time `json:"lastRunAt,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+	FailureCount int        `json:"failureCount"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
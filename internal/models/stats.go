@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+const (
+	StatsBucketDay   = "day"
+	StatsBucketWeek  = "week"
+	StatsBucketMonth = "month"
+	StatsBucketYear  = "year"
+)
+
+type StatsFilter struct {
+	From   time.Time
+	To     time.Time
+	Bucket string
+	Top    int
+}
+
+// StatsBucket holds the coins a user moved during one period starting at
+// Start. Fees are the transfer fees paid on top of Sent.
+type StatsBucket struct {
+	Start    time.Time `json:"start"`
+	Spent    int       `json:"spent"`
+	Sent     int       `json:"sent"`
+	Fees     int       `json:"fees"`
+	Received int       `json:"received"`
+}
+
+type StatsTotals struct {
+	Spent    int `json:"spent"`
+	Sent     int `json:"sent"`
+	Fees     int `json:"fees"`
+	Received int `json:"received"`
+}
+
+type CounterpartyStats struct {
+	Username  string `json:"username"`
+	Sent      int    `json:"sent"`
+	Received  int    `json:"received"`
+	Transfers int    `json:"transfers"`
+}
+
+type ItemStats struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+	Spent    int    `json:"spent"`
+}
+
+type StatsResponse struct {
+	From              time.Time           `json:"from"`
+	To                time.Time           `json:"to"`
+	Bucket            string              `json:"bucket"`
+	Totals            StatsTotals         `json:"totals"`
+	Buckets           []StatsBucket       `json:"buckets"`
+	TopCounterparties []CounterpartyStats `json:"topCounterparties"`
+	TopItems          []ItemStats         `json:"topItems"`
+}
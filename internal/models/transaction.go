@@ -1,6 +1,120 @@
 package models
 
+import "time"
+
+const (
+	TransactionStatusPending   = "pending"
+	TransactionStatusCompleted = "completed"
+	TransactionStatusDeclined  = "declined"
+	TransactionStatusExpired   = "expired"
+	TransactionStatusHeld      = "held"
+
+	TransactionKindTransfer = "transfer"
+	TransactionKindReversal = "reversal"
+)
+
 type SendCoinsRequest struct {
+	ToUser            string `json:"toUser" validate:"required"`
+	Amount            int    `json:"amount" validate:"required"`
+	RequireAcceptance bool   `json:"requireAcceptance,omitempty"`
+}
+
+type Transaction struct {
+	ID        int64     `json:"id"`
+	FromUser  string    `json:"fromUser"`
+	ToUser    string    `json:"toUser"`
+	Amount    int       `json:"amount"`
+	Status    string    `json:"status"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PendingTransfer struct {
+	ID        int64     `json:"id"`
+	FromUser  string    `json:"fromUser"`
+	ToUser    string    `json:"toUser"`
+	Amount    int       `json:"amount"`
+	Fee       int       `json:"fee,omitempty"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type PendingTransferResponse struct {
+	ID        int64     `json:"id"`
+	Fee       int       `json:"fee,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type PendingTransfersResponse struct {
+	Incoming []PendingTransfer `json:"incoming"`
+	Outgoing []PendingTransfer `json:"outgoing"`
+}
+
+type BatchRecipient struct {
 	ToUser string `json:"toUser" validate:"required"`
-	Amount int    `json:"amount" validate:"required"`
+	Amount int    `json:"amount" validate:"required,gt=0"`
+}
+
+// BatchTransferRequest lists either explicit recipients with amounts, or
+// usernames in ToUsers sharing Total evenly.
+type BatchTransferRequest struct {
+	Recipients []BatchRecipient `json:"recipients,omitempty" validate:"required_without=ToUsers,excluded_with=ToUsers,dive"`
+	ToUsers    []string         `json:"toUsers,omitempty" validate:"required_with=Total,dive,required"`
+	Total      int              `json:"total,omitempty" validate:"required_with=ToUsers"`
+}
+
+// BatchTransaction is one transfer of a batch. Status is completed, or held
+// when the transfer awaits fraud review.
+type BatchTransaction struct {
+	ID     int64  `json:"id"`
+	ToUser string `json:"toUser"`
+	Amount int    `json:"amount"`
+	Fee    int    `json:"fee,omitempty"`
+	Status string `json:"status"`
+}
+
+type BatchTransferResponse struct {
+	BatchID      int64              `json:"batchId"`
+	Total        int                `json:"total"`
+	Fees         int                `json:"fees,omitempty"`
+	Transactions []BatchTransaction `json:"transactions"`
+}
+
+// TransferQuote is what a transfer costs the sender: the amount the recipient
+// gets plus the fee.
+type TransferQuote struct {
+	ToUser string `json:"toUser"`
+	Amount int    `json:"amount"`
+	Fee    int    `json:"fee"`
+	Total  int    `json:"total"`
+}
+
+// TransferReceipt is what a completed transfer cost the sender, as recorded
+// on the transaction.
+type TransferReceipt struct {
+	TransactionID int64  `json:"transactionId"`
+	ToUser        string `json:"toUser"`
+	Amount        int    `json:"amount"`
+	Fee           int    `json:"fee"`
+	Total         int    `json:"total"`
+}
+
+type ReverseTransactionRequest struct {
+	Reason       string `json:"reason" validate:"required,max=500"`
+	AllowPartial bool   `json:"allowPartial,omitempty"`
+}
+
+// Reversal records a compensating transaction created by an admin. Shortfall
+// is the part of the original amount the recipient had already spent when
+// a partial reversal was allowed.
+type Reversal struct {
+	ID                        int64     `json:"id"`
+	TransactionID             int64     `json:"transactionId"`
+	CompensatingTransactionID int64     `json:"compensatingTransactionId"`
+	Amount                    int       `json:"amount"`
+	Shortfall                 int       `json:"shortfall"`
+	Reason                    string    `json:"reason"`
+	Admin                     string    `json:"admin"`
+	CreatedAt                 time.Time `json:"createdAt"`
 }
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+// Webhook is an admin-managed subscription. An empty Events list subscribes
+// to every event type. The secret is only returned when the webhook is
+// created.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,startswith=http"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret" validate:"omitempty,min=16"`
+}
+
+// WebhookPayload is the JSON body POSTed to subscribers. ID identifies the
+// event, so receivers can drop the duplicates that retries may cause.
+type WebhookPayload struct {
+	ID         int64     `json:"id"`
+	Type       string    `json:"type"`
+	Username   string    `json:"username"`
+	Data       any       `json:"data"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhookId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+
+	// URL and Secret of the webhook, loaded for sending only.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
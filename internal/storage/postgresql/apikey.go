@@ -0,0 +1,144 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+func (r *Repo) CreateAPIKey(ctx context.Context, keyHash string, k models.APIKey) (models.APIKey, error) {
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO api_keys (key_hash, prefix, username, name, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, keyHash, k.Prefix, k.Username, k.Name, k.Scopes, k.ExpiresAt, k.CreatedAt).Scan(&k.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return models.APIKey{}, storage.ErrUserNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("error creating api key: %w", err)
+	}
+
+	return k, nil
+}
+
+func (r *Repo) GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	var k models.APIKey
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT id, username, name, prefix, scopes, expires_at, last_used_at, created_at
+		FROM api_keys
+		WHERE key_hash = $1
+	`, keyHash).Scan(&k.ID, &k.Username, &k.Name, &k.Prefix, &k.Scopes, &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, storage.ErrAPIKeyNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("error fetching api key: %w", err)
+	}
+
+	return k, nil
+}
+
+func (r *Repo) ListAPIKeys(ctx context.Context, username string) ([]models.APIKey, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, username, name, prefix, scopes, expires_at, last_used_at, created_at
+		FROM api_keys
+		WHERE username = $1
+		ORDER BY id
+	`, username)
+	if err != nil {
+		return nil, fmt.Errorf("error listing api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(&k.ID, &k.Username, &k.Name, &k.Prefix, &k.Scopes, &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning api key row: %w", err)
+		}
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading api key rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+// CountAPIKeysForUpdate locks the user until the end of the transaction and
+// returns how many keys they hold, so that concurrent creates cannot go over
+// the limit together.
+func (r *Repo) CountAPIKeysForUpdate(ctx context.Context, username string) (int, error) {
+	var count int
+
+	err := r.conn(ctx).QueryRow(ctx,
+		"SELECT 1 FROM users WHERE username = $1 FOR UPDATE", username).
+		Scan(new(int))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("error locking user: %w", err)
+	}
+
+	err = r.conn(ctx).QueryRow(ctx,
+		"SELECT COUNT(*) FROM api_keys WHERE username = $1", username).
+		Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting api keys: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteAPIKey deletes the key only if it belongs to username, so that users
+// cannot delete each other's keys by id.
+func (r *Repo) DeleteAPIKey(ctx context.Context, username string, id int64) error {
+	tag, err := r.conn(ctx).Exec(ctx, "DELETE FROM api_keys WHERE id = $1 AND username = $2", id, username)
+	if err != nil {
+		return fmt.Errorf("error deleting api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// DeleteUserAPIKeys deletes every key of the user and returns how many there
+// were.
+func (r *Repo) DeleteUserAPIKeys(ctx context.Context, username string) (int64, error) {
+	tag, err := r.conn(ctx).Exec(ctx, "DELETE FROM api_keys WHERE username = $1", username)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting api keys: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// TouchAPIKey records that the key was used now, unless that was already
+// recorded since notBefore. This keeps busy keys from writing on every
+// request.
+func (r *Repo) TouchAPIKey(ctx context.Context, id int64, now, notBefore time.Time) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE api_keys
+		SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)
+	`, id, now, notBefore)
+	if err != nil {
+		return fmt.Errorf("error updating api key last use: %w", err)
+	}
+
+	return nil
+}
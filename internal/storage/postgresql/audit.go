@@ -0,0 +1,83 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+func (r *Repo) InsertAuditEntry(ctx context.Context, e models.AuditEntry) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		INSERT INTO audit_log (actor, action, params, request_id, client_ip, outcome, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, e.Actor, e.Action, e.Params, e.RequestID, e.ClientIP, e.Outcome, e.Error, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListAuditEntries returns up to filter.Limit entries matching the filter,
+// newest first.
+func (r *Repo) ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var (
+		args  []any
+		conds []string
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.Actor != "" {
+		conds = append(conds, "actor = "+arg(filter.Actor))
+	}
+	if filter.Action != "" {
+		conds = append(conds, "action = "+arg(filter.Action))
+	}
+	if filter.From != nil {
+		conds = append(conds, "created_at >= "+arg(*filter.From))
+	}
+	if filter.To != nil {
+		conds = append(conds, "created_at < "+arg(*filter.To))
+	}
+	if filter.Before > 0 {
+		conds = append(conds, "id < "+arg(filter.Before))
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, actor, action, params, request_id, client_ip, outcome, error, created_at
+		FROM audit_log
+		`+where+`
+		ORDER BY id DESC
+		LIMIT `+arg(filter.Limit), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Params, &e.RequestID, &e.ClientIP, &e.Outcome, &e.Error, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning audit entry row: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit entry rows: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,69 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+// initialBalance is what every new account starts with.
+const initialBalance = 1000
+
+// GetBalanceAsOf replays the user's history up to at and returns the balance
+// it yields along with the account creation time. It mirrors how the
+// transaction service moves coins:
+//   - purchases and every sent transfer debit amount plus fee when created;
+//   - declined or expired transfers, pending or held, refund the amount plus
+//     fee when resolved;
+//   - completed transfers credit the receiver when resolved, or when created
+//     if they never waited;
+//   - the fee account is credited the fee of a completed transfer at the same
+//     moment as the receiver.
+func (r *Repo) GetBalanceAsOf(ctx context.Context, username string, at time.Time, feeAccount string) (int, time.Time, error) {
+	var (
+		balance   int
+		createdAt time.Time
+	)
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT u.created_at,
+			$3
+			- COALESCE((
+				SELECT SUM(total_price) FROM purchases
+				WHERE username = $1 AND created_at <= $2
+			), 0)
+			- COALESCE((
+				SELECT SUM(amount + fee) FROM transactions
+				WHERE sender_username = $1 AND created_at <= $2
+			), 0)
+			+ COALESCE((
+				SELECT SUM(amount + fee) FROM transactions
+				WHERE sender_username = $1 AND status IN ($5, $6) AND resolved_at <= $2
+			), 0)
+			+ COALESCE((
+				SELECT SUM(amount) FROM transactions
+				WHERE receiver_username = $1 AND status = $4 AND COALESCE(resolved_at, created_at) <= $2
+			), 0)
+			+ CASE WHEN u.username = $7 THEN COALESCE((
+				SELECT SUM(fee) FROM transactions
+				WHERE fee > 0 AND status = $4 AND COALESCE(resolved_at, created_at) <= $2
+			), 0) ELSE 0 END
+		FROM users u
+		WHERE u.username = $1
+	`, username, at, initialBalance,
+		models.TransactionStatusCompleted, models.TransactionStatusDeclined, models.TransactionStatusExpired,
+		feeAccount).Scan(&createdAt, &balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, time.Time{}, storage.ErrUserNotFound
+		}
+		return 0, time.Time{}, fmt.Errorf("error reconstructing balance: %w", err)
+	}
+
+	return balance, createdAt, nil
+}
@@ -10,7 +10,7 @@ import (
 func (r *Repo) GetBalance(ctx context.Context, username string) (int, error) {
 	var balance int
 
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
 		SELECT balance FROM balances WHERE username = $1 FOR UPDATE
 	`, username).Scan(&balance)
 	if err != nil {
@@ -24,7 +24,7 @@ func (r *Repo) GetBalance(ctx context.Context, username string) (int, error) {
 }
 
 func (r *Repo) UpdateBalanceDeduct(ctx context.Context, senderUsername string, amount int) error {
-	tx, err := r.db.Begin(ctx)
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -44,7 +44,7 @@ func (r *Repo) UpdateBalanceDeduct(ctx context.Context, senderUsername string, a
 }
 
 func (r *Repo) UpdateBalance(ctx context.Context, receiverUsername string, amount int) error {
-	tx, err := r.db.Begin(ctx)
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
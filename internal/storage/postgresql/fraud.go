@@ -0,0 +1,171 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+// CountNewAccountSenders counts the distinct senders other than exclude whose
+// accounts were created after createdAfter and who sent coins to receiver
+// since the given time.
+func (r *Repo) CountNewAccountSenders(
+	ctx context.Context,
+	receiverUsername, exclude string,
+	since, createdAfter time.Time,
+) (int, error) {
+	var count int
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT COUNT(DISTINCT t.sender_username)
+		FROM transactions t
+		JOIN users u ON u.username = t.sender_username
+		WHERE t.receiver_username = $1 AND t.sender_username <> $2
+			AND t.created_at >= $3 AND u.created_at >= $4
+			AND t.kind = $5 AND t.status IN ($6, $7, $8)
+	`, receiverUsername, exclude, since, createdAfter, models.TransactionKindTransfer,
+		models.TransactionStatusCompleted, models.TransactionStatusPending, models.TransactionStatusHeld).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting new account senders: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *Repo) CountSentTransfersSince(ctx context.Context, senderUsername string, since time.Time) (int, error) {
+	var count int
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM transactions
+		WHERE sender_username = $1 AND created_at >= $2 AND kind = $3
+	`, senderUsername, since, models.TransactionKindTransfer).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting sent transfers: %w", err)
+	}
+
+	return count, nil
+}
+
+// HasTransferPath reports whether coins flowed from one user to another,
+// directly or through at most maxDepth hops, since the given time.
+func (r *Repo) HasTransferPath(ctx context.Context, from, to string, since time.Time, maxDepth int) (bool, error) {
+	var found bool
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		WITH RECURSIVE reach (username, depth) AS (
+			SELECT receiver_username, 1
+			FROM transactions
+			WHERE sender_username = $1 AND created_at >= $3 AND kind = $5 AND status IN ($6, $7, $8)
+			UNION
+			SELECT t.receiver_username, reach.depth + 1
+			FROM reach
+			JOIN transactions t ON t.sender_username = reach.username
+			WHERE reach.depth < $4 AND t.created_at >= $3 AND t.kind = $5 AND t.status IN ($6, $7, $8)
+		)
+		SELECT EXISTS (SELECT 1 FROM reach WHERE username = $2)
+	`, from, to, since, maxDepth, models.TransactionKindTransfer,
+		models.TransactionStatusCompleted, models.TransactionStatusPending, models.TransactionStatusHeld).Scan(&found)
+	if err != nil {
+		return false, fmt.Errorf("error searching transfer path: %w", err)
+	}
+
+	return found, nil
+}
+
+func (r *Repo) CreateFraudFlag(ctx context.Context, transactionID int64, verdict models.FraudVerdict) (int64, error) {
+	var id int64
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO fraud_flags (transaction_id, rules, action)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, transactionID, verdict.Rules, verdict.Action).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error creating fraud flag: %w", err)
+	}
+
+	return id, nil
+}
+
+const fraudFlagColumns = `
+	f.id, f.transaction_id, t.sender_username, t.receiver_username, t.amount, t.fee,
+	f.rules, f.action, f.status, COALESCE(f.reviewed_by, ''), f.review_note, f.reviewed_at, f.created_at
+`
+
+func (r *Repo) ListFraudFlags(ctx context.Context, status string) ([]models.FraudFlag, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+fraudFlagColumns+`
+		FROM fraud_flags f
+		JOIN transactions t ON t.id = f.transaction_id
+		WHERE $1 = '' OR f.status = $1
+		ORDER BY f.created_at DESC
+	`, status)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching fraud flags: %w", err)
+	}
+	defer rows.Close()
+
+	var flags []models.FraudFlag
+	for rows.Next() {
+		f, err := scanFraudFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning fraud flag row: %w", err)
+		}
+		flags = append(flags, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading fraud flag rows: %w", err)
+	}
+
+	return flags, nil
+}
+
+// GetFraudFlag returns the flag and locks it for the rest of the enclosing
+// transaction.
+func (r *Repo) GetFraudFlag(ctx context.Context, id int64) (models.FraudFlag, error) {
+	row := r.conn(ctx).QueryRow(ctx, `
+		SELECT `+fraudFlagColumns+`
+		FROM fraud_flags f
+		JOIN transactions t ON t.id = f.transaction_id
+		WHERE f.id = $1
+		FOR UPDATE OF f
+	`, id)
+
+	f, err := scanFraudFlag(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.FraudFlag{}, storage.ErrFraudFlagNotFound
+		}
+		return models.FraudFlag{}, fmt.Errorf("error fetching fraud flag: %w", err)
+	}
+
+	return f, nil
+}
+
+func (r *Repo) ReviewFraudFlag(ctx context.Context, id int64, status, admin, note string) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE fraud_flags
+		SET status = $1, reviewed_by = $2, review_note = $3, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`, status, admin, note, id)
+	if err != nil {
+		return fmt.Errorf("error reviewing fraud flag: %w", err)
+	}
+
+	return nil
+}
+
+func scanFraudFlag(row pgx.Row) (models.FraudFlag, error) {
+	var f models.FraudFlag
+	err := row.Scan(&f.ID, &f.TransactionID, &f.FromUser, &f.ToUser, &f.Amount, &f.Fee,
+		&f.Rules, &f.Action, &f.Status, &f.ReviewedBy, &f.ReviewNote, &f.ReviewedAt, &f.CreatedAt)
+	return f, err
+}
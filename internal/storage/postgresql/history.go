@@ -7,12 +7,13 @@ import (
 	"fmt"
 	"github.com/nglmq/avito-shop/internal/models"
 	"github.com/nglmq/avito-shop/internal/storage"
+	"strings"
 )
 
 func (r *Repo) GetInfo(ctx context.Context, username string) (models.InfoResponse, error) {
 	var info models.InfoResponse
 
-	err := r.db.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
 		SELECT balance
 		FROM balances
 		WHERE username = $1
@@ -24,7 +25,7 @@ func (r *Repo) GetInfo(ctx context.Context, username string) (models.InfoRespons
 		return models.InfoResponse{}, fmt.Errorf("error fetching balance: %w", err)
 	}
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
 		SELECT item_name, SUM(amount) AS total_quantity
 		FROM purchases 
 		WHERE username = $1
@@ -50,14 +51,15 @@ func (r *Repo) GetInfo(ctx context.Context, username string) (models.InfoRespons
 		})
 	}
 
-	transactionRows, err := r.db.Query(ctx, `
+	transactionRows, err := r.conn(ctx).Query(ctx, `
 		SELECT 
 			sender_username,
 			receiver_username,
-			amount
+			amount,
+			fee
 		FROM transactions
-		WHERE sender_username = $1 OR receiver_username = $1
-	`, username)
+		WHERE (sender_username = $1 OR receiver_username = $1) AND status = $2
+	`, username, models.TransactionStatusCompleted)
 	if err != nil {
 		return models.InfoResponse{}, fmt.Errorf("error fetching transaction history: %w", err)
 	}
@@ -65,8 +67,8 @@ func (r *Repo) GetInfo(ctx context.Context, username string) (models.InfoRespons
 
 	for transactionRows.Next() {
 		var senderUsername, receiverUsername string
-		var amount int
-		if err := transactionRows.Scan(&senderUsername, &receiverUsername, &amount); err != nil {
+		var amount, fee int
+		if err := transactionRows.Scan(&senderUsername, &receiverUsername, &amount, &fee); err != nil {
 			return models.InfoResponse{}, fmt.Errorf("error scanning transaction history row: %w", err)
 		}
 
@@ -74,6 +76,7 @@ func (r *Repo) GetInfo(ctx context.Context, username string) (models.InfoRespons
 			info.CoinHistory.Sent = append(info.CoinHistory.Sent, models.TransactionSentHistory{
 				ToUser: receiverUsername,
 				Amount: amount,
+				Fee:    fee,
 			})
 		} else if receiverUsername == username {
 			info.CoinHistory.Received = append(info.CoinHistory.Received, models.TransactionReceivedHistory{
@@ -89,3 +92,92 @@ func (r *Repo) GetInfo(ctx context.Context, username string) (models.InfoRespons
 
 	return info, nil
 }
+
+// GetHistory returns up to filter.Limit entries of the user's history, newest
+// first. Every branch of the union is filtered and limited on its own so that
+// it can use the (username, created_at) indexes.
+func (r *Repo) GetHistory(ctx context.Context, username string, filter models.HistoryFilter) ([]models.HistoryEntry, error) {
+	args := []any{username}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := func(source, counterpartyColumn string) string {
+		var conds []string
+		if filter.From != nil {
+			conds = append(conds, "created_at >= "+arg(*filter.From))
+		}
+		if filter.To != nil {
+			conds = append(conds, "created_at < "+arg(*filter.To))
+		}
+		if counterpartyColumn != "" && filter.Counterparty != "" {
+			conds = append(conds, counterpartyColumn+" = "+arg(filter.Counterparty))
+		}
+		if c := filter.After; c != nil {
+			conds = append(conds, fmt.Sprintf("(created_at, '%s'::text, id) < (%s, %s::text, %s)",
+				source, arg(c.Timestamp), arg(c.Source), arg(c.ID)))
+		}
+		if len(conds) == 0 {
+			return ""
+		}
+		return " AND " + strings.Join(conds, " AND ")
+	}
+
+	var branches []string
+	if filter.Direction == "" || filter.Direction == models.HistoryDirectionSent {
+		branches = append(branches, `
+			SELECT 't' AS source, id, 'sent' AS type, receiver_username AS counterparty,
+				'' AS item, 0 AS quantity, amount, fee, status, kind, created_at
+			FROM transactions
+			WHERE sender_username = $1`+where(models.HistorySourceTransaction, "receiver_username"))
+	}
+	if filter.Direction == "" || filter.Direction == models.HistoryDirectionReceived {
+		branches = append(branches, `
+			SELECT 't' AS source, id, 'received' AS type, sender_username AS counterparty,
+				'' AS item, 0 AS quantity, amount, 0 AS fee, status, kind, created_at
+			FROM transactions
+			WHERE receiver_username = $1`+where(models.HistorySourceTransaction, "sender_username"))
+	}
+	if (filter.Direction == "" || filter.Direction == models.HistoryDirectionPurchases) && filter.Counterparty == "" {
+		branches = append(branches, `
+			SELECT 'p' AS source, id, 'purchase' AS type, '' AS counterparty,
+				item_name AS item, amount AS quantity, total_price AS amount, 0 AS fee, '' AS status, '' AS kind, created_at
+			FROM purchases
+			WHERE username = $1`+where(models.HistorySourcePurchase, ""))
+	}
+	if len(branches) == 0 {
+		return nil, nil
+	}
+
+	limit := arg(filter.Limit)
+	for i, b := range branches {
+		branches[i] = "(" + b + "\n\t\t\tORDER BY created_at DESC, id DESC LIMIT " + limit + ")"
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, type, counterparty, item, quantity, amount, fee, status, kind, created_at
+		FROM (`+strings.Join(branches, " UNION ALL ")+`) h
+		ORDER BY created_at DESC, source DESC, id DESC
+		LIMIT `+limit, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.HistoryEntry
+	for rows.Next() {
+		var e models.HistoryEntry
+		if err := rows.Scan(&e.ID, &e.Type, &e.Counterparty, &e.Item, &e.Quantity,
+			&e.Amount, &e.Fee, &e.Status, &e.Kind, &e.Timestamp); err != nil {
+			return nil, fmt.Errorf("error scanning history row: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history rows: %w", err)
+	}
+
+	return entries, nil
+}
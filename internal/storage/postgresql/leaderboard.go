@@ -0,0 +1,126 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+// GetTopBalances ranks users by their current balance. Users who opted out
+// of leaderboards and the exclude account, typically the fee account, are
+// skipped.
+func (r *Repo) GetTopBalances(ctx context.Context, exclude string, limit int) ([]models.LeaderboardEntry, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT b.username, b.balance
+		FROM balances b
+		JOIN users u ON u.username = b.username
+		WHERE NOT u.leaderboard_opt_out AND b.username <> $1
+		ORDER BY b.balance DESC, b.username
+		LIMIT $2
+	`, exclude, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching top balances: %w", err)
+	}
+
+	return scanLeaderboard(rows)
+}
+
+// GetTopSenders ranks users by the coins they sent in completed transfers
+// since the given time, or ever when since is nil.
+func (r *Repo) GetTopSenders(ctx context.Context, since *time.Time, limit int) ([]models.LeaderboardEntry, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT t.sender_username, SUM(t.amount)
+		FROM transactions t
+		JOIN users u ON u.username = t.sender_username
+		WHERE NOT u.leaderboard_opt_out
+			AND t.status = $1 AND t.kind = $2
+			AND ($3::timestamp IS NULL OR t.created_at >= $3)
+		GROUP BY t.sender_username
+		ORDER BY SUM(t.amount) DESC, t.sender_username
+		LIMIT $4
+	`, models.TransactionStatusCompleted, models.TransactionKindTransfer, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching top senders: %w", err)
+	}
+
+	return scanLeaderboard(rows)
+}
+
+// GetTopReceivers ranks users by the coins they received in completed
+// transfers since the given time, or ever when since is nil.
+func (r *Repo) GetTopReceivers(ctx context.Context, since *time.Time, limit int) ([]models.LeaderboardEntry, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT t.receiver_username, SUM(t.amount)
+		FROM transactions t
+		JOIN users u ON u.username = t.receiver_username
+		WHERE NOT u.leaderboard_opt_out
+			AND t.status = $1 AND t.kind = $2
+			AND ($3::timestamp IS NULL OR t.created_at >= $3)
+		GROUP BY t.receiver_username
+		ORDER BY SUM(t.amount) DESC, t.receiver_username
+		LIMIT $4
+	`, models.TransactionStatusCompleted, models.TransactionKindTransfer, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching top receivers: %w", err)
+	}
+
+	return scanLeaderboard(rows)
+}
+
+// GetTopCollectors ranks users by how many of the item they bought since the
+// given time, or ever when since is nil.
+func (r *Repo) GetTopCollectors(ctx context.Context, item string, since *time.Time, limit int) ([]models.LeaderboardEntry, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT p.username, SUM(p.amount)
+		FROM purchases p
+		JOIN users u ON u.username = p.username
+		WHERE NOT u.leaderboard_opt_out
+			AND p.item_name = $1
+			AND ($2::timestamp IS NULL OR p.created_at >= $2)
+		GROUP BY p.username
+		ORDER BY SUM(p.amount) DESC, p.username
+		LIMIT $3
+	`, item, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching top collectors: %w", err)
+	}
+
+	return scanLeaderboard(rows)
+}
+
+func (r *Repo) SetLeaderboardOptOut(ctx context.Context, username string, optOut bool) error {
+	tag, err := r.conn(ctx).Exec(ctx,
+		"UPDATE users SET leaderboard_opt_out = $2, updated_at = $3 WHERE username = $1",
+		username, optOut, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error updating leaderboard opt-out: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func scanLeaderboard(rows pgx.Rows) ([]models.LeaderboardEntry, error) {
+	defer rows.Close()
+
+	var entries []models.LeaderboardEntry
+	for rows.Next() {
+		var e models.LeaderboardEntry
+		if err := rows.Scan(&e.Username, &e.Value); err != nil {
+			return nil, fmt.Errorf("error scanning leaderboard row: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading leaderboard rows: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,92 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// LockLoginAttempts returns the failure counter of key, creating an empty one
+// if there is none, and locks it until the transaction in ctx ends. Failures
+// before windowStart are not counted.
+func (r *Repo) LockLoginAttempts(ctx context.Context, key string, now, windowStart time.Time) (models.LoginAttempts, error) {
+	_, err := r.conn(ctx).Exec(ctx, `
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES ($1, 0, $2)
+		ON CONFLICT (key) DO NOTHING
+	`, key, now)
+	if err != nil {
+		return models.LoginAttempts{}, fmt.Errorf("error creating login attempts: %w", err)
+	}
+
+	a := models.LoginAttempts{Key: key}
+	err = r.conn(ctx).QueryRow(ctx, `
+		SELECT CASE WHEN last_failure_at < $2 THEN 0 ELSE failures END, last_failure_at
+		FROM login_attempts
+		WHERE key = $1
+		FOR UPDATE
+	`, key, windowStart).Scan(&a.Failures, &a.LastFailureAt)
+	if err != nil {
+		return models.LoginAttempts{}, fmt.Errorf("error locking login attempts: %w", err)
+	}
+
+	return a, nil
+}
+
+// RecordLoginFailure counts a failed login for key and returns the updated
+// counter. Failures before windowStart are forgotten, so the count starts
+// over.
+func (r *Repo) RecordLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (models.LoginAttempts, error) {
+	a := models.LoginAttempts{Key: key}
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at < $3 THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures, last_failure_at
+	`, key, now, windowStart).Scan(&a.Failures, &a.LastFailureAt)
+	if err != nil {
+		return models.LoginAttempts{}, fmt.Errorf("error recording login failure: %w", err)
+	}
+
+	return a, nil
+}
+
+// ReleaseLoginAttempt takes back one failure counted for key.
+func (r *Repo) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE login_attempts
+		SET failures = failures - 1
+		WHERE key = $1 AND failures > 0
+	`, key)
+	if err != nil {
+		return fmt.Errorf("error releasing login attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, err := r.conn(ctx).Exec(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+	if err != nil {
+		return fmt.Errorf("error resetting login attempts: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.conn(ctx).Exec(ctx, "DELETE FROM login_attempts WHERE last_failure_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting stale login attempts: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
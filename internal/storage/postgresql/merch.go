@@ -3,10 +3,12 @@ package postgresql
 import (
 	"context"
 	"fmt"
+
+	"github.com/nglmq/avito-shop/internal/models"
 )
 
 func (r *Repo) AddPurchase(ctx context.Context, username, itemName string, amount, totalPrice int) error {
-	_, err := r.db.Exec(ctx, `
+	_, err := r.conn(ctx).Exec(ctx, `
 		INSERT INTO purchases (username, item_name, amount, total_price)
 		VALUES ($1, $2, $3, $4)
 	`, username, itemName, amount, totalPrice)
@@ -15,3 +17,40 @@ func (r *Repo) AddPurchase(ctx context.Context, username, itemName string, amoun
 	}
 	return nil
 }
+
+// ListPurchases returns the user's purchases with an id below before (all of
+// them when before is 0), newest first.
+func (r *Repo) ListPurchases(ctx context.Context, username, item string, before int64, limit int) ([]models.Purchase, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, item_name, amount, total_price / NULLIF(amount, 0), total_price, created_at
+		FROM purchases
+		WHERE username = $1
+			AND ($2 = '' OR item_name = $2)
+			AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`, username, item, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching purchases: %w", err)
+	}
+	defer rows.Close()
+
+	var purchases []models.Purchase
+	for rows.Next() {
+		var p models.Purchase
+		var unitPrice *int
+		if err := rows.Scan(&p.ID, &p.Item, &p.Quantity, &unitPrice, &p.TotalPrice, &p.PurchasedAt); err != nil {
+			return nil, fmt.Errorf("error scanning purchase row: %w", err)
+		}
+		if unitPrice != nil {
+			p.UnitPrice = *unitPrice
+		}
+		purchases = append(purchases, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading purchase rows: %w", err)
+	}
+
+	return purchases, nil
+}
@@ -0,0 +1,93 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// InsertOutboxEvent records an event in the transaction bound to ctx.
+func (r *Repo) InsertOutboxEvent(ctx context.Context, eventType, username string, payload []byte, now time.Time) (int64, error) {
+	var id int64
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO outbox (event_type, username, payload, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, eventType, username, payload, now).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting outbox event: %w", err)
+	}
+
+	return id, nil
+}
+
+// ListUndeliveredOutboxEvents returns the oldest events that are neither
+// delivered nor parked in order, locking them until the surrounding
+// transaction ends so that only one relay works through the outbox at a time.
+func (r *Repo) ListUndeliveredOutboxEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, event_type, username, payload, attempts, created_at
+		FROM outbox
+		WHERE delivered_at IS NULL AND parked_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var ev models.OutboxEvent
+		if err := rows.Scan(&ev.ID, &ev.Type, &ev.Username, &ev.Payload, &ev.Attempts, &ev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning outbox row: %w", err)
+		}
+		events = append(events, ev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading outbox rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *Repo) MarkOutboxEventDelivered(ctx context.Context, id int64, now time.Time) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE outbox SET delivered_at = $2, attempts = attempts + 1, last_error = '' WHERE id = $1
+	`, id, now)
+	if err != nil {
+		return fmt.Errorf("error marking outbox event delivered: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) RecordOutboxFailure(ctx context.Context, id int64, lastError string) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1
+	`, id, lastError)
+	if err != nil {
+		return fmt.Errorf("error recording outbox failure: %w", err)
+	}
+
+	return nil
+}
+
+// ParkOutboxEvent records a final failed attempt and takes the event out of
+// the relay's queue.
+func (r *Repo) ParkOutboxEvent(ctx context.Context, id int64, lastError string, now time.Time) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE outbox SET attempts = attempts + 1, last_error = $2, parked_at = $3 WHERE id = $1
+	`, id, lastError, now)
+	if err != nil {
+		return fmt.Errorf("error parking outbox event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+const paymentRequestColumns = `
+	id, requester_username, payer_username, amount, note, status,
+	transaction_id, created_at, expires_at, resolved_at
+`
+
+func (r *Repo) CreatePaymentRequest(ctx context.Context, req models.PaymentRequest) (int64, error) {
+	var id int64
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO payment_requests (requester_username, payer_username, amount, note, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, req.Requester, req.Payer, req.Amount, req.Note, req.Status, req.ExpiresAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error creating payment request: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetPaymentRequest returns the payment request and locks it for the rest of
+// the enclosing transaction.
+func (r *Repo) GetPaymentRequest(ctx context.Context, id int64) (models.PaymentRequest, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+paymentRequestColumns+`
+		FROM payment_requests
+		WHERE id = $1
+		FOR UPDATE
+	`, id)
+	if err != nil {
+		return models.PaymentRequest{}, fmt.Errorf("error fetching payment request: %w", err)
+	}
+
+	requests, err := scanPaymentRequests(rows)
+	if err != nil {
+		return models.PaymentRequest{}, err
+	}
+	if len(requests) == 0 {
+		return models.PaymentRequest{}, storage.ErrPaymentRequestNotFound
+	}
+
+	return requests[0], nil
+}
+
+func (r *Repo) ListPaymentRequests(ctx context.Context, username, status string) ([]models.PaymentRequest, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+paymentRequestColumns+`
+		FROM payment_requests
+		WHERE (requester_username = $1 OR payer_username = $1)
+			AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+	`, username, status)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching payment requests: %w", err)
+	}
+
+	return scanPaymentRequests(rows)
+}
+
+func (r *Repo) ResolvePaymentRequest(ctx context.Context, id int64, status string, transactionID *int64) error {
+	tag, err := r.conn(ctx).Exec(ctx, `
+		UPDATE payment_requests
+		SET status = $1, transaction_id = $2, resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = $4
+	`, status, transactionID, id, models.PaymentRequestStatusPending)
+	if err != nil {
+		return fmt.Errorf("error resolving payment request: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrPaymentRequestNotFound
+	}
+
+	return nil
+}
+
+// ReopenPaymentRequest makes the request paid with the transaction pending
+// again. It is a no-op when no request was paid with it.
+func (r *Repo) ReopenPaymentRequest(ctx context.Context, transactionID int64) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE payment_requests
+		SET status = $1, transaction_id = NULL, resolved_at = NULL
+		WHERE transaction_id = $2 AND status = $3
+	`, models.PaymentRequestStatusPending, transactionID, models.PaymentRequestStatusPaid)
+	if err != nil {
+		return fmt.Errorf("error reopening payment request: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) ExpirePaymentRequests(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+		UPDATE payment_requests
+		SET status = $1, resolved_at = CURRENT_TIMESTAMP
+		WHERE status = $2 AND expires_at <= $3
+	`, models.PaymentRequestStatusExpired, models.PaymentRequestStatusPending, now)
+	if err != nil {
+		return 0, fmt.Errorf("error expiring payment requests: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func scanPaymentRequests(rows pgx.Rows) ([]models.PaymentRequest, error) {
+	defer rows.Close()
+
+	var requests []models.PaymentRequest
+	for rows.Next() {
+		var req models.PaymentRequest
+		err := rows.Scan(&req.ID, &req.Requester, &req.Payer, &req.Amount, &req.Note, &req.Status,
+			&req.TransactionID, &req.CreatedAt, &req.ExpiresAt, &req.ResolvedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning payment request row: %w", err)
+		}
+		requests = append(requests, req)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading payment request rows: %w", err)
+	}
+
+	return requests, nil
+}
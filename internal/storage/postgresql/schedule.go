@@ -0,0 +1,109 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+const scheduledTransferColumns = `
+	id, sender_username, receiver_username, amount, schedule, status,
+	next_run_at, last_run_at, last_error, failure_count, created_at
+`
+
+func (r *Repo) CreateScheduledTransfer(ctx context.Context, t models.ScheduledTransfer) (int64, error) {
+	var id int64
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO scheduled_transfers (sender_username, receiver_username, amount, schedule, status, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, t.FromUser, t.ToUser, t.Amount, t.Schedule, t.Status, t.NextRunAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error creating scheduled transfer: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *Repo) ListScheduledTransfers(ctx context.Context, username string) ([]models.ScheduledTransfer, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+scheduledTransferColumns+`
+		FROM scheduled_transfers
+		WHERE sender_username = $1
+		ORDER BY created_at DESC
+	`, username)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching scheduled transfers: %w", err)
+	}
+
+	return scanScheduledTransfers(rows)
+}
+
+func (r *Repo) CancelScheduledTransfer(ctx context.Context, username string, id int64) (bool, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+		UPDATE scheduled_transfers
+		SET status = $1
+		WHERE id = $2 AND sender_username = $3 AND status = $4
+	`, models.ScheduleStatusCancelled, id, username, models.ScheduleStatusActive)
+	if err != nil {
+		return false, fmt.Errorf("error cancelling scheduled transfer: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ClaimDueScheduledTransfers locks active transfers due at now. Rows locked by
+// another scheduler instance are skipped, so it must run inside a transaction.
+func (r *Repo) ClaimDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]models.ScheduledTransfer, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+scheduledTransferColumns+`
+		FROM scheduled_transfers
+		WHERE status = $1 AND next_run_at <= $2
+		ORDER BY next_run_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`, models.ScheduleStatusActive, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming scheduled transfers: %w", err)
+	}
+
+	return scanScheduledTransfers(rows)
+}
+
+func (r *Repo) UpdateScheduledTransferRun(ctx context.Context, t models.ScheduledTransfer) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE scheduled_transfers
+		SET status = $1, next_run_at = $2, last_run_at = $3, last_error = $4, failure_count = $5
+		WHERE id = $6
+	`, t.Status, t.NextRunAt, t.LastRunAt, t.LastError, t.FailureCount, t.ID)
+	if err != nil {
+		return fmt.Errorf("error updating scheduled transfer: %w", err)
+	}
+
+	return nil
+}
+
+func scanScheduledTransfers(rows pgx.Rows) ([]models.ScheduledTransfer, error) {
+	defer rows.Close()
+
+	var transfers []models.ScheduledTransfer
+	for rows.Next() {
+		var t models.ScheduledTransfer
+		err := rows.Scan(&t.ID, &t.FromUser, &t.ToUser, &t.Amount, &t.Schedule, &t.Status,
+			&t.NextRunAt, &t.LastRunAt, &t.LastError, &t.FailureCount, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning scheduled transfer row: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading scheduled transfer rows: %w", err)
+	}
+
+	return transfers, nil
+}
@@ -0,0 +1,122 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+)
+
+// GetStatsBuckets sums the coins the user spent on merch, sent and received
+// in [from, to), grouped by date_trunc(bucket, created_at). Periods without
+// any activity are omitted.
+func (r *Repo) GetStatsBuckets(ctx context.Context, username, bucket string, from, to time.Time) ([]models.StatsBucket, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT bucket, SUM(spent), SUM(sent), SUM(fees), SUM(received)
+		FROM (
+			SELECT date_trunc($2, created_at) AS bucket, 0 AS spent, amount AS sent, fee AS fees, 0 AS received
+			FROM transactions
+			WHERE sender_username = $1 AND status = $5 AND created_at >= $3 AND created_at < $4
+			UNION ALL
+			SELECT date_trunc($2, created_at), 0, 0, 0, amount
+			FROM transactions
+			WHERE receiver_username = $1 AND status = $5 AND created_at >= $3 AND created_at < $4
+			UNION ALL
+			SELECT date_trunc($2, created_at), total_price, 0, 0, 0
+			FROM purchases
+			WHERE username = $1 AND created_at >= $3 AND created_at < $4
+		) s
+		GROUP BY bucket
+		ORDER BY bucket
+	`, username, bucket, from, to, models.TransactionStatusCompleted)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching stats buckets: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []models.StatsBucket
+	for rows.Next() {
+		var b models.StatsBucket
+		if err := rows.Scan(&b.Start, &b.Spent, &b.Sent, &b.Fees, &b.Received); err != nil {
+			return nil, fmt.Errorf("error scanning stats bucket row: %w", err)
+		}
+		buckets = append(buckets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading stats bucket rows: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// GetTopCounterparties returns the users the given user exchanged the most
+// coins with in [from, to).
+func (r *Repo) GetTopCounterparties(ctx context.Context, username string, from, to time.Time, limit int) ([]models.CounterpartyStats, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT counterparty, SUM(sent), SUM(received), COUNT(*)
+		FROM (
+			SELECT receiver_username AS counterparty, amount AS sent, 0 AS received
+			FROM transactions
+			WHERE sender_username = $1 AND status = $4 AND created_at >= $2 AND created_at < $3
+			UNION ALL
+			SELECT sender_username, 0, amount
+			FROM transactions
+			WHERE receiver_username = $1 AND status = $4 AND created_at >= $2 AND created_at < $3
+		) c
+		GROUP BY counterparty
+		ORDER BY SUM(sent) + SUM(received) DESC, counterparty
+		LIMIT $5
+	`, username, from, to, models.TransactionStatusCompleted, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching top counterparties: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []models.CounterpartyStats
+	for rows.Next() {
+		var c models.CounterpartyStats
+		if err := rows.Scan(&c.Username, &c.Sent, &c.Received, &c.Transfers); err != nil {
+			return nil, fmt.Errorf("error scanning counterparty row: %w", err)
+		}
+		stats = append(stats, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading counterparty rows: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetTopItems returns the items the user spent the most coins on in [from, to).
+func (r *Repo) GetTopItems(ctx context.Context, username string, from, to time.Time, limit int) ([]models.ItemStats, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT item_name, SUM(amount), SUM(total_price)
+		FROM purchases
+		WHERE username = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY item_name
+		ORDER BY SUM(total_price) DESC, item_name
+		LIMIT $4
+	`, username, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching top items: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []models.ItemStats
+	for rows.Next() {
+		var i models.ItemStats
+		if err := rows.Scan(&i.Item, &i.Quantity, &i.Spent); err != nil {
+			return nil, fmt.Errorf("error scanning item row: %w", err)
+		}
+		stats = append(stats, i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading item rows: %w", err)
+	}
+
+	return stats, nil
+}
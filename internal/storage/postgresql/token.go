@@ -0,0 +1,223 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+func (r *Repo) CreateRefreshToken(ctx context.Context, tokenHash string, t models.RefreshToken) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		INSERT INTO refresh_tokens (token_hash, family_id, username, access_token_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, tokenHash, t.FamilyID, t.Username, t.AccessTokenID, t.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("error creating refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshTokenForUpdate looks up a refresh token by hash and locks it
+// until the end of the transaction, so that it cannot be rotated twice.
+func (r *Repo) GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	var t models.RefreshToken
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT id, family_id, username, access_token_id, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, tokenHash).Scan(&t.ID, &t.FamilyID, &t.Username, &t.AccessTokenID, &t.ExpiresAt, &t.UsedAt, &t.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RefreshToken{}, storage.ErrRefreshTokenNotFound
+		}
+		return models.RefreshToken{}, fmt.Errorf("error fetching refresh token: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *Repo) MarkRefreshTokenUsed(ctx context.Context, id int64, now time.Time) error {
+	_, err := r.conn(ctx).Exec(ctx, "UPDATE refresh_tokens SET used_at = $2 WHERE id = $1", id, now)
+	if err != nil {
+		return fmt.Errorf("error marking refresh token used: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshTokenFamily returns the family of the refresh token issued along
+// with the given access token.
+func (r *Repo) GetRefreshTokenFamily(ctx context.Context, accessTokenID string) (string, error) {
+	var familyID string
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT family_id FROM refresh_tokens WHERE access_token_id = $1
+	`, accessTokenID).Scan(&familyID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", storage.ErrRefreshTokenNotFound
+		}
+		return "", fmt.Errorf("error fetching refresh token family: %w", err)
+	}
+
+	return familyID, nil
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token of the family and
+// returns the ids of the access tokens issued with them.
+func (r *Repo) RevokeRefreshTokenFamily(ctx context.Context, familyID string, now time.Time) ([]string, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = COALESCE(revoked_at, $2)
+		WHERE family_id = $1
+		RETURNING access_token_id
+	`, familyID, now)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking refresh tokens: %w", err)
+	}
+
+	return scanAccessTokenIDs(rows)
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of the user that is not
+// revoked yet and returns the ids of the access tokens issued with them.
+func (r *Repo) RevokeUserRefreshTokens(ctx context.Context, username string, now time.Time) ([]string, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = $2
+		WHERE username = $1 AND revoked_at IS NULL
+		RETURNING access_token_id
+	`, username, now)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking refresh tokens: %w", err)
+	}
+
+	return scanAccessTokenIDs(rows)
+}
+
+func scanAccessTokenIDs(rows pgx.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning access token id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading access token ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+// RevokeTokens denies the access tokens with the given ids until expiresAt,
+// after which they are rejected for having expired anyway.
+func (r *Repo) RevokeTokens(ctx context.Context, ids []string, expiresAt time.Time) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		SELECT unnest($1::text[]), $2
+		ON CONFLICT (jti) DO NOTHING
+	`, ids, expiresAt)
+	if err != nil {
+		return fmt.Errorf("error revoking tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) IsTokenRevoked(ctx context.Context, id string) (bool, error) {
+	var revoked bool
+
+	err := r.conn(ctx).QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", id).
+		Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("error checking token revocation: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpiredTokens drops refresh tokens, password reset tokens and
+// revocations that have expired by now and returns how many rows were removed.
+func (r *Repo) DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	refresh, err := r.conn(ctx).Exec(ctx, "DELETE FROM refresh_tokens WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired refresh tokens: %w", err)
+	}
+
+	revoked, err := r.conn(ctx).Exec(ctx, "DELETE FROM revoked_tokens WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired revocations: %w", err)
+	}
+
+	resets, err := r.conn(ctx).Exec(ctx, "DELETE FROM password_reset_tokens WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired password reset tokens: %w", err)
+	}
+
+	return refresh.RowsAffected() + revoked.RowsAffected() + resets.RowsAffected(), nil
+}
+
+// CreatePasswordResetToken stores a reset token for the user, replacing any
+// the user has not redeemed yet so that only the latest one works.
+func (r *Repo) CreatePasswordResetToken(ctx context.Context, tokenHash string, t models.PasswordResetToken) error {
+	_, err := r.conn(ctx).Exec(ctx,
+		"DELETE FROM password_reset_tokens WHERE username = $1 AND used_at IS NULL", t.Username)
+	if err != nil {
+		return fmt.Errorf("error deleting previous password reset tokens: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, `
+		INSERT INTO password_reset_tokens (token_hash, username, created_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, tokenHash, t.Username, t.CreatedBy, t.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("error creating password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// GetPasswordResetTokenForUpdate looks up a reset token by hash and locks it
+// until the end of the transaction, so that it cannot be redeemed twice.
+func (r *Repo) GetPasswordResetTokenForUpdate(ctx context.Context, tokenHash string) (models.PasswordResetToken, error) {
+	var t models.PasswordResetToken
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT id, username, created_by, expires_at, used_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, tokenHash).Scan(&t.ID, &t.Username, &t.CreatedBy, &t.ExpiresAt, &t.UsedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.PasswordResetToken{}, storage.ErrPasswordResetTokenNotFound
+		}
+		return models.PasswordResetToken{}, fmt.Errorf("error fetching password reset token: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *Repo) MarkPasswordResetTokenUsed(ctx context.Context, id int64, now time.Time) error {
+	_, err := r.conn(ctx).Exec(ctx, "UPDATE password_reset_tokens SET used_at = $2 WHERE id = $1", id, now)
+	if err != nil {
+		return fmt.Errorf("error marking password reset token used: %w", err)
+	}
+
+	return nil
+}
@@ -2,16 +2,304 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
 )
 
-func (r *Repo) CreateTransaction(ctx context.Context, senderUsername, receiverUsername string, amount int) error {
-	_, err := r.db.Exec(ctx, `
+func (r *Repo) CreateTransaction(ctx context.Context, senderUsername, receiverUsername string, amount int) (int64, error) {
+	var id int64
+
+	err := r.conn(ctx).QueryRow(ctx, `
 		INSERT INTO transactions (sender_username, receiver_username, amount)
 		VALUES ($1, $2, $3)
-	`, senderUsername, receiverUsername, amount)
+		RETURNING id
+	`, senderUsername, receiverUsername, amount).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *Repo) CreatePendingTransaction(ctx context.Context, senderUsername, receiverUsername string, amount int, expiresAt time.Time) (int64, error) {
+	var id int64
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO transactions (sender_username, receiver_username, amount, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, senderUsername, receiverUsername, amount, models.TransactionStatusPending, expiresAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error creating pending transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *Repo) GetPendingTransaction(ctx context.Context, id int64) (models.PendingTransfer, error) {
+	var t models.PendingTransfer
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT id, sender_username, receiver_username, amount, fee, status, created_at, expires_at
+		FROM transactions
+		WHERE id = $1 AND expires_at IS NOT NULL
+		FOR UPDATE
+	`, id).Scan(&t.ID, &t.FromUser, &t.ToUser, &t.Amount, &t.Fee, &t.Status, &t.CreatedAt, &t.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.PendingTransfer{}, storage.ErrTransactionNotFound
+		}
+		return models.PendingTransfer{}, fmt.Errorf("error fetching pending transaction: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *Repo) ListPendingTransactions(ctx context.Context, username string) ([]models.PendingTransfer, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, sender_username, receiver_username, amount, fee, status, created_at, expires_at
+		FROM transactions
+		WHERE status = $1 AND (sender_username = $2 OR receiver_username = $2)
+		ORDER BY created_at
+	`, models.TransactionStatusPending, username)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pending transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transfers []models.PendingTransfer
+	for rows.Next() {
+		var t models.PendingTransfer
+		if err := rows.Scan(&t.ID, &t.FromUser, &t.ToUser, &t.Amount, &t.Fee, &t.Status, &t.CreatedAt, &t.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("error scanning pending transaction row: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading pending transaction rows: %w", err)
+	}
+
+	return transfers, nil
+}
+
+func (r *Repo) ListExpiredPendingTransactions(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id
+		FROM transactions
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3
+	`, models.TransactionStatusPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching expired transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning expired transaction row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading expired transaction rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *Repo) ResolvePendingTransaction(ctx context.Context, id int64, status string) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE transactions
+		SET status = $1, resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = $3
+	`, status, id, models.TransactionStatusPending)
+	if err != nil {
+		return fmt.Errorf("error resolving pending transaction: %w", err)
+	}
+
+	return nil
+}
+
+// HoldPendingTransaction moves an accepted pending transfer to fraud review.
+// Its coins and fee stay off both balances until an admin resolves it.
+func (r *Repo) HoldPendingTransaction(ctx context.Context, id int64) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE transactions
+		SET status = $1
+		WHERE id = $2 AND status = $3
+	`, models.TransactionStatusHeld, id, models.TransactionStatusPending)
+	if err != nil {
+		return fmt.Errorf("error holding pending transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetTransactionFee records the fee the sender paid on top of the amount.
+func (r *Repo) SetTransactionFee(ctx context.Context, id int64, fee int) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE transactions SET fee = $1 WHERE id = $2
+	`, fee, id)
+	if err != nil {
+		return fmt.Errorf("error setting transaction fee: %w", err)
+	}
+
+	return nil
+}
+
+// CreateHeldTransaction records a transfer whose coins have left the sender
+// but are kept from the recipient until an admin reviews it.
+func (r *Repo) CreateHeldTransaction(ctx context.Context, senderUsername, receiverUsername string, amount int) (int64, error) {
+	var id int64
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO transactions (sender_username, receiver_username, amount, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, senderUsername, receiverUsername, amount, models.TransactionStatusHeld).Scan(&id)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("error creating held transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *Repo) ResolveHeldTransaction(ctx context.Context, id int64, status string) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE transactions
+		SET status = $1, resolved_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = $3
+	`, status, id, models.TransactionStatusHeld)
+	if err != nil {
+		return fmt.Errorf("error resolving held transaction: %w", err)
 	}
 
 	return nil
 }
+
+// CreateTransferBatch records a batch and links the given transactions to it.
+func (r *Repo) CreateTransferBatch(ctx context.Context, senderUsername string, total int, transactionIDs []int64) (int64, error) {
+	var id int64
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO transfer_batches (sender_username, total_amount)
+		VALUES ($1, $2)
+		RETURNING id
+	`, senderUsername, total).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error creating transfer batch: %w", err)
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, `
+		UPDATE transactions SET batch_id = $1 WHERE id = ANY($2)
+	`, id, transactionIDs)
+	if err != nil {
+		return 0, fmt.Errorf("error linking transactions to batch: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetTransaction returns the transaction and locks it for the rest of the
+// enclosing transaction.
+func (r *Repo) GetTransaction(ctx context.Context, id int64) (models.Transaction, error) {
+	var t models.Transaction
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT id, sender_username, receiver_username, amount, status, kind, created_at
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&t.ID, &t.FromUser, &t.ToUser, &t.Amount, &t.Status, &t.Kind, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Transaction{}, storage.ErrTransactionNotFound
+		}
+		return models.Transaction{}, fmt.Errorf("error fetching transaction: %w", err)
+	}
+
+	return t, nil
+}
+
+// CreateReversal records the compensating transaction and links it to the
+// reversed one. A transaction can only be reversed once.
+func (r *Repo) CreateReversal(ctx context.Context, rev models.Reversal, from, to string) (models.Reversal, error) {
+	var reversed bool
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM transaction_reversals WHERE transaction_id = $1)
+	`, rev.TransactionID).Scan(&reversed)
+	if err != nil {
+		return models.Reversal{}, fmt.Errorf("error checking reversal: %w", err)
+	}
+	if reversed {
+		return models.Reversal{}, storage.ErrAlreadyReversed
+	}
+
+	err = r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO transactions (sender_username, receiver_username, amount, kind)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, from, to, rev.Amount, models.TransactionKindReversal).Scan(&rev.CompensatingTransactionID)
+	if err != nil {
+		return models.Reversal{}, fmt.Errorf("error creating compensating transaction: %w", err)
+	}
+
+	err = r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO transaction_reversals
+			(transaction_id, compensating_transaction_id, amount, shortfall, reason, admin_username)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, rev.TransactionID, rev.CompensatingTransactionID, rev.Amount, rev.Shortfall, rev.Reason, rev.Admin).
+		Scan(&rev.ID, &rev.CreatedAt)
+	if err != nil {
+		return models.Reversal{}, fmt.Errorf("error recording reversal: %w", err)
+	}
+
+	return rev, nil
+}
+
+// GetSentAmountSince sums completed, pending and held transfers sent by username
+// since the given time.
+func (r *Repo) GetSentAmountSince(ctx context.Context, username string, since time.Time) (int, error) {
+	var sum int
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE sender_username = $1 AND created_at >= $2 AND kind = $3 AND status IN ($4, $5, $6)
+	`, username, since, models.TransactionKindTransfer,
+		models.TransactionStatusCompleted, models.TransactionStatusPending, models.TransactionStatusHeld).Scan(&sum)
+	if err != nil {
+		return 0, fmt.Errorf("error summing sent transactions: %w", err)
+	}
+
+	return sum, nil
+}
+
+// GetReceivedAmountSince sums completed, pending and held transfers addressed to
+// username since the given time.
+func (r *Repo) GetReceivedAmountSince(ctx context.Context, username string, since time.Time) (int, error) {
+	var sum int
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE receiver_username = $1 AND created_at >= $2 AND kind = $3 AND status IN ($4, $5, $6)
+	`, username, since, models.TransactionKindTransfer,
+		models.TransactionStatusCompleted, models.TransactionStatusPending, models.TransactionStatusHeld).Scan(&sum)
+	if err != nil {
+		return 0, fmt.Errorf("error summing received transactions: %w", err)
+	}
+
+	return sum, nil
+}
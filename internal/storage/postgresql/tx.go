@@ -0,0 +1,51 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nglmq/avito-shop/internal/utils/txhook"
+)
+
+type txKey struct{}
+
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// conn returns the transaction bound to ctx by WithinTransaction, or the pool
+// when there is none, so repository methods compose into a single transaction.
+func (r *Repo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+// WithinTransaction runs fn in a transaction, or a savepoint when ctx already
+// carries one. Functions registered with txhook.AfterCommit run once the
+// outermost transaction commits.
+func (r *Repo) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ctx, hooks := txhook.Begin(ctx)
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	hooks.Commit()
+
+	return nil
+}
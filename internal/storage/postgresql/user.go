@@ -6,12 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jackc/pgerrcode"
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/nglmq/avito-shop/internal/storage"
+	"time"
 )
 
 func (r *Repo) SaveUser(ctx context.Context, username, password string) (string, error) {
-	tx, err := r.db.Begin(ctx)
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -19,13 +20,14 @@ func (r *Repo) SaveUser(ctx context.Context, username, password string) (string,
 
 	_, err = tx.Exec(ctx, "INSERT INTO users (username, password_hash) VALUES ($1, $2)", username, password)
 	if err != nil {
-		if err, ok := err.(*pq.Error); ok && err.Code == pgerrcode.UniqueViolation {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
 			return "", storage.ErrUsernameExists
 		}
-		return "", fmt.Errorf("failed to insert history: %w", err)
+		return "", fmt.Errorf("failed to insert user: %w", err)
 	}
 
-	_, err = tx.Exec(ctx, "INSERT INTO balances (username, balance) VALUES ($1, $2)", username, 1000)
+	_, err = tx.Exec(ctx, "INSERT INTO balances (username, balance) VALUES ($1, $2)", username, initialBalance)
 	if err != nil {
 		return "", fmt.Errorf("failed to insert coin balance: %w", err)
 	}
@@ -41,7 +43,7 @@ func (r *Repo) SaveUser(ctx context.Context, username, password string) (string,
 func (r *Repo) GetUserPassword(ctx context.Context, username string) (string, error) {
 	var userPassword string
 
-	err := r.db.QueryRow(
+	err := r.conn(ctx).QueryRow(
 		ctx,
 		"SELECT password_hash FROM users WHERE username = $1", username).
 		Scan(&userPassword)
@@ -56,10 +58,24 @@ func (r *Repo) GetUserPassword(ctx context.Context, username string) (string, er
 	return userPassword, nil
 }
 
+func (r *Repo) UpdateUserPassword(ctx context.Context, username, password string) error {
+	tag, err := r.conn(ctx).Exec(ctx,
+		"UPDATE users SET password_hash = $2, updated_at = $3 WHERE username = $1",
+		username, password, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error updating user password: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	return nil
+}
+
 func (r *Repo) GetUserByUsername(ctx context.Context, username string) (bool, error) {
 	var exists bool
 
-	err := r.db.QueryRow(
+	err := r.conn(ctx).QueryRow(
 		ctx,
 		"SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)", username).
 		Scan(&exists)
@@ -69,3 +85,70 @@ func (r *Repo) GetUserByUsername(ctx context.Context, username string) (bool, er
 
 	return exists, nil
 }
+
+func (r *Repo) GetUserCreatedAt(ctx context.Context, username string) (time.Time, error) {
+	var createdAt time.Time
+
+	err := r.conn(ctx).QueryRow(
+		ctx,
+		"SELECT created_at FROM users WHERE username = $1", username).
+		Scan(&createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, storage.ErrUserNotFound
+		}
+
+		return time.Time{}, err
+	}
+
+	return createdAt, nil
+}
+
+func (r *Repo) GetUserRole(ctx context.Context, username string) (string, error) {
+	var role string
+
+	err := r.conn(ctx).QueryRow(ctx,
+		"SELECT role FROM users WHERE username = $1", username).
+		Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", storage.ErrUserNotFound
+		}
+
+		return "", fmt.Errorf("error fetching user role: %w", err)
+	}
+
+	return role, nil
+}
+
+func (r *Repo) SetUserRole(ctx context.Context, username, role string) error {
+	tag, err := r.conn(ctx).Exec(ctx,
+		"UPDATE users SET role = $2, updated_at = $3 WHERE username = $1",
+		username, role, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error updating user role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// CountUsersWithRole counts the users with the given role, locking them until
+// the end of the transaction so that concurrent role changes cannot leave the
+// count stale.
+func (r *Repo) CountUsersWithRole(ctx context.Context, role string) (int, error) {
+	var count int
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT 1 FROM users WHERE role = $1 FOR UPDATE
+		) locked
+	`, role).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting users with role: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,198 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nglmq/avito-shop/internal/models"
+	"github.com/nglmq/avito-shop/internal/storage"
+)
+
+func (r *Repo) CreateWebhook(ctx context.Context, w models.Webhook) (models.Webhook, error) {
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO webhooks (url, secret, events, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, active
+	`, w.URL, w.Secret, w.Events, w.CreatedBy, w.CreatedAt).Scan(&w.ID, &w.Active)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("error creating webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+func (r *Repo) GetWebhook(ctx context.Context, id int64) (models.Webhook, error) {
+	var w models.Webhook
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT id, url, events, active, created_by, created_at
+		FROM webhooks
+		WHERE id = $1
+	`, id).Scan(&w.ID, &w.URL, &w.Events, &w.Active, &w.CreatedBy, &w.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Webhook{}, storage.ErrWebhookNotFound
+		}
+		return models.Webhook{}, fmt.Errorf("error fetching webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// ListWebhooks returns every webhook without its secret.
+func (r *Repo) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, url, events, active, created_by, created_at
+		FROM webhooks
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		var w models.Webhook
+		if err := rows.Scan(&w.ID, &w.URL, &w.Events, &w.Active, &w.CreatedBy, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning webhook row: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading webhook rows: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// DeactivateWebhook stops deliveries to the webhook, failing whatever is
+// still queued for it.
+func (r *Repo) DeactivateWebhook(ctx context.Context, id int64) error {
+	tag, err := r.conn(ctx).Exec(ctx, "UPDATE webhooks SET active = FALSE WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deactivating webhook: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrWebhookNotFound
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, last_error = 'webhook deactivated'
+		WHERE webhook_id = $1 AND status = $3
+	`, id, models.WebhookDeliveryStatusFailed, models.WebhookDeliveryStatusPending)
+	if err != nil {
+		return fmt.Errorf("error cancelling webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// EnqueueWebhookDeliveries queues the payload for every active webhook
+// subscribed to eventType and returns how many deliveries were queued.
+func (r *Repo) EnqueueWebhookDeliveries(ctx context.Context, eventType string, payload []byte, now time.Time) (int64, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload, next_attempt_at, created_at)
+		SELECT id, $1, $2, $3, $3
+		FROM webhooks
+		WHERE active AND (cardinality(events) = 0 OR $1 = ANY(events))
+	`, eventType, payload, now)
+	if err != nil {
+		return 0, fmt.Errorf("error enqueuing webhook deliveries: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// ClaimDueWebhookDeliveries picks up to limit pending deliveries due at now
+// and pushes their next attempt to leaseUntil, so that a concurrent or
+// restarted worker does not send them again while they are in flight.
+func (r *Repo) ClaimDueWebhookDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET next_attempt_at = $3
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = $1 AND next_attempt_at <= $2
+				ORDER BY next_attempt_at
+				LIMIT $4
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT c.id, c.webhook_id, c.event_type, c.payload, c.status, c.attempts, c.response_status,
+			c.last_error, c.next_attempt_at, c.created_at, c.delivered_at, w.url, w.secret
+		FROM claimed c
+		JOIN webhooks w ON w.id = c.webhook_id
+		ORDER BY c.id
+	`, models.WebhookDeliveryStatusPending, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+
+	return scanWebhookDeliveries(rows, true)
+}
+
+// RecordWebhookAttempt stores the outcome of a delivery attempt.
+func (r *Repo) RecordWebhookAttempt(ctx context.Context, d models.WebhookDelivery) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, response_status = $4, last_error = $5,
+			next_attempt_at = $6, delivered_at = $7
+		WHERE id = $1
+	`, d.ID, d.Status, d.Attempts, d.ResponseStatus, d.LastError, d.NextAttemptAt, d.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("error recording webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries returns the most recent deliveries of a webhook,
+// optionally filtered by status.
+func (r *Repo) ListWebhookDeliveries(ctx context.Context, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, webhook_id, event_type, payload, status, attempts, response_status,
+			last_error, next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, webhookID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing webhook deliveries: %w", err)
+	}
+
+	return scanWebhookDeliveries(rows, false)
+}
+
+func scanWebhookDeliveries(rows pgx.Rows, withTarget bool) ([]models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		dest := []any{&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.ResponseStatus,
+			&d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt}
+		if withTarget {
+			dest = append(dest, &d.URL, &d.Secret)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery row: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading webhook delivery rows: %w", err)
+	}
+
+	return deliveries, nil
+}
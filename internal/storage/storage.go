@@ -6,9 +6,17 @@ import (
 )
 
 var (
-	ErrUsernameExists    = errors.New("username already exists")
-	ErrUserNotFound      = errors.New("user not found")
-	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrUsernameExists             = errors.New("username already exists")
+	ErrUserNotFound               = errors.New("user not found")
+	ErrInsufficientFunds          = errors.New("insufficient funds")
+	ErrTransactionNotFound        = errors.New("transaction not found")
+	ErrPaymentRequestNotFound     = errors.New("payment request not found")
+	ErrAlreadyReversed            = errors.New("transaction already reversed")
+	ErrFraudFlagNotFound          = errors.New("fraud flag not found")
+	ErrWebhookNotFound            = errors.New("webhook not found")
+	ErrRefreshTokenNotFound       = errors.New("refresh token not found")
+	ErrPasswordResetTokenNotFound = errors.New("password reset token not found")
+	ErrAPIKeyNotFound             = errors.New("api key not found")
 )
 
 type Getter interface {
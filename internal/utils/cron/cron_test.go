@@ -0,0 +1,46 @@
+package cron_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nglmq/avito-shop/internal/utils/cron"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2025, time.February, 14, 10, 30, 0, 0, time.UTC) // Friday
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"*/15 * * * *", time.Date(2025, time.February, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2025, time.February, 17, 9, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, time.February, 15, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2025, time.February, 15, 12, 0, 0, 0, time.UTC)},
+		{"30 10 * * 1-5", time.Date(2025, time.February, 17, 10, 30, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := cron.Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if next := s.Next(from); !next.Equal(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := cron.Parse(spec); !errors.Is(err, cron.ErrInvalidSchedule) {
+			t.Errorf("expected ErrInvalidSchedule for %q, got %v", spec, err)
+		}
+	}
+}
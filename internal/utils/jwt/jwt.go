@@ -1,53 +1,101 @@
 package ujwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
-	"os"
 	"time"
 )
 
-const TokenExp = time.Hour * 3
+// TokenExp is the default lifetime of an access token. Sessions outlive it
+// through refresh tokens.
+const TokenExp = 15 * time.Minute
 
-var SecretKey = os.Getenv("SECRET_JWT_KEY")
+var ErrMissingTokenID = errors.New("token has no id")
 
 type Claims struct {
 	jwt.RegisteredClaims
 	UserID string
+	Role   string
 }
 
-func BuildJWTString(uuid string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+// BuildJWTString signs an access token for uuid with the given role that
+// expires after ttl. Each token gets a random id (jti), so that it can be
+// revoked before it expires, and names the signing key in its kid header.
+func BuildJWTString(uuid, role string, ttl time.Duration) (string, Claims, error) {
+	ks, err := keySet()
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	id, err := newTokenID()
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	now := time.Now()
+	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenExp)),
+			ID:        id,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		},
 		UserID: uuid,
-	})
+		Role:   role,
+	}
 
-	tokenString, err := token.SignedString([]byte(SecretKey))
+	token := jwt.NewWithClaims(ks.signingMethod(), claims)
+	token.Header["kid"] = ks.signingKid
+
+	tokenString, err := token.SignedString(ks.signing)
 	if err != nil {
-		return "", err
+		return "", Claims{}, err
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
 
-func GetUserID(tokenString string) (string, error) {
-	claims := &Claims{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return []byte(SecretKey), nil
-	})
+// ParseToken verifies the token against the key named by its kid header and
+// returns its claims. Tokens without an id predate revocation support and are
+// rejected.
+func ParseToken(tokenString string) (Claims, error) {
+	ks, err := keySet()
 	if err != nil {
-		return "", err
+		return Claims{}, err
+	}
+
+	claims := Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, &claims, ks.verificationKey)
+	if err != nil {
+		return Claims{}, err
 	}
 
 	if !token.Valid {
+		return Claims{}, errors.New("invalid token")
+	}
+	if claims.ID == "" {
+		return Claims{}, ErrMissingTokenID
+	}
+
+	return claims, nil
+}
+
+func GetUserID(tokenString string) (string, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
 		return "", err
 	}
 
 	return claims.UserID, nil
 }
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating token id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
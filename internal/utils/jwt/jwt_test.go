@@ -0,0 +1,172 @@
+package ujwt_test
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nglmq/avito-shop/internal/models"
+	ujwt "github.com/nglmq/avito-shop/internal/utils/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	return key
+}
+
+func useKeys(t *testing.T, signing crypto.Signer, verification ...crypto.PublicKey) *ujwt.KeySet {
+	t.Helper()
+	keys, err := ujwt.NewKeySet(signing, verification...)
+	if err != nil {
+		t.Fatalf("building key set: %v", err)
+	}
+	ujwt.SetKeySet(keys)
+	t.Cleanup(func() { ujwt.SetKeySet(nil) })
+	return keys
+}
+
+func TestSignAndParse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+	}{
+		{name: "RS256", key: rsaKey, alg: "RS256"},
+		{name: "EdDSA", key: newEd25519Key(t), alg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := useKeys(t, tt.key)
+
+			token, claims, err := ujwt.BuildJWTString("alice", models.RoleAdmin, time.Minute)
+			assert.NoError(t, err)
+
+			parsed, _, err := new(jwt.Parser).ParseUnverified(token, &ujwt.Claims{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.alg, parsed.Header["alg"])
+			assert.Equal(t, keys.JWKS().Keys[0].Kid, parsed.Header["kid"])
+
+			got, err := ujwt.ParseToken(token)
+			assert.NoError(t, err)
+			assert.Equal(t, "alice", got.UserID)
+			assert.Equal(t, models.RoleAdmin, got.Role)
+			assert.Equal(t, claims.ID, got.ID)
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKey := newEd25519Key(t), newEd25519Key(t)
+
+	useKeys(t, oldKey)
+	oldToken, _, err := ujwt.BuildJWTString("alice", models.RoleUser, time.Minute)
+	assert.NoError(t, err)
+
+	keys := useKeys(t, newKey, oldKey.Public())
+	assert.Len(t, keys.JWKS().Keys, 2)
+
+	_, err = ujwt.ParseToken(oldToken)
+	assert.NoError(t, err, "tokens signed with the previous key should stay valid")
+
+	useKeys(t, newKey)
+	_, err = ujwt.ParseToken(oldToken)
+	assert.ErrorIs(t, err, ujwt.ErrUnknownKey)
+}
+
+func TestParseRejectsForeignTokens(t *testing.T) {
+	key := newEd25519Key(t)
+	useKeys(t, key)
+
+	kid := func() string {
+		token, _, _ := ujwt.BuildJWTString("alice", models.RoleUser, time.Minute)
+		parsed, _, _ := new(jwt.Parser).ParseUnverified(token, &ujwt.Claims{})
+		return parsed.Header["kid"].(string)
+	}()
+
+	claims := ujwt.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ID: "jti", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		UserID:           "mallory",
+	}
+
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hmac.Header["kid"] = kid
+	token, err := hmac.SignedString([]byte(""))
+	assert.NoError(t, err)
+	_, err = ujwt.ParseToken(token)
+	assert.Error(t, err, "an HMAC token naming our key must be rejected")
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	forged.Header["kid"] = kid
+	token, err = forged.SignedString(newEd25519Key(t))
+	assert.NoError(t, err)
+	_, err = ujwt.ParseToken(token)
+	assert.Error(t, err)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("writing key: %v", err)
+		}
+		return path
+	}
+
+	signing := newEd25519Key(t)
+	der, err := x509.MarshalPKCS8PrivateKey(signing)
+	assert.NoError(t, err)
+	signingFile := write("signing.pem", "PRIVATE KEY", der)
+
+	der, err = x509.MarshalPKIXPublicKey(newEd25519Key(t).Public())
+	assert.NoError(t, err)
+	previousFile := write("previous.pub", "PUBLIC KEY", der)
+
+	keys, err := ujwt.LoadKeySet(signingFile, []string{previousFile})
+	assert.NoError(t, err)
+	jwks := keys.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+		assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	}
+
+	_, err = ujwt.LoadKeySet("", nil)
+	assert.ErrorIs(t, err, ujwt.ErrNoSigningKey)
+
+	_, err = ujwt.LoadKeySet(previousFile, nil)
+	assert.ErrorContains(t, err, "private key")
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	weakFile := write("weak.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(weak))
+	_, err = ujwt.LoadKeySet(weakFile, nil)
+	assert.True(t, err != nil && strings.Contains(err.Error(), "2048"))
+}
+
+func TestNoKeyConfigured(t *testing.T) {
+	ujwt.SetKeySet(nil)
+
+	_, _, err := ujwt.BuildJWTString("alice", models.RoleUser, time.Minute)
+	assert.ErrorIs(t, err, ujwt.ErrNoSigningKey)
+}
@@ -0,0 +1,259 @@
+package ujwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const minRSAKeyBits = 2048
+
+var (
+	ErrNoSigningKey = errors.New("no JWT signing key configured")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+var (
+	keysMu  sync.RWMutex
+	current *KeySet
+)
+
+// KeySet holds the private key new tokens are signed with and the public keys
+// tokens are accepted from. Keeping the previous signing key among the
+// verification keys lets tokens it signed stay valid through a rotation.
+type KeySet struct {
+	signing    crypto.Signer
+	signingKid string
+	verify     map[string]crypto.PublicKey
+	order      []string
+}
+
+// JWK is the public part of a key as published in the JWKS document.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// SetKeySet makes ks the key set BuildJWTString and ParseToken use.
+func SetKeySet(ks *KeySet) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	current = ks
+}
+
+func keySet() (*KeySet, error) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	if current == nil {
+		return nil, ErrNoSigningKey
+	}
+	return current, nil
+}
+
+// NewKeySet builds a key set signing with an RSA or Ed25519 private key and
+// also accepting tokens signed by the keys in verification.
+func NewKeySet(signing crypto.Signer, verification ...crypto.PublicKey) (*KeySet, error) {
+	if signing == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	ks := &KeySet{
+		signing: signing,
+		verify:  make(map[string]crypto.PublicKey),
+	}
+	kid, err := ks.add(signing.Public())
+	if err != nil {
+		return nil, fmt.Errorf("signing key: %w", err)
+	}
+	ks.signingKid = kid
+
+	for _, pub := range verification {
+		if _, err := ks.add(pub); err != nil {
+			return nil, fmt.Errorf("verification key: %w", err)
+		}
+	}
+
+	return ks, nil
+}
+
+// LoadKeySet reads PEM-encoded keys: a private key to sign with and any
+// number of public or private keys to verify with.
+func LoadKeySet(signingFile string, verificationFiles []string) (*KeySet, error) {
+	if signingFile == "" {
+		return nil, ErrNoSigningKey
+	}
+
+	key, err := readKey(signingFile)
+	if err != nil {
+		return nil, err
+	}
+	signing, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: signing key must be a private key", signingFile)
+	}
+
+	var verification []crypto.PublicKey
+	for _, file := range verificationFiles {
+		key, err := readKey(file)
+		if err != nil {
+			return nil, err
+		}
+		if signer, ok := key.(crypto.Signer); ok {
+			key = signer.Public()
+		}
+		verification = append(verification, key)
+	}
+
+	return NewKeySet(signing, verification...)
+}
+
+// JWKS returns the verification keys, signing key first.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.order))}
+	for _, kid := range ks.order {
+		jwk, _ := publicJWK(ks.verify[kid])
+		jwk.Kid = kid
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+func (ks *KeySet) add(pub crypto.PublicKey) (string, error) {
+	jwk, err := publicJWK(pub)
+	if err != nil {
+		return "", err
+	}
+	kid := thumbprint(jwk)
+	if _, ok := ks.verify[kid]; !ok {
+		ks.verify[kid] = pub
+		ks.order = append(ks.order, kid)
+	}
+
+	return kid, nil
+}
+
+func (ks *KeySet) signingMethod() jwt.SigningMethod {
+	if _, ok := ks.signing.Public().(ed25519.PublicKey); ok {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// verificationKey returns the key named by the token's kid header, as long as
+// the token's algorithm is the one that key signs with.
+func (ks *KeySet) verificationKey(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	pub, ok := ks.verify[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+
+	jwk, _ := publicJWK(pub)
+	if t.Method.Alg() != jwk.Alg {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+
+	return pub, nil
+}
+
+func publicJWK(pub crypto.PublicKey) (JWK, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return JWK{}, fmt.Errorf("RSA key must have at least %d bits", minRSAKeyBits)
+		}
+		return JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: jwt.SigningMethodEdDSA.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T, want RSA or Ed25519", pub)
+	}
+}
+
+// thumbprint computes the RFC 7638 thumbprint of the key, used as its kid.
+func thumbprint(jwk JWK) string {
+	var members any
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	b, _ := json.Marshal(members)
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func readKey(file string) (any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", file)
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", file, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,47 @@
+// Package txhook defers side effects, such as notifications, until the
+// database transaction that caused them has committed.
+package txhook
+
+import "context"
+
+type hooksKey struct{}
+
+// Hooks collects the functions deferred within one transaction.
+type Hooks struct {
+	parent *Hooks
+	fns    []func()
+}
+
+// Begin starts collecting hooks for a transaction. Transactions begun inside
+// another one hand their hooks to the outer transaction on commit, so nothing
+// runs until the outermost commit.
+func Begin(ctx context.Context) (context.Context, *Hooks) {
+	parent, _ := ctx.Value(hooksKey{}).(*Hooks)
+	h := &Hooks{parent: parent}
+	return context.WithValue(ctx, hooksKey{}, h), h
+}
+
+// Commit runs the collected hooks, or passes them to the enclosing
+// transaction. Hooks of a transaction that is rolled back are simply never
+// committed.
+func (h *Hooks) Commit() {
+	if h.parent != nil {
+		h.parent.fns = append(h.parent.fns, h.fns...)
+		return
+	}
+
+	for _, fn := range h.fns {
+		fn()
+	}
+}
+
+// AfterCommit runs fn once the transaction bound to ctx commits, or right
+// away when ctx carries no transaction.
+func AfterCommit(ctx context.Context, fn func()) {
+	if h, ok := ctx.Value(hooksKey{}).(*Hooks); ok {
+		h.fns = append(h.fns, fn)
+		return
+	}
+
+	fn()
+}
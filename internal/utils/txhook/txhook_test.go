@@ -0,0 +1,43 @@
+package txhook_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nglmq/avito-shop/internal/utils/txhook"
+)
+
+func TestAfterCommit(t *testing.T) {
+	var ran []string
+
+	txhook.AfterCommit(context.Background(), func() { ran = append(ran, "no tx") })
+	if len(ran) != 1 {
+		t.Fatalf("expected hook outside a transaction to run immediately, got %v", ran)
+	}
+
+	outerCtx, outer := txhook.Begin(context.Background())
+	txhook.AfterCommit(outerCtx, func() { ran = append(ran, "outer") })
+
+	innerCtx, inner := txhook.Begin(outerCtx)
+	txhook.AfterCommit(innerCtx, func() { ran = append(ran, "inner") })
+	inner.Commit()
+
+	rolledBackCtx, _ := txhook.Begin(outerCtx)
+	txhook.AfterCommit(rolledBackCtx, func() { ran = append(ran, "rolled back") })
+
+	if len(ran) != 1 {
+		t.Fatalf("expected no hooks before the outer commit, got %v", ran)
+	}
+
+	outer.Commit()
+	want := []string{"no tx", "outer", "inner"}
+	if len(ran) != len(want) {
+		t.Fatalf("expected %v, got %v", want, ran)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, ran)
+			break
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Run calls fn every interval until ctx is cancelled. Errors are logged and
+// do not stop the loop.
+func Run(ctx context.Context, logger *slog.Logger, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Info("Starting background worker",
+		slog.String("worker", name),
+		slog.Duration("interval", interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Stopping background worker", slog.String("worker", name))
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				logger.Error("Background worker run failed",
+					slog.String("worker", name),
+					slog.String("error", err.Error()))
+			}
+		}
+	}
+}
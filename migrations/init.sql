@@ -3,9 +3,14 @@ CREATE TABLE IF NOT EXISTS users (
     username VARCHAR(255) UNIQUE NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE,
+    role VARCHAR(32) NOT NULL DEFAULT 'user'
 );
 
+ALTER TABLE users ADD COLUMN IF NOT EXISTS leaderboard_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';
+
 CREATE TABLE IF NOT EXISTS balances (
     id SERIAL PRIMARY KEY,
     username VARCHAR(255) REFERENCES users(username),
@@ -22,14 +27,221 @@ CREATE TABLE IF NOT EXISTS purchases (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE IF NOT EXISTS transfer_batches (
+    id SERIAL PRIMARY KEY,
+    sender_username VARCHAR(255) REFERENCES users(username),
+    total_amount INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE TABLE IF NOT EXISTS transactions (
     id SERIAL PRIMARY KEY,
     sender_username VARCHAR(255) REFERENCES users(username),
     receiver_username VARCHAR(255) REFERENCES users(username),
     amount INT NOT NULL,
+    fee INT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'completed',
+    kind VARCHAR(16) NOT NULL DEFAULT 'transfer',
+    expires_at TIMESTAMP,
+    resolved_at TIMESTAMP,
+    batch_id INT REFERENCES transfer_batches(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'completed',
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS batch_id INT REFERENCES transfer_batches(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'transfer';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+    id SERIAL PRIMARY KEY,
+    sender_username VARCHAR(255) REFERENCES users(username),
+    receiver_username VARCHAR(255) REFERENCES users(username),
+    amount INT NOT NULL CHECK (amount > 0),
+    schedule VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    next_run_at TIMESTAMP NOT NULL,
+    last_run_at TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    failure_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS payment_requests (
+    id SERIAL PRIMARY KEY,
+    requester_username VARCHAR(255) REFERENCES users(username),
+    payer_username VARCHAR(255) REFERENCES users(username),
+    amount INT NOT NULL CHECK (amount > 0),
+    note VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    transaction_id INT REFERENCES transactions(id),
+    expires_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS transaction_reversals (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT UNIQUE NOT NULL REFERENCES transactions(id),
+    compensating_transaction_id INT NOT NULL REFERENCES transactions(id),
+    amount INT NOT NULL,
+    shortfall INT NOT NULL DEFAULT 0,
+    reason TEXT NOT NULL,
+    admin_username VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS fraud_flags (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT UNIQUE NOT NULL REFERENCES transactions(id),
+    rules TEXT[] NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    reviewed_by VARCHAR(255),
+    review_note TEXT NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL REFERENCES webhooks(id),
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    response_status INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(64) NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMP,
+    parked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS parked_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    params JSONB,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '',
+    outcome VARCHAR(20) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    family_id VARCHAR(64) NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    access_token_id VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    username VARCHAR(255) NOT NULL REFERENCES users(username),
+    created_by VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key VARCHAR(320) PRIMARY KEY,
+    failures INT NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    prefix VARCHAR(16) NOT NULL,
+    username VARCHAR(255) NOT NULL REFERENCES users(username),
+    name VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 CREATE INDEX IF NOT EXISTS idx_transactions_sender_username ON transactions(sender_username);
-CREATE INDEX IF NOT EXISTS idx_transactions_receiver_username ON transactions(receiver_username);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_transactions_receiver_username ON transactions(receiver_username);
+CREATE INDEX IF NOT EXISTS idx_transactions_pending_expires_at ON transactions(expires_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_sender_username ON scheduled_transfers(sender_username);
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON scheduled_transfers(next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_payment_requests_payer_username ON payment_requests(payer_username);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_requester_username ON payment_requests(requester_username);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_pending_expires_at ON payment_requests(expires_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_transactions_batch_id ON transactions(batch_id) WHERE batch_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_sender_created_at ON transactions(sender_username, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_receiver_created_at ON transactions(receiver_username, created_at);
+CREATE INDEX IF NOT EXISTS idx_fraud_flags_status ON fraud_flags(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_purchases_username_created_at ON purchases(username, created_at);
+CREATE INDEX IF NOT EXISTS idx_purchases_username_id ON purchases(username, id);
+CREATE INDEX IF NOT EXISTS idx_purchases_item_name_created_at ON purchases(item_name, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, id);
+DROP INDEX IF EXISTS idx_outbox_undelivered;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(id) WHERE delivered_at IS NULL AND parked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_access_token_id ON refresh_tokens(access_token_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_username ON password_reset_tokens(username);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_expires_at ON password_reset_tokens(expires_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure_at ON login_attempts(last_failure_at);
+CREATE INDEX IF NOT EXISTS idx_api_keys_username ON api_keys(username);
\ No newline at end of file
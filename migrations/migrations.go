@@ -0,0 +1,9 @@
+// Package migrations holds the database schema. init.sql is mounted into the
+// postgres container on first start and applied again by the service on every
+// start, so each statement in it must be idempotent.
+package migrations
+
+import _ "embed"
+
+//go:embed init.sql
+var Schema string